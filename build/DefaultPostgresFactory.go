@@ -3,12 +3,19 @@ package build
 import (
 	cref "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
 	cbuild "github.com/pip-services3-gox/pip-services3-components-gox/build"
-	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/connect"
+	pcache "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/cache"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+	plock "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/lock"
+	pstate "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/state"
 )
 
 // DefaultPostgresFactory creates Postgres components by their descriptors.
 //	see Factory
 //	see PostgresConnection
+//	see PostgresHealthCheck
+//	see PostgresLock
+//	see PostgresCache
+//	see PostgresStateStore
 type DefaultPostgresFactory struct {
 	*cbuild.Factory
 }
@@ -22,5 +29,17 @@ func NewDefaultPostgresFactory() *DefaultPostgresFactory {
 	postgresConnectionDescriptor := cref.NewDescriptor("pip-services", "connection", "postgres", "*", "1.0")
 	c.RegisterType(postgresConnectionDescriptor, conn.NewPostgresConnection)
 
+	postgresHealthCheckDescriptor := cref.NewDescriptor("pip-services", "health-check", "postgres", "*", "1.0")
+	c.RegisterType(postgresHealthCheckDescriptor, conn.NewPostgresHealthCheck)
+
+	postgresLockDescriptor := cref.NewDescriptor("pip-services", "lock", "postgres", "*", "1.0")
+	c.RegisterType(postgresLockDescriptor, plock.NewPostgresLock)
+
+	postgresCacheDescriptor := cref.NewDescriptor("pip-services", "cache", "postgres", "*", "1.0")
+	c.RegisterType(postgresCacheDescriptor, pcache.NewPostgresCache[any])
+
+	postgresStateStoreDescriptor := cref.NewDescriptor("pip-services", "state-store", "postgres", "*", "1.0")
+	c.RegisterType(postgresStateStoreDescriptor, pstate.NewPostgresStateStore[any])
+
 	return c
 }
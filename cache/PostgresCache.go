@@ -0,0 +1,457 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	cref "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+)
+
+// Defaults of the cache configuration.
+const (
+	DefaultCacheTable           = "cache"
+	DefaultCacheTimeout         = 60000
+	DefaultCacheCleanupInterval = 60000
+)
+
+// PostgresCache is a distributed cache that stores values in an UNLOGGED PostgreSQL table,
+// so services without Redis or Memcached can share cached values through the database they already use.
+//
+// Values are stored as JSON with their expiration time, which is calculated by the database clock,
+// so instances with skewed clocks agree on it. Expired values are not returned and removed
+// by a background cleanup. The table is created when the component is opened. Unlogged tables
+// are not written to the write-ahead log, so they are faster, but truncated after a crash and not replicated.
+//
+//	Configuration parameters
+//		- table:                    (optional) a name of the cache table (default: cache)
+//		- schema:                   (optional) a schema of the cache table (default: the search path)
+//		- connection(s):
+//			- discovery_key:        (optional) a key to retrieve the connection from IDiscovery
+//			- host:                 host name or IP address
+//			- port:                 port number (default: 5432)
+//			- uri:                  resource URI or connection string with all parameters in it
+//		- credential(s):
+//			- store_key:            (optional) a key to retrieve the credentials from ICredentialStore
+//			- username:             (optional) user name
+//			- password:             (optional) user password
+//		- dependencies:
+//			- connection:           (optional) descriptor of the shared PostgresConnection (default: *:connection:postgres:*:1.0)
+//		- options:
+//			- timeout:              (optional) default caching timeout in milliseconds (default: 60000)
+//			- cleanup_interval:     (optional) number of milliseconds between removals of expired values, 0 to disable (default: 60000)
+//
+//	References
+//		- *:logger:*:*:1.0            (optional) ILogger components to pass log messages
+//		- *:connection:postgres:*:1.0 (optional) shared PostgresConnection, otherwise a local connection is created
+//		- *:discovery:*:*:1.0         (optional) IDiscovery services
+//		- *:credential-store:*:*:1.0  (optional) Credential stores to resolve credentials
+//
+//	Example:
+//		cache := cache.NewPostgresCache[string]()
+//		cache.Configure(ctx, cconf.NewConfigParamsFromTuples(
+//			"connection.host", "localhost",
+//			"connection.port", 5432,
+//			"connection.database", "test",
+//		))
+//		_ = cache.Open(ctx, "123")
+//
+//		_, err := cache.Store(ctx, "123", "key1", "ABC", 10000)
+//		value, err := cache.Retrieve(ctx, "123", "key1") // Result: "ABC"
+type PostgresCache[T any] struct {
+	defaultConfig *cconf.ConfigParams
+	config        *cconf.ConfigParams
+	references    cref.IReferences
+
+	// The dependency resolver.
+	DependencyResolver *cref.DependencyResolver
+	// The logger.
+	Logger *clog.CompositeLogger
+	// The PostgreSQL connection component.
+	Connection *conn.PostgresConnection
+	// The name of the cache table.
+	TableName string
+	// The schema of the cache table.
+	SchemaName string
+
+	localConnection bool
+	opened          bool
+	timeout         int64
+	cleanupInterval time.Duration
+	convertor       cconv.IJSONEngine[T]
+	cleanupLock     sync.Mutex
+	stopCleanup     context.CancelFunc
+}
+
+// NewPostgresCache creates a new instance of the cache component.
+//
+//	Returns: *PostgresCache
+func NewPostgresCache[T any]() *PostgresCache[T] {
+	c := &PostgresCache[T]{
+		defaultConfig: cconf.NewConfigParamsFromTuples(
+			"dependencies.connection", "*:connection:postgres:*:1.0",
+		),
+		Logger:          clog.NewCompositeLogger(),
+		TableName:       DefaultCacheTable,
+		timeout:         DefaultCacheTimeout,
+		cleanupInterval: DefaultCacheCleanupInterval * time.Millisecond,
+		convertor:       cconv.NewDefaultCustomTypeJsonConvertor[T](),
+	}
+	c.DependencyResolver = cref.NewDependencyResolver()
+	c.DependencyResolver.Configure(context.Background(), c.defaultConfig)
+	return c
+}
+
+// Configure component by passing configuration parameters.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- config configuration parameters to be set.
+func (c *PostgresCache[T]) Configure(ctx context.Context, config *cconf.ConfigParams) {
+	config = config.SetDefaults(c.defaultConfig)
+	c.config = config
+
+	c.DependencyResolver.Configure(ctx, config)
+	c.TableName = config.GetAsStringWithDefault("table", c.TableName)
+	c.SchemaName = config.GetAsStringWithDefault("schema", c.SchemaName)
+	c.timeout = config.GetAsLongWithDefault("options.timeout", c.timeout)
+	interval := config.GetAsIntegerWithDefault("options.cleanup_interval", int(c.cleanupInterval.Milliseconds()))
+	c.cleanupInterval = time.Duration(interval) * time.Millisecond
+}
+
+// SetReferences references to dependent components.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- references references to locate the component dependencies.
+func (c *PostgresCache[T]) SetReferences(ctx context.Context, references cref.IReferences) {
+	c.references = references
+	c.Logger.SetReferences(ctx, references)
+	c.DependencyResolver.SetReferences(ctx, references)
+
+	if dep, ok := c.DependencyResolver.GetOneOptional("connection").(*conn.PostgresConnection); ok {
+		c.Connection = dep
+		c.localConnection = false
+	}
+	// Or create a local one
+	if c.Connection == nil {
+		c.Connection = c.createConnection(ctx)
+		c.localConnection = true
+	}
+}
+
+// UnsetReferences (clears) previously set references to dependent components.
+func (c *PostgresCache[T]) UnsetReferences() {
+	c.Connection = nil
+}
+
+func (c *PostgresCache[T]) createConnection(ctx context.Context) *conn.PostgresConnection {
+	connection := conn.NewPostgresConnection()
+	if c.config != nil {
+		connection.Configure(ctx, c.config)
+	}
+	if c.references != nil {
+		connection.SetReferences(ctx, c.references)
+	}
+	return connection
+}
+
+// IsOpen checks if the component is opened.
+//
+//	Returns: true if the component has been opened and false otherwise.
+func (c *PostgresCache[T]) IsOpen() bool {
+	return c.opened
+}
+
+// Open the component, creates the cache table and starts the background cleanup.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occurred.
+func (c *PostgresCache[T]) Open(ctx context.Context, correlationId string) (err error) {
+	if c.opened {
+		return nil
+	}
+
+	if c.Connection == nil {
+		c.Connection = c.createConnection(ctx)
+		c.localConnection = true
+	}
+	if c.localConnection && !c.Connection.IsOpen() {
+		if err = c.Connection.Open(ctx, correlationId); err != nil {
+			return err
+		}
+	}
+	pool := c.Connection.GetConnection()
+	if pool == nil {
+		err = cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "PostgreSQL connection is not opened")
+	} else if err = c.createTable(ctx, pool); err != nil {
+		err = cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to create cache table "+c.quotedTableName()).
+			WithCause(err)
+	}
+	if err != nil {
+		// The local connection is not used when the component fails to open
+		if c.localConnection {
+			_ = c.Connection.Close(ctx, correlationId)
+		}
+		return err
+	}
+
+	if c.cleanupInterval > 0 {
+		cleanupCtx, cancel := context.WithCancel(context.Background())
+		c.stopCleanup = cancel
+		go c.runCleanup(cleanupCtx, correlationId)
+	}
+
+	c.opened = true
+	c.Logger.Debug(ctx, correlationId, "Opened postgres cache %s", c.quotedTableName())
+	return nil
+}
+
+// Close component and stops the background cleanup.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occurred.
+func (c *PostgresCache[T]) Close(ctx context.Context, correlationId string) (err error) {
+	if !c.opened {
+		return nil
+	}
+
+	if c.stopCleanup != nil {
+		c.stopCleanup()
+		c.stopCleanup = nil
+	}
+	// Waits for the running cleanup to complete
+	c.cleanupLock.Lock()
+	c.cleanupLock.Unlock()
+
+	if c.localConnection && c.Connection != nil {
+		if err = c.Connection.Close(ctx, correlationId); err != nil {
+			return err
+		}
+		c.Connection = nil
+	}
+	c.opened = false
+	return nil
+}
+
+// quotedTableName returns the quoted name of the cache table qualified with the schema.
+func (c *PostgresCache[T]) quotedTableName() string {
+	if c.SchemaName != "" {
+		return pgx.Identifier{c.SchemaName, c.TableName}.Sanitize()
+	}
+	return pgx.Identifier{c.TableName}.Sanitize()
+}
+
+// createTable creates the cache table and the index of expiration times when they don't exist.
+func (c *PostgresCache[T]) createTable(ctx context.Context, pool *pgxpool.Pool) error {
+	statements := []string{
+		"CREATE UNLOGGED TABLE IF NOT EXISTS " + c.quotedTableName() +
+			" (\"key\" TEXT PRIMARY KEY, \"value\" JSONB, \"expires_at\" TIMESTAMP WITH TIME ZONE NOT NULL)",
+		"CREATE INDEX IF NOT EXISTS " + pgx.Identifier{c.TableName + "_expires_at"}.Sanitize() +
+			" ON " + c.quotedTableName() + " (\"expires_at\")",
+	}
+	if c.SchemaName != "" {
+		statements = append([]string{"CREATE SCHEMA IF NOT EXISTS " + pgx.Identifier{c.SchemaName}.Sanitize()}, statements...)
+	}
+	for _, statement := range statements {
+		if _, err := pool.Exec(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getClient returns the connection pool or an error when the component is not opened.
+func (c *PostgresCache[T]) getClient(correlationId string) (*pgxpool.Pool, error) {
+	if c.opened && c.Connection != nil {
+		if pool := c.Connection.GetConnection(); pool != nil {
+			return pool, nil
+		}
+	}
+	return nil, cerr.NewInvalidStateError(correlationId, "NO_CONNECTION", "PostgreSQL connection is not opened")
+}
+
+// checkKey returns an error when the key is empty.
+func checkKey(correlationId string, key string) error {
+	if key == "" {
+		return cerr.NewInvalidStateError(correlationId, "INVALID_KEY", "key can not be empty string")
+	}
+	return nil
+}
+
+// Retrieve cached value from the cache using its key.
+// If value is missing in the cache or expired it returns the zero value.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- key a unique value key.
+//	Returns: a cached value or error.
+func (c *PostgresCache[T]) Retrieve(ctx context.Context, correlationId string, key string) (value T, err error) {
+	if err = checkKey(correlationId, key); err != nil {
+		return value, err
+	}
+	pool, err := c.getClient(correlationId)
+	if err != nil {
+		return value, err
+	}
+
+	var buf *string
+	err = pool.QueryRow(ctx, "SELECT \"value\"::text FROM "+c.quotedTableName()+
+		" WHERE \"key\"=$1 AND \"expires_at\">now()", key).Scan(&buf)
+	if err == pgx.ErrNoRows {
+		return value, nil
+	}
+	if err != nil {
+		return value, cerr.NewConnectionError(correlationId, "CACHE_FAILED", "Failed to retrieve cached value "+key).
+			WithDetails("key", key).
+			WithCause(err)
+	}
+	if buf == nil {
+		return value, nil
+	}
+	return c.convertor.FromJson(*buf)
+}
+
+// Store value in the cache with expiration time.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- key a unique value key.
+//		- value a value to store.
+//		- timeout expiration timeout in milliseconds, or 0 to use the configured timeout.
+//	Returns: the stored value or error.
+func (c *PostgresCache[T]) Store(ctx context.Context, correlationId string, key string, value T, timeout int64) (result T, err error) {
+	if err = checkKey(correlationId, key); err != nil {
+		return result, err
+	}
+	pool, err := c.getClient(correlationId)
+	if err != nil {
+		return result, err
+	}
+	if timeout <= 0 {
+		timeout = c.timeout
+	}
+
+	buf, err := c.convertor.ToJson(value)
+	if err != nil {
+		return result, err
+	}
+	_, err = pool.Exec(ctx, "INSERT INTO "+c.quotedTableName()+" (\"key\", \"value\", \"expires_at\")"+
+		" VALUES ($1, $2::jsonb, now() + $3 * interval '1 millisecond')"+
+		" ON CONFLICT (\"key\") DO UPDATE SET \"value\"=EXCLUDED.\"value\", \"expires_at\"=EXCLUDED.\"expires_at\"",
+		key, buf, timeout)
+	if err != nil {
+		return result, cerr.NewConnectionError(correlationId, "CACHE_FAILED", "Failed to store cached value "+key).
+			WithDetails("key", key).
+			WithCause(err)
+	}
+	return value, nil
+}
+
+// Remove a value from the cache by its key.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- key a unique value key.
+//	Returns: error or nil no errors occurred.
+func (c *PostgresCache[T]) Remove(ctx context.Context, correlationId string, key string) error {
+	if err := checkKey(correlationId, key); err != nil {
+		return err
+	}
+	pool, err := c.getClient(correlationId)
+	if err != nil {
+		return err
+	}
+
+	if _, err = pool.Exec(ctx, "DELETE FROM "+c.quotedTableName()+" WHERE \"key\"=$1", key); err != nil {
+		return cerr.NewConnectionError(correlationId, "CACHE_FAILED", "Failed to remove cached value "+key).
+			WithDetails("key", key).
+			WithCause(err)
+	}
+	return nil
+}
+
+// Contains checks if the cache contains a value with the key which is not expired.
+// Errors are logged and reported as missing values.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- key a unique value key.
+//	Returns: true if the value is cached.
+func (c *PostgresCache[T]) Contains(ctx context.Context, correlationId string, key string) bool {
+	if checkKey(correlationId, key) != nil {
+		return false
+	}
+	pool, err := c.getClient(correlationId)
+	if err != nil {
+		c.Logger.Warn(ctx, correlationId, "Failed to check cached value %s: %s", key, err.Error())
+		return false
+	}
+
+	var exists bool
+	err = pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM "+c.quotedTableName()+
+		" WHERE \"key\"=$1 AND \"expires_at\">now())", key).Scan(&exists)
+	if err != nil {
+		c.Logger.Warn(ctx, correlationId, "Failed to check cached value %s: %s", key, err.Error())
+		return false
+	}
+	return exists
+}
+
+// Cleanup removes expired values from the cache. It's called in background by options.cleanup_interval.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//	Returns: number of removed values or error.
+func (c *PostgresCache[T]) Cleanup(ctx context.Context, correlationId string) (int64, error) {
+	pool, err := c.getClient(correlationId)
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := pool.Exec(ctx, "DELETE FROM "+c.quotedTableName()+" WHERE \"expires_at\"<=now()")
+	if err != nil {
+		return 0, cerr.NewConnectionError(correlationId, "CACHE_FAILED", "Failed to remove expired values from "+c.quotedTableName()).
+			WithCause(err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// runCleanup removes expired values every cleanup interval until the context is canceled.
+func (c *PostgresCache[T]) runCleanup(ctx context.Context, correlationId string) {
+	ticker := time.NewTicker(c.cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		c.cleanupLock.Lock()
+		count, err := c.Cleanup(ctx, correlationId)
+		c.cleanupLock.Unlock()
+		if err != nil && ctx.Err() == nil {
+			c.Logger.Warn(ctx, correlationId, "Failed to clean up postgres cache: %s", err.Error())
+		} else if count > 0 {
+			c.Logger.Trace(ctx, correlationId, "Removed %d expired values from %s", count, c.quotedTableName())
+		}
+	}
+}
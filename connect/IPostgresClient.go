@@ -0,0 +1,44 @@
+package connect
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// IPostgresClient is a common interface for the PostgreSQL connection pool and transactions.
+// It allows persistence components to execute queries the same way regardless
+// of whether they run inside a transaction or directly against the pool.
+type IPostgresClient interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+type transactionContextKey struct{}
+
+// NewContextWithTransaction creates a child context which carries the given transaction.
+// Persistence components called with this context execute their queries within the transaction.
+//	Parameters:
+//		- ctx context.Context
+//		- tx transaction to bind to the context
+//	Returns: a new context.Context
+func NewContextWithTransaction(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, transactionContextKey{}, tx)
+}
+
+// TransactionFromContext retrieves a transaction previously bound to the context.
+//	Parameters:
+//		- ctx context.Context
+//	Returns: the transaction and true if it was found or nil and false otherwise.
+func TransactionFromContext(ctx context.Context) (pgx.Tx, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	tx, ok := ctx.Value(transactionContextKey{}).(pgx.Tx)
+	return tx, ok && tx != nil
+}
@@ -2,14 +2,26 @@ package connect
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"io"
 	"math"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
 
-	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
 	cref "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	cauth "github.com/pip-services3-gox/pip-services3-components-gox/auth"
+	ccount "github.com/pip-services3-gox/pip-services3-components-gox/count"
 	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
 )
 
@@ -17,6 +29,7 @@ import (
 //
 // By defining a connection and sharing it through multiple persistence components
 // you can reduce number of used database connections.
+// Passwords are masked in logged connection strings and in causes of returned errors.
 //
 //	Configuration parameters
 //		- connection(s):
@@ -24,6 +37,7 @@ import (
 //			- host:                 host name or IP address
 //			- port:                 port number (default: 27017)
 //			- uri:                  resource URI or connection string with all parameters in it
+//		- replica(s):               (optional) read replicas with the same parameters as connection(s)
 //		- credential(s):
 //			- store_key:            (optional) a key to retrieve the credentials from ICredentialStore
 //			- username:             user name
@@ -32,34 +46,101 @@ import (
 //			- connect_timeout:      (optional) number of milliseconds to wait before timing out when connecting a new client (default: 0)
 //			- idle_timeout:         (optional) number of milliseconds a client must sit idle in the pool and not be checked out (default: 10000)
 //			- max_pool_size:        (optional) maximum number of clients the pool should contain (default: 10)
+//			- min_pool_size:        (optional) number of connections established at open and kept in the pool (default: 0)
+//			- max_conn_lifetime:      (optional) number of milliseconds after which a connection is closed and replaced (default: 3600000)
+//			- max_conn_lifetime_jitter: (optional) random number of milliseconds added to max_conn_lifetime to avoid closing all connections at once (default: 0)
+//			- health_check_period:    (optional) number of milliseconds between health checks of idle connections (default: 60000)
+//			- statement_cache_capacity: (optional) number of prepared statements cached per connection, 0 to disable (default: 512)
+//			- debug:                  (optional) writes driver-level query logs into the logger with debug level (default: false)
+//			- validate_on_acquire:    (optional) pings connections before they are given out of the pool and replaces dead ones (default: false)
+//			- search_path:            (optional) schema search path set for every session
+//			- application_name:       (optional) application name shown in pg_stat_activity
+//			- timezone:               (optional) time zone set for every session
+//			- statement_timeout:      (optional) number of milliseconds after which a statement is aborted by the server
+//			- ssl_ca_file:            (optional) path to PEM file with trusted certificate authorities
+//			- ssl_cert_file:          (optional) path to PEM file with client certificate
+//			- ssl_key_file:           (optional) path to PEM file with client private key
+//			- ssl_verify:             (optional) verifies server certificate and host name (default: true)
+//			- replica_policy:         (optional) routing policy for read replicas: round_robin or least_loaded (default: round_robin)
+//			- metrics_interval:       (optional) number of milliseconds between reports of connection pool statistics, 0 to disable (default: 10000)
+//			- refresh_credentials:    (optional) resolves credentials for every new connection and again after authentication failures (default: false)
+//			- credentials_refresh_interval: (optional) number of milliseconds to cache resolved credentials, 0 to cache until authentication fails (default: 0)
+//			- auto_reconnect:         (optional) enables automatic reconnection of the connection and read replicas when they are lost (default: false)
+//			- reconnect_interval:     (optional) number of milliseconds between connection health checks (default: 5000)
+//			- reconnect_delay:        (optional) initial number of milliseconds to wait before reconnect attempt (default: 1000)
+//			- reconnect_max_delay:    (optional) maximum number of milliseconds to wait between reconnect attempts (default: 30000)
+//			- reconnect_jitter:       (optional) random deviation of the reconnect delay as a fraction from 0 to 1 (default: 0.2)
+//			- reconnect_max_attempts: (optional) maximum number of reconnect attempts, 0 for unlimited (default: 10)
 //
 //	References
 //		- *:logger:*:*:1.0           (optional) ILogger components to pass log messages
+//		- *:counters:*:*:1.0         (optional) ICounters components to pass collected measurements
 //		- *:discovery:*:*:1.0        (optional) IDiscovery services
 //		- *:credential-store:*:*:1.0 (optional) Credential stores to resolve credentials
 type PostgresConnection struct {
 	defaultConfig *cconf.ConfigParams
 	// The logger.
 	Logger *clog.CompositeLogger
+	// The performance counters.
+	Counters *ccount.CompositeCounters
 	// The connection resolver.
 	ConnectionResolver *PostgresConnectionResolver
 	// The configuration options.
 	Options *cconf.ConfigParams
 	// The PostgreSQL connection pool object.
 	Connection *pgxpool.Pool
+	// The PostgreSQL read replica connection pools.
+	Replicas []*pgxpool.Pool
 	// The PostgreSQL database name.
 	DatabaseName string
 
-	retries int
+	retries    int
+	lock       sync.RWMutex
+	poolConfig *pgxpool.Config
+	cancel     context.CancelFunc
+	closeCtx   context.Context
+
+	replicaResolvers []*PostgresConnectionResolver
+	replicaCounter   uint32
+
+	credentialLock       sync.Mutex
+	credential           *cauth.CredentialParams
+	credentialResolvedAt time.Time
+
+	hooksLock         sync.RWMutex
+	afterConnectHooks []PostgresAfterConnectHook
 }
 
 const (
-	DefaultConnectTimeout = 1000
-	DefaultIdleTimeout    = 10000
-	DefaultMaxPoolSize    = 3
-	DefaultRetriesCount   = 3
+	DefaultConnectTimeout       = 1000
+	DefaultIdleTimeout          = 10000
+	DefaultMaxPoolSize          = 3
+	DefaultRetriesCount         = 3
+	DefaultReconnectInterval    = 5000
+	DefaultReconnectDelay       = 1000
+	DefaultReconnectMaxDelay    = 30000
+	DefaultReconnectJitter      = 0.2
+	DefaultReconnectMaxAttempts = 10
+	DefaultMetricsInterval      = 10000
+
+	DefaultCredentialsRefreshInterval = 0
 )
 
+const (
+	// ReplicaPolicyRoundRobin distributes read queries between replicas in turn.
+	ReplicaPolicyRoundRobin = "round_robin"
+	// ReplicaPolicyLeastLoaded sends read queries to the replica with the least acquired connections.
+	ReplicaPolicyLeastLoaded = "least_loaded"
+)
+
+// sessionParameters maps configuration options to PostgreSQL session runtime parameters.
+var sessionParameters = map[string]string{
+	"search_path":       "search_path",
+	"application_name":  "application_name",
+	"timezone":          "TimeZone",
+	"statement_timeout": "statement_timeout",
+}
+
 // NewPostgresConnection creates a new instance of the connection component.
 func NewPostgresConnection() *PostgresConnection {
 	c := &PostgresConnection{
@@ -69,6 +150,7 @@ func NewPostgresConnection() *PostgresConnection {
 			"options.max_pool_size", DefaultMaxPoolSize,
 		),
 		Logger:             clog.NewCompositeLogger(),
+		Counters:           ccount.NewCompositeCounters(),
 		ConnectionResolver: NewPostgresConnectionResolver(),
 		Options:            cconf.NewEmptyConfigParams(),
 		retries:            DefaultRetriesCount,
@@ -84,6 +166,39 @@ func (c *PostgresConnection) Configure(ctx context.Context, config *cconf.Config
 	config = config.SetDefaults(c.defaultConfig)
 	c.ConnectionResolver.Configure(ctx, config)
 	c.Options = c.Options.Override(config.GetSection("options"))
+
+	c.replicaResolvers = make([]*PostgresConnectionResolver, 0)
+	for _, replicaConfig := range composeReplicaConfigs(config) {
+		resolver := NewPostgresConnectionResolver()
+		resolver.Configure(ctx, replicaConfig)
+		c.replicaResolvers = append(c.replicaResolvers, resolver)
+	}
+}
+
+// composeReplicaConfigs converts "replica(s)" sections into separate connection configurations
+// which share credentials and options with the primary connection.
+func composeReplicaConfigs(config *cconf.ConfigParams) []*cconf.ConfigParams {
+	sections := make([]*cconf.ConfigParams, 0)
+
+	replicas := config.GetSection("replicas")
+	if replicas.Len() > 0 {
+		for _, name := range replicas.GetSectionNames() {
+			sections = append(sections, replicas.GetSection(name))
+		}
+	} else if replica := config.GetSection("replica"); replica.Len() > 0 {
+		sections = append(sections, replica)
+	}
+
+	result := make([]*cconf.ConfigParams, 0, len(sections))
+	for _, section := range sections {
+		replicaConfig := cconf.NewEmptyConfigParams()
+		replicaConfig.AddSection("connection", section)
+		replicaConfig.AddSection("credential", config.GetSection("credential"))
+		replicaConfig.AddSection("credentials", config.GetSection("credentials"))
+		replicaConfig.AddSection("options", config.GetSection("options"))
+		result = append(result, replicaConfig)
+	}
+	return result
 }
 
 // SetReferences references to dependent components.
@@ -92,13 +207,17 @@ func (c *PostgresConnection) Configure(ctx context.Context, config *cconf.Config
 //		- references references to locate the component dependencies.
 func (c *PostgresConnection) SetReferences(ctx context.Context, references cref.IReferences) {
 	c.Logger.SetReferences(ctx, references)
+	c.Counters.SetReferences(ctx, references)
 	c.ConnectionResolver.SetReferences(ctx, references)
+	for _, resolver := range c.replicaResolvers {
+		resolver.SetReferences(ctx, references)
+	}
 }
 
 // IsOpen checks if the component is opened.
 //	Returns true if the component has been opened and false otherwise.
 func (c *PostgresConnection) IsOpen() bool {
-	return c.Connection != nil
+	return c.GetConnection() != nil
 }
 
 //	Open the component.
@@ -108,21 +227,124 @@ func (c *PostgresConnection) IsOpen() bool {
 //		- Return 			error or nil no errors occurred.
 func (c *PostgresConnection) Open(ctx context.Context, correlationId string) error {
 
-	uri, err := c.ConnectionResolver.Resolve(ctx, correlationId)
+	config, err := c.composePoolConfig(ctx, correlationId, c.ConnectionResolver)
 	if err != nil {
-		c.Logger.Error(ctx, correlationId, err, "Failed to resolve Postgres connection")
+		return err
+	}
+
+	c.Logger.Debug(ctx, correlationId, "Connecting to postgres")
+
+	retries := c.retries
+	for retries > 0 {
+		pool, err := connectPool(ctx, config)
+		if err != nil {
+			retries--
+			if retries <= 0 {
+				return cerr.
+					NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to postgres failed").
+					WithCause(c.redactError(err, config))
+			}
+			if isAuthenticationError(err) {
+				c.invalidateCredential()
+			}
+			c.Logger.Debug(ctx, correlationId, "Failed to connect to postgress, try reconnect...")
+			err = c.waitForRetry(ctx, correlationId, retries)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err = c.warmUp(ctx, correlationId, pool); err != nil {
+			pool.Close()
+			return err
+		}
+		c.lock.Lock()
+		c.Connection = pool
+		c.DatabaseName = config.ConnConfig.Database
+		c.poolConfig = config
+		c.lock.Unlock()
+		break
+	}
+
+	if err = c.openReplicas(ctx, correlationId); err != nil {
+		_ = c.Close(ctx, correlationId)
+		return err
+	}
+
+	monitorCtx, cancel := context.WithCancel(context.Background())
+	c.lock.Lock()
+	c.cancel = cancel
+	c.closeCtx = monitorCtx
+	c.lock.Unlock()
+
+	if c.Options.GetAsBooleanWithDefault("auto_reconnect", false) {
+		go c.monitorConnection(monitorCtx, correlationId)
+	}
+	if c.Options.GetAsIntegerWithDefault("metrics_interval", DefaultMetricsInterval) > 0 {
+		go c.collectMetrics(monitorCtx)
+	}
+	return nil
+}
+
+// warmUp establishes the minimum number of connections in the pool,
+// so the first requests after open do not wait for new connections.
+func (c *PostgresConnection) warmUp(ctx context.Context, correlationId string, pool *pgxpool.Pool) error {
+	minConns := int(pool.Config().MinConns)
+	if minConns <= 1 {
 		return nil
 	}
 
+	conns := make([]*pgxpool.Conn, 0, minConns)
+	defer func() {
+		for _, poolConn := range conns {
+			poolConn.Release()
+		}
+	}()
+
+	// Connections are held until all of them are established to force the pool to create new ones
+	for len(conns) < minConns {
+		poolConn, err := pool.Acquire(ctx)
+		if err != nil {
+			return cerr.
+				NewConnectionError(correlationId, "WARMUP_FAILED", "Failed to establish minimum number of connections to postgres").
+				WithDetails("min_pool_size", minConns).
+				WithDetails("established", len(conns)).
+				WithCause(c.redactError(err, pool.Config()))
+		}
+		conns = append(conns, poolConn)
+	}
+
+	c.Logger.Debug(ctx, correlationId, "Warmed up %d connections to postgres", minConns)
+	return nil
+}
+
+// composePoolConfig resolves connection parameters and composes configuration of the connection pool.
+func (c *PostgresConnection) composePoolConfig(ctx context.Context, correlationId string,
+	resolver *PostgresConnectionResolver) (*pgxpool.Config, error) {
+
+	uri, err := resolver.Resolve(ctx, correlationId)
+	if err != nil {
+		c.Logger.Error(ctx, correlationId, err, "Failed to resolve Postgres connection")
+		return nil, err
+	}
+
 	maxPoolSize := c.Options.GetAsIntegerWithDefault("max_pool_size", DefaultMaxPoolSize)
+	minPoolSize := c.Options.GetAsIntegerWithDefault("min_pool_size", 0)
 	idleTimeoutMS := c.Options.GetAsIntegerWithDefault("idle_timeout", DefaultIdleTimeout)
 	connectTimeoutMS := c.Options.GetAsIntegerWithDefault("connect_timeout", DefaultConnectTimeout)
+	maxConnLifetimeMS := c.Options.GetAsIntegerWithDefault("max_conn_lifetime", 0)
+	maxConnLifetimeJitterMS := c.Options.GetAsIntegerWithDefault("max_conn_lifetime_jitter", 0)
+	healthCheckPeriodMS := c.Options.GetAsIntegerWithDefault("health_check_period", 0)
 
+	// All pool settings must be applied before the pool is created
 	config, err := pgxpool.ParseConfig(uri)
 	if err != nil {
+		err = RedactError(err, uriPassword(uri))
 		c.Logger.Error(ctx, correlationId, err, "Failed to parse Postgres config string")
-		return nil
+		return nil, cerr.NewConfigError(correlationId, "INVALID_CONNECTION", "Invalid postgres connection string").
+			WithCause(err)
 	}
+	c.Logger.Trace(ctx, correlationId, "Resolved postgres connection %s", RedactUri(uri))
 
 	if connectTimeoutMS > 0 {
 		config.ConnConfig.ConnectTimeout = time.Duration((int64)(connectTimeoutMS)) * time.Millisecond
@@ -133,31 +355,62 @@ func (c *PostgresConnection) Open(ctx context.Context, correlationId string) err
 	if maxPoolSize > 0 {
 		config.MaxConns = (int32)(maxPoolSize)
 	}
+	if minPoolSize > 0 {
+		config.MinConns = (int32)(minPoolSize)
+		if config.MinConns > config.MaxConns {
+			config.MinConns = config.MaxConns
+		}
+	}
+	if maxConnLifetimeMS > 0 {
+		config.MaxConnLifetime = time.Duration((int64)(maxConnLifetimeMS)) * time.Millisecond
+	}
+	if maxConnLifetimeJitterMS > 0 {
+		config.MaxConnLifetimeJitter = time.Duration((int64)(maxConnLifetimeJitterMS)) * time.Millisecond
+	}
+	if healthCheckPeriodMS > 0 {
+		config.HealthCheckPeriod = time.Duration((int64)(healthCheckPeriodMS)) * time.Millisecond
+	}
 
-	c.Logger.Debug(ctx, correlationId, "Connecting to postgres")
+	if c.Options.GetAsBooleanWithDefault("refresh_credentials", false) {
+		config.BeforeConnect = c.applyCredential
+	}
+	config.AfterConnect = c.runAfterConnectHooks
+	if capacity, ok := c.Options.GetAsNullableInteger("statement_cache_capacity"); ok {
+		config.ConnConfig.StatementCacheCapacity = capacity
+		if capacity <= 0 {
+			// Without the cache statements are described on every execution
+			config.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeDescribeExec
+		}
+	}
+	if c.Options.GetAsBooleanWithDefault("debug", false) {
+		config.ConnConfig.Tracer = NewPostgresTracer(c.Logger)
+	}
+	if c.Options.GetAsBooleanWithDefault("validate_on_acquire", false) {
+		// Returning false destroys the connection and makes the pool acquire another one
+		config.BeforeAcquire = func(ctx context.Context, conn *pgx.Conn) bool {
+			return conn.Ping(ctx) == nil
+		}
+	}
 
-	retries := c.retries
-	for retries > 0 {
-		pool, err := pgxpool.ConnectConfig(ctx, config)
-		if err != nil {
-			retries--
-			if retries <= 0 {
-				return cerr.
-					NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to postgres failed").
-					WithCause(err)
-			}
-			c.Logger.Debug(ctx, correlationId, "Failed to connect to postgress, try reconnect...")
-			err = c.waitForRetry(ctx, correlationId, retries)
-			if err != nil {
-				return err
-			}
-			continue
+	// Session parameters are sent in the startup message of every new connection
+	for option, param := range sessionParameters {
+		if value := c.Options.GetAsString(option); value != "" {
+			config.ConnConfig.RuntimeParams[param] = value
 		}
-		c.Connection = pool
-		c.DatabaseName = config.ConnConfig.Database
-		break
 	}
-	return nil
+
+	tlsConfig, err := resolver.ResolveTLSConfig(correlationId)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		config.ConnConfig.TLSConfig = composeTLSConfig(tlsConfig, config.ConnConfig.Host)
+		for _, fallback := range config.ConnConfig.Fallbacks {
+			fallback.TLSConfig = composeTLSConfig(tlsConfig, fallback.Host)
+		}
+	}
+
+	return config, nil
 }
 
 // Close component and frees used resources.
@@ -166,17 +419,37 @@ func (c *PostgresConnection) Open(ctx context.Context, correlationId string) err
 //		- correlationId (optional) transaction id to trace execution through call chain.
 //	Returns: error or nil no errors occurred
 func (c *PostgresConnection) Close(ctx context.Context, correlationId string) error {
-	if c.Connection == nil {
+	c.lock.Lock()
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+		c.closeCtx = nil
+	}
+	pool := c.Connection
+	replicas := c.Replicas
+	c.Connection = nil
+	c.Replicas = nil
+	c.poolConfig = nil
+	c.lock.Unlock()
+
+	for _, replica := range replicas {
+		replica.Close()
+	}
+
+	if pool == nil {
 		return nil
 	}
-	c.Connection.Close()
+	pool.Close()
 	c.Logger.Debug(ctx, correlationId, "Disconnected from postgres database %s", c.DatabaseName)
-	c.Connection = nil
 	c.DatabaseName = ""
 	return nil
 }
 
+// GetConnection gets the current PostgreSQL connection pool.
+// The pool can be replaced when the connection is automatically restored.
 func (c *PostgresConnection) GetConnection() *pgxpool.Pool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
 	return c.Connection
 }
 
@@ -184,6 +457,679 @@ func (c *PostgresConnection) GetDatabaseName() string {
 	return c.DatabaseName
 }
 
+// Ping checks that the database is reachable over the opened connection pool.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//	Returns: ConnectionError if the database is unreachable or nil otherwise.
+func (c *PostgresConnection) Ping(ctx context.Context, correlationId string) error {
+	pool := c.GetConnection()
+	if pool == nil {
+		return cerr.NewInvalidStateError(correlationId, "NO_CONNECTION", "PostgreSQL connection is not opened")
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		return cerr.
+			NewConnectionError(correlationId, "PING_FAILED", "PostgreSQL database "+c.DatabaseName+" is not reachable").
+			WithCause(c.redactError(err, nil))
+	}
+	return nil
+}
+
+// openReplicas opens connection pools to configured read replicas.
+func (c *PostgresConnection) openReplicas(ctx context.Context, correlationId string) error {
+	replicas, err := c.connectReplicas(ctx, correlationId)
+	if err != nil {
+		return err
+	}
+
+	if len(replicas) > 0 {
+		c.Logger.Debug(ctx, correlationId, "Connected to %d postgres replica(s)", len(replicas))
+	}
+	c.replaceReplicas(replicas)
+	return nil
+}
+
+// connectReplicas creates connection pools to configured read replicas.
+// When any of them fails, the already created pools are closed.
+func (c *PostgresConnection) connectReplicas(ctx context.Context, correlationId string) ([]*pgxpool.Pool, error) {
+	replicas := make([]*pgxpool.Pool, 0, len(c.replicaResolvers))
+	for _, resolver := range c.replicaResolvers {
+		config, err := c.composePoolConfig(ctx, correlationId, resolver)
+		if err == nil {
+			var pool *pgxpool.Pool
+			if pool, err = connectPool(ctx, config); err == nil {
+				if err = c.warmUp(ctx, correlationId, pool); err == nil {
+					replicas = append(replicas, pool)
+					continue
+				}
+				pool.Close()
+				for _, replica := range replicas {
+					replica.Close()
+				}
+				return nil, err
+			}
+		}
+
+		for _, replica := range replicas {
+			replica.Close()
+		}
+		return nil, cerr.
+			NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to postgres replica failed").
+			WithCause(c.redactError(err, config))
+	}
+	return replicas, nil
+}
+
+// GetReadConnection gets a connection pool to execute read-only queries.
+// When read replicas are configured the pool is selected according to the replica_policy option,
+// otherwise the primary connection pool is returned.
+func (c *PostgresConnection) GetReadConnection() *pgxpool.Pool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if len(c.Replicas) == 0 {
+		return c.Connection
+	}
+
+	if c.Options.GetAsStringWithDefault("replica_policy", ReplicaPolicyRoundRobin) == ReplicaPolicyLeastLoaded {
+		var result *pgxpool.Pool
+		var minLoad float64
+		for _, replica := range c.Replicas {
+			stat := replica.Stat()
+			load := float64(stat.AcquiredConns())
+			if stat.MaxConns() > 0 {
+				load = load / float64(stat.MaxConns())
+			}
+			if result == nil || load < minLoad {
+				result = replica
+				minLoad = load
+			}
+		}
+		return result
+	}
+
+	index := atomic.AddUint32(&c.replicaCounter, 1)
+	return c.Replicas[int(index)%len(c.Replicas)]
+}
+
+// PostgresAfterConnectHook is a callback executed on every new connection of the pool
+// before it is used, e.g. to register custom data types.
+type PostgresAfterConnectHook func(ctx context.Context, conn *pgx.Conn) error
+
+// OnAfterConnect registers a hook executed on every new pooled connection, including read replicas.
+// Hooks are executed in order of registration. If a hook fails the connection is discarded.
+// Hooks shall be registered before the connection is opened, otherwise they only apply
+// to connections established after the registration.
+//	Parameters:
+//		- hook a callback to initialize the connection.
+func (c *PostgresConnection) OnAfterConnect(hook PostgresAfterConnectHook) {
+	if hook == nil {
+		return
+	}
+	c.hooksLock.Lock()
+	defer c.hooksLock.Unlock()
+	c.afterConnectHooks = append(c.afterConnectHooks, hook)
+}
+
+// runAfterConnectHooks executes registered hooks for a new connection.
+func (c *PostgresConnection) runAfterConnectHooks(ctx context.Context, conn *pgx.Conn) error {
+	c.hooksLock.RLock()
+	hooks := c.afterConnectHooks
+	c.hooksLock.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx, conn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PostgresNotificationHandler is a callback to receive notifications sent by NOTIFY command.
+type PostgresNotificationHandler func(ctx context.Context, channel string, payload string)
+
+// Listen subscribes to notifications sent to the channel by NOTIFY command.
+// It dedicates a separate connection outside of the pool, restores it when it is lost
+// and stops listening when the context is cancelled or the connection component is closed.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- channel name of the notification channel.
+//		- handler callback to receive notifications.
+//	Returns: error or nil if listening was successfully started.
+func (c *PostgresConnection) Listen(ctx context.Context, correlationId string, channel string,
+	handler PostgresNotificationHandler) error {
+
+	c.lock.RLock()
+	config := c.poolConfig
+	closeCtx := c.closeCtx
+	c.lock.RUnlock()
+
+	if config == nil || closeCtx == nil {
+		return cerr.NewInvalidStateError(correlationId, "NO_CONNECTION", "PostgreSQL connection is not opened")
+	}
+
+	listenCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-closeCtx.Done():
+			cancel()
+		case <-listenCtx.Done():
+		}
+	}()
+
+	listenConn, err := c.listen(listenCtx, config, channel)
+	if err != nil {
+		cancel()
+		return cerr.
+			NewConnectionError(correlationId, "LISTEN_FAILED", "Failed to listen postgres channel "+channel).
+			WithCause(c.redactError(err, config))
+	}
+
+	c.Logger.Debug(ctx, correlationId, "Started listening postgres channel %s", channel)
+	go c.receiveNotifications(listenCtx, cancel, correlationId, config, channel, listenConn, handler)
+	return nil
+}
+
+// Notify sends a notification to the channel.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- channel name of the notification channel.
+//		- payload notification payload.
+//	Returns: error or nil no errors occurred.
+func (c *PostgresConnection) Notify(ctx context.Context, correlationId string, channel string, payload string) error {
+	var client IPostgresClient
+	if tx, ok := TransactionFromContext(ctx); ok {
+		client = tx
+	} else if pool := c.GetConnection(); pool != nil {
+		client = pool
+	} else {
+		return cerr.NewInvalidStateError(correlationId, "NO_CONNECTION", "PostgreSQL connection is not opened")
+	}
+
+	if _, err := client.Exec(ctx, "SELECT pg_notify($1, $2)", channel, payload); err != nil {
+		return cerr.
+			NewConnectionError(correlationId, "NOTIFY_FAILED", "Failed to notify postgres channel "+channel).
+			WithCause(err)
+	}
+	return nil
+}
+
+// listen opens a dedicated connection and subscribes it to the channel.
+func (c *PostgresConnection) listen(ctx context.Context, config *pgxpool.Config, channel string) (*pgx.Conn, error) {
+	listenConn, err := pgx.ConnectConfig(ctx, config.ConnConfig.Copy())
+	if err != nil {
+		return nil, err
+	}
+	if _, err = listenConn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		_ = listenConn.Close(context.Background())
+		return nil, err
+	}
+	return listenConn, nil
+}
+
+// receiveNotifications delivers notifications to the handler and restores the listening connection when it is lost.
+func (c *PostgresConnection) receiveNotifications(ctx context.Context, cancel context.CancelFunc, correlationId string,
+	config *pgxpool.Config, channel string, listenConn *pgx.Conn, handler PostgresNotificationHandler) {
+
+	defer cancel()
+
+	for {
+		notification, err := listenConn.WaitForNotification(ctx)
+		if err == nil {
+			handler(ctx, notification.Channel, notification.Payload)
+			continue
+		}
+
+		_ = listenConn.Close(context.Background())
+		if ctx.Err() != nil {
+			c.Logger.Debug(ctx, correlationId, "Stopped listening postgres channel %s", channel)
+			return
+		}
+
+		c.Logger.Warn(ctx, correlationId, "Lost listening connection on postgres channel %s, reconnecting...", channel)
+		for attempt := 1; ; attempt++ {
+			select {
+			case <-time.After(c.getReconnectDelay(attempt)):
+			case <-ctx.Done():
+				return
+			}
+			if listenConn, err = c.listen(ctx, config, channel); err == nil {
+				c.Logger.Info(ctx, correlationId, "Restored listening on postgres channel %s", channel)
+				break
+			}
+			c.Logger.Debug(ctx, correlationId, "Reconnect attempt %d to listen postgres channel %s failed: %s", attempt, channel, c.redactError(err, config).Error())
+		}
+	}
+}
+
+// Begin starts a new transaction. If the context already carries a transaction
+// then a nested transaction (savepoint) is started within it.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//	Returns: started transaction or error.
+func (c *PostgresConnection) Begin(ctx context.Context, correlationId string) (pgx.Tx, error) {
+	var tx pgx.Tx
+	var err error
+
+	if parent, ok := TransactionFromContext(ctx); ok {
+		tx, err = parent.Begin(ctx)
+	} else {
+		pool := c.GetConnection()
+		if pool == nil {
+			return nil, cerr.NewInvalidStateError(correlationId, "NO_CONNECTION", "PostgreSQL connection is not opened")
+		}
+		tx, err = pool.Begin(ctx)
+	}
+
+	if err != nil {
+		return nil, cerr.
+			NewConnectionError(correlationId, "BEGIN_FAILED", "Failed to begin postgres transaction").
+			WithCause(err)
+	}
+	return tx, nil
+}
+
+// Commit commits the transaction.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- tx transaction to be committed.
+//	Returns: error or nil no errors occurred.
+func (c *PostgresConnection) Commit(ctx context.Context, correlationId string, tx pgx.Tx) error {
+	if err := tx.Commit(ctx); err != nil {
+		return cerr.
+			NewConnectionError(correlationId, "COMMIT_FAILED", "Failed to commit postgres transaction").
+			WithCause(err)
+	}
+	return nil
+}
+
+// Rollback rolls back the transaction. Rolling back already finished transaction is not an error.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- tx transaction to be rolled back.
+//	Returns: error or nil no errors occurred.
+func (c *PostgresConnection) Rollback(ctx context.Context, correlationId string, tx pgx.Tx) error {
+	if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		return cerr.
+			NewConnectionError(correlationId, "ROLLBACK_FAILED", "Failed to rollback postgres transaction").
+			WithCause(err)
+	}
+	return nil
+}
+
+// WithTransaction executes the function within a transaction.
+// The function receives a context carrying the transaction, so all persistence
+// operations called with this context are executed within it.
+// The transaction is committed when the function returns no error and rolled back otherwise.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- fn function to be executed within the transaction.
+//	Returns: error returned by the function or by the transaction.
+func (c *PostgresConnection) WithTransaction(ctx context.Context, correlationId string,
+	fn func(ctx context.Context, tx pgx.Tx) error) (err error) {
+
+	tx, err := c.Begin(ctx, correlationId)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = c.Rollback(ctx, correlationId, tx)
+			panic(r)
+		}
+	}()
+
+	if err = fn(NewContextWithTransaction(ctx, tx), tx); err != nil {
+		if rbErr := c.Rollback(ctx, correlationId, tx); rbErr != nil {
+			c.Logger.Error(ctx, correlationId, rbErr, "Failed to rollback transaction")
+		}
+		return err
+	}
+
+	return c.Commit(ctx, correlationId, tx)
+}
+
+// monitorConnection periodically checks the connection and restores it when it is lost.
+func (c *PostgresConnection) monitorConnection(ctx context.Context, correlationId string) {
+	interval := c.Options.GetAsIntegerWithDefault("reconnect_interval", DefaultReconnectInterval)
+	if interval <= 0 {
+		interval = DefaultReconnectInterval
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pool := c.GetConnection()
+			if pool == nil {
+				return
+			}
+
+			pingCtx, cancel := context.WithTimeout(ctx, time.Duration(interval)*time.Millisecond)
+			err := c.pingPools(pingCtx, pool)
+			cancel()
+			if err == nil || ctx.Err() != nil {
+				continue
+			}
+
+			c.Logger.Warn(ctx, correlationId, "Lost connection to postgres database %s, reconnecting...", c.DatabaseName)
+			if err = c.reconnect(ctx, correlationId); err != nil && ctx.Err() == nil {
+				c.Logger.Error(ctx, correlationId, err, "Failed to restore connection to postgres")
+			}
+		}
+	}
+}
+
+// pingPools checks the primary connection pool and the read replica pools.
+func (c *PostgresConnection) pingPools(ctx context.Context, pool *pgxpool.Pool) error {
+	if err := pool.Ping(ctx); err != nil {
+		return err
+	}
+
+	c.lock.RLock()
+	replicas := c.Replicas
+	c.lock.RUnlock()
+	for _, replica := range replicas {
+		if err := replica.Ping(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconnect creates a new connection pool with exponential backoff between attempts
+// and replaces the lost pool with it.
+func (c *PostgresConnection) reconnect(ctx context.Context, correlationId string) error {
+	maxAttempts := c.Options.GetAsIntegerWithDefault("reconnect_max_attempts", DefaultReconnectMaxAttempts)
+
+	c.lock.RLock()
+	config := c.poolConfig
+	c.lock.RUnlock()
+	if config == nil {
+		return cerr.NewInvalidStateError(correlationId, "NO_CONNECTION", "PostgreSQL connection is not opened")
+	}
+
+	var err error
+	for attempt := 1; maxAttempts <= 0 || attempt <= maxAttempts; attempt++ {
+		var pool *pgxpool.Pool
+		var replicas []*pgxpool.Pool
+		if pool, err = connectPool(ctx, config); err == nil {
+			// Read replicas are restored together with the primary pool
+			if replicas, err = c.connectReplicas(ctx, correlationId); err != nil {
+				pool.Close()
+			}
+		}
+		if err == nil {
+			c.replacePool(pool)
+			c.replaceReplicas(replicas)
+			c.Logger.Info(ctx, correlationId, "Restored connection to postgres database %s after %d attempt(s)", c.DatabaseName, attempt)
+			return nil
+		}
+		if isAuthenticationError(err) {
+			c.invalidateCredential()
+		}
+
+		c.Logger.Debug(ctx, correlationId, "Reconnect attempt %d to postgres failed: %s", attempt, c.redactError(err, config).Error())
+
+		select {
+		case <-time.After(c.getReconnectDelay(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return cerr.
+		NewConnectionError(correlationId, "RECONNECT_FAILED", "Failed to restore connection to postgres").
+		WithCause(c.redactError(err, config))
+}
+
+// collectMetrics periodically reports statistics of the connection pool to performance counters.
+func (c *PostgresConnection) collectMetrics(ctx context.Context) {
+	interval := c.Options.GetAsIntegerWithDefault("metrics_interval", DefaultMetricsInterval)
+	ticker := time.NewTicker(time.Duration(interval) * time.Millisecond)
+	defer ticker.Stop()
+
+	var lastAcquireCount, lastEmptyAcquireCount int64
+	var lastAcquireDuration time.Duration
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pool := c.GetConnection()
+			if pool == nil {
+				continue
+			}
+			stat := pool.Stat()
+			prefix := "postgres." + c.DatabaseName + ".pool."
+
+			c.Counters.Last(ctx, prefix+"total_connections", float64(stat.TotalConns()))
+			c.Counters.Last(ctx, prefix+"idle_connections", float64(stat.IdleConns()))
+			c.Counters.Last(ctx, prefix+"acquired_connections", float64(stat.AcquiredConns()))
+			c.Counters.Last(ctx, prefix+"max_connections", float64(stat.MaxConns()))
+
+			// Pool statistics are cumulative, so only deltas are reported.
+			// After reconnect the new pool starts from zero.
+			acquireCount := stat.AcquireCount()
+			acquireDuration := stat.AcquireDuration()
+			emptyAcquireCount := stat.EmptyAcquireCount()
+			if acquireCount < lastAcquireCount {
+				lastAcquireCount, lastAcquireDuration, lastEmptyAcquireCount = 0, 0, 0
+			}
+
+			if acquireCount > lastAcquireCount {
+				waitTime := (acquireDuration - lastAcquireDuration) / time.Duration(acquireCount-lastAcquireCount)
+				c.Counters.Stats(ctx, prefix+"acquire_wait_time", float64(waitTime.Milliseconds()))
+			}
+			c.Counters.Increment(ctx, prefix+"acquire_count", acquireCount-lastAcquireCount)
+			c.Counters.Increment(ctx, prefix+"empty_acquire_count", emptyAcquireCount-lastEmptyAcquireCount)
+
+			lastAcquireCount, lastAcquireDuration, lastEmptyAcquireCount = acquireCount, acquireDuration, emptyAcquireCount
+		}
+	}
+}
+
+// replacePool replaces the current connection pool with a new one.
+// The old pool is closed in background after all acquired connections are released.
+func (c *PostgresConnection) replacePool(pool *pgxpool.Pool) {
+	c.lock.Lock()
+	oldPool := c.Connection
+	c.Connection = pool
+	c.lock.Unlock()
+
+	if oldPool != nil {
+		go oldPool.Close()
+	}
+}
+
+// replaceReplicas replaces the current read replica pools with new ones.
+// The old pools are closed in background after all acquired connections are released.
+func (c *PostgresConnection) replaceReplicas(replicas []*pgxpool.Pool) {
+	c.lock.Lock()
+	oldReplicas := c.Replicas
+	c.Replicas = replicas
+	c.lock.Unlock()
+
+	for _, replica := range oldReplicas {
+		go replica.Close()
+	}
+}
+
+// RefreshCredentials resolves credentials again and rebuilds the connection pool with them.
+// It shall be called when the credential store signals about rotation of the credentials.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occurred.
+func (c *PostgresConnection) RefreshCredentials(ctx context.Context, correlationId string) error {
+	c.lock.RLock()
+	config := c.poolConfig
+	c.lock.RUnlock()
+	if config == nil {
+		return cerr.NewInvalidStateError(correlationId, "NO_CONNECTION", "PostgreSQL connection is not opened")
+	}
+
+	c.invalidateCredential()
+	if !c.Options.GetAsBooleanWithDefault("refresh_credentials", false) {
+		// Without the refresh hook credentials are only resolved when the configuration is composed
+		var err error
+		if config, err = c.composePoolConfig(ctx, correlationId, c.ConnectionResolver); err != nil {
+			return err
+		}
+		c.lock.Lock()
+		c.poolConfig = config
+		c.lock.Unlock()
+	}
+
+	pool, err := connectPool(ctx, config)
+	if err != nil {
+		return cerr.
+			NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to connect to postgres with refreshed credentials").
+			WithCause(c.redactError(err, config))
+	}
+	c.replacePool(pool)
+	c.Logger.Info(ctx, correlationId, "Rebuilt connection pool to postgres database %s with refreshed credentials", c.DatabaseName)
+	return nil
+}
+
+// applyCredential sets credentials resolved from the credential store to the configuration of a new connection.
+func (c *PostgresConnection) applyCredential(ctx context.Context, config *pgx.ConnConfig) error {
+	refreshInterval := c.Options.GetAsIntegerWithDefault("credentials_refresh_interval", DefaultCredentialsRefreshInterval)
+
+	c.credentialLock.Lock()
+	defer c.credentialLock.Unlock()
+
+	if c.credential == nil || (refreshInterval > 0 && time.Since(c.credentialResolvedAt) > time.Duration(refreshInterval)*time.Millisecond) {
+		credential, err := c.ConnectionResolver.CredentialResolver.Lookup(ctx, "")
+		if err != nil {
+			return err
+		}
+		c.credential = credential
+		c.credentialResolvedAt = time.Now()
+	}
+
+	if c.credential != nil {
+		if username := c.credential.Username(); username != "" {
+			config.User = username
+		}
+		if password := c.credential.Password(); password != "" {
+			config.Password = password
+		}
+	}
+	return nil
+}
+
+// redactError removes passwords used to connect to the database from the error message.
+func (c *PostgresConnection) redactError(err error, config *pgxpool.Config) error {
+	secrets := make([]string, 0, 2)
+	if config != nil {
+		secrets = append(secrets, config.ConnConfig.Password)
+	}
+
+	c.credentialLock.Lock()
+	if c.credential != nil {
+		secrets = append(secrets, c.credential.Password())
+	}
+	c.credentialLock.Unlock()
+
+	return RedactError(err, secrets...)
+}
+
+// invalidateCredential forces credentials to be resolved again for the next connection.
+func (c *PostgresConnection) invalidateCredential() {
+	c.credentialLock.Lock()
+	c.credential = nil
+	c.credentialLock.Unlock()
+}
+
+// IsConnectionError checks if the error is caused by an unreachable or lost database connection
+// rather than by the executed statement.
+//	Parameters:
+//		- err an error returned by the driver.
+//	Returns: true if the error is a connection failure.
+func IsConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 - connection exception and admin/crash shutdown
+		return strings.HasPrefix(pgErr.Code, "08") || pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || pgconn.SafeToRetry(err)
+}
+
+// isAuthenticationError checks if the error is caused by rejected credentials.
+func isAuthenticationError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// invalid_password and invalid_authorization_specification
+		return pgErr.Code == "28P01" || pgErr.Code == "28000"
+	}
+	return false
+}
+
+// getReconnectDelay calculates exponential backoff delay with random jitter for the reconnect attempt.
+func (c *PostgresConnection) getReconnectDelay(attempt int) time.Duration {
+	delay := float64(c.Options.GetAsIntegerWithDefault("reconnect_delay", DefaultReconnectDelay))
+	maxDelay := float64(c.Options.GetAsIntegerWithDefault("reconnect_max_delay", DefaultReconnectMaxDelay))
+	jitter := c.Options.GetAsDoubleWithDefault("reconnect_jitter", DefaultReconnectJitter)
+
+	delay = math.Min(delay*math.Pow(2, float64(attempt-1)), maxDelay)
+	if jitter > 0 {
+		jitter = math.Min(jitter, 1)
+		delay += delay * jitter * (2*rand.Float64() - 1)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+// connectPool creates a connection pool and checks that the database is reachable.
+// The pool does not establish connections on creation, so it is pinged explicitly.
+func connectPool(ctx context.Context, config *pgxpool.Config) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.NewWithConfig(ctx, config.Copy())
+	if err != nil {
+		return nil, err
+	}
+	if err = pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// composeTLSConfig clones TLS configuration for a particular host
+// to verify the server certificate against the host name.
+func composeTLSConfig(config *tls.Config, host string) *tls.Config {
+	result := config.Clone()
+	if result.ServerName == "" && !result.InsecureSkipVerify {
+		result.ServerName = host
+	}
+	return result
+}
+
 func (c *PostgresConnection) waitForRetry(ctx context.Context, correlationId string, retries int) error {
 	waitTime := DefaultConnectTimeout * int(math.Pow(float64(c.retries-retries), 2))
 
@@ -2,10 +2,15 @@ package connect
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"net/url"
+	"os"
+	"sort"
 	"strconv"
+	"strings"
 
-	pgx "github.com/jackc/pgx/v4"
+	pgx "github.com/jackc/pgx/v5"
 	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
 	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
 	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
@@ -17,6 +22,9 @@ import (
 // PostgresConnectionResolver a helper struct  that resolves Postgres connection and credential parameters,
 // validates them and generates a connection URI.
 // It is able to process multiple connections to Postgres cluster nodes.
+// When several connections are configured they are combined into a multi-host URI
+// with target_session_attrs=read-write, so the client automatically fails over
+// to the node that currently accepts writes.
 //
 //	Configuration parameters:
 //		- connection(s):
@@ -25,10 +33,20 @@ import (
 //			- port:                        port number (default: 27017)
 //			- database:                    database name
 //			- uri:                         resource URI or connection string with all parameters in it
+//			- service:                     (optional) name of the service in pg_service.conf with connection parameters
+//			- servicefile:                 (optional) path to the service file (default: ~/.pg_service.conf)
+//			- passfile:                    (optional) path to the password file (default: ~/.pgpass)
+//			- other parameters:            (optional) any other parameters (sslmode, connect_timeout, target_session_attrs, etc.) are added to the URI
 //		- credential(s):
 //			- store_key:                   (optional) a key to retrieve the credentials from ICredentialStore
 //			- username:                    user name
 //			- password:                    user password
+//		- options:
+//			- ssl_ca_file:                 (optional) path to PEM file with trusted certificate authorities
+//			- ssl_cert_file:               (optional) path to PEM file with client certificate
+//			- ssl_key_file:                (optional) path to PEM file with client private key
+//			- ssl_verify:                  (optional) verifies server certificate and host name (default: true)
+//			- use_pgpass:                  (optional) takes passwords from .pgpass or pg_service.conf instead of credentials (default: false)
 //
 //	References:
 //		- *:discovery:*:*:1.0             (optional) IDiscovery services
@@ -38,6 +56,15 @@ type PostgresConnectionResolver struct {
 	ConnectionResolver *cconn.ConnectionResolver
 	// The credentials' resolver.
 	CredentialResolver *cauth.CredentialResolver
+
+	options *cconf.ConfigParams
+}
+
+// reservedParameters are connection and credential parameters
+// that are not passed to the connection URI as query parameters.
+var reservedParameters = []string{
+	"uri", "host", "port", "database", "protocol", "discovery_key",
+	"username", "password", "store_key", "access_id", "access_key", "email",
 }
 
 // NewPostgresConnectionResolver creates new connection resolver
@@ -46,6 +73,7 @@ func NewPostgresConnectionResolver() *PostgresConnectionResolver {
 	mongoCon := PostgresConnectionResolver{}
 	mongoCon.ConnectionResolver = cconn.NewEmptyConnectionResolver()
 	mongoCon.CredentialResolver = cauth.NewEmptyCredentialResolver()
+	mongoCon.options = cconf.NewEmptyConfigParams()
 	return &mongoCon
 }
 
@@ -56,6 +84,7 @@ func NewPostgresConnectionResolver() *PostgresConnectionResolver {
 func (c *PostgresConnectionResolver) Configure(ctx context.Context, config *cconf.ConfigParams) {
 	c.ConnectionResolver.Configure(ctx, config)
 	c.CredentialResolver.Configure(ctx, config)
+	c.options = c.options.Override(config.GetSection("options"))
 }
 
 // SetReferences is sets references to dependent components.
@@ -70,20 +99,70 @@ func (c *PostgresConnectionResolver) SetReferences(ctx context.Context, referenc
 func (c *PostgresConnectionResolver) validateConnection(correlationId string, connection *cconn.ConnectionParams) error {
 	uri := connection.Uri()
 	if uri != "" {
+		// Key/value connection strings are validated by the driver
+		if !strings.Contains(uri, "://") {
+			return nil
+		}
+		parsed, err := url.Parse(uri)
+		if err != nil {
+			return cerr.NewConfigError(correlationId, "INVALID_URI", "Connection uri is not a valid URL").
+				WithDetails("parameters", []string{"connection.uri"}).WithCause(RedactError(err, uriPassword(uri)))
+		}
+		if parsed.Scheme != "postgres" && parsed.Scheme != "postgresql" {
+			return cerr.NewConfigError(correlationId, "INVALID_URI", "Connection uri must start with postgres:// or postgresql://").
+				WithDetails("parameters", []string{"connection.uri"})
+		}
 		return nil
 	}
 
-	host := connection.Host()
-	if host == "" {
-		return cerr.NewConfigError(correlationId, "NO_HOST", "Connection host is not set")
+	// Connection parameters are defined in pg_service.conf
+	if service := connection.GetAsString("service"); service != "" {
+		return nil
+	}
+
+	missing := make([]string, 0)
+	code := ""
+
+	if host := connection.Host(); host == "" {
+		missing = append(missing, "connection.host")
+		code = "NO_HOST"
 	}
 	port := connection.Port()
 	if port == 0 {
-		return cerr.NewConfigError(correlationId, "NO_PORT", "Connection port is not set")
+		missing = append(missing, "connection.port")
+		code = "NO_PORT"
+	} else if port < 0 || port > 65535 {
+		return cerr.NewConfigError(correlationId, "INVALID_PORT", "Connection port "+strconv.Itoa(port)+" is out of range").
+			WithDetails("parameters", []string{"connection.port"})
+	}
+	if database, ok := connection.GetAsNullableString("database"); !ok || database == "" {
+		missing = append(missing, "connection.database")
+		code = "NO_DATABASE"
+	}
+
+	if len(missing) > 1 {
+		code = "NO_CONNECTION_PARAMS"
 	}
-	database, ok := connection.GetAsNullableString("database")
-	if !ok || database == "" {
-		return cerr.NewConfigError(correlationId, "NO_DATABASE", "Connection database is not set")
+	if len(missing) > 0 {
+		return cerr.NewConfigError(correlationId, code, "Missing connection parameters: "+strings.Join(missing, ", ")).
+			WithDetails("parameters", missing)
+	}
+	return nil
+}
+
+func (c *PostgresConnectionResolver) validateCredential(correlationId string, connections []*cconn.ConnectionParams,
+	credential *cauth.CredentialParams) error {
+
+	// Credentials can be defined in the uri or in pg_service.conf
+	for _, connection := range connections {
+		if connection.Uri() != "" || connection.GetAsString("service") != "" {
+			return nil
+		}
+	}
+
+	if credential == nil || credential.Username() == "" {
+		return cerr.NewConfigError(correlationId, "NO_USERNAME", "Missing credential parameters: credential.username").
+			WithDetails("parameters", []string{"credential.username"})
 	}
 	return nil
 }
@@ -174,6 +253,8 @@ func (c *PostgresConnectionResolver) composeUri(connections []*cconn.ConnectionP
 		}
 		if port != 0 {
 			hosts += host + ":" + strconv.Itoa(port)
+		} else {
+			hosts += host
 		}
 	}
 
@@ -188,13 +269,21 @@ func (c *PostgresConnectionResolver) composeUri(connections []*cconn.ConnectionP
 		database = "/" + database
 	}
 
+	// Passwords are taken from .pgpass or pg_service.conf instead of the URI
+	externalPassword := c.options.GetAsBooleanWithDefault("use_pgpass", false)
+	for _, connection := range connections {
+		if connection.GetAsString("service") != "" {
+			externalPassword = true
+		}
+	}
+
 	// Define authentication part
 	var auth = ""
 	if credential != nil {
 		var username = credential.Username()
 		if len(username) > 0 {
 			var password = credential.Password()
-			if len(password) > 0 {
+			if len(password) > 0 && !externalPassword {
 				auth = username + ":" + password + "@"
 			} else {
 				auth = username + "@"
@@ -212,27 +301,32 @@ func (c *PostgresConnectionResolver) composeUri(connections []*cconn.ConnectionP
 	} else {
 		options = cconf.NewConfigParamsFromValue(consConf.Value())
 	}
-	options.Remove("uri")
-	options.Remove("host")
-	options.Remove("port")
-	options.Remove("database")
-	options.Remove("username")
-	options.Remove("password")
+	for _, key := range reservedParameters {
+		options.Remove(key)
+	}
+
+	// Connect only to the writable node when several hosts are set
+	if len(connections) > 1 && options.GetAsString("target_session_attrs") == "" {
+		options.Put("target_session_attrs", "read-write")
+	}
+
+	// All other parameters are passed to the driver as is
 	params := ""
 	keys := options.Keys()
+	sort.Strings(keys)
 	for _, key := range keys {
 		if len(params) > 0 {
 			params += "&"
 		}
-		params += key
+		params += url.QueryEscape(key)
 
 		value := options.GetAsString(key)
 		if value != "" {
-			params += "=" + value
+			params += "=" + url.QueryEscape(value)
 		}
 	}
 	if len(params) > 0 {
-		params = "?" + url.PathEscape(params)
+		params = "?" + params
 	}
 
 	// Compose uri
@@ -262,5 +356,56 @@ func (c *PostgresConnectionResolver) Resolve(ctx context.Context, correlationId
 	if err != nil {
 		return "", err
 	}
+	err = c.validateCredential(correlationId, connections, credential)
+	if err != nil {
+		return "", err
+	}
 	return c.composeUri(connections, credential), nil
 }
+
+// ResolveTLSConfig composes TLS configuration from ssl_* options.
+//	Parameters:
+//		- correlationId string (optional) transaction id to trace execution through call chain.
+//	Returns: TLS configuration or nil if TLS options are not set, and error, if this occured.
+func (c *PostgresConnectionResolver) ResolveTLSConfig(correlationId string) (*tls.Config, error) {
+	caFile := c.options.GetAsString("ssl_ca_file")
+	certFile := c.options.GetAsString("ssl_cert_file")
+	keyFile := c.options.GetAsString("ssl_key_file")
+	_, verifySet := c.options.GetAsNullableBoolean("ssl_verify")
+
+	if caFile == "" && certFile == "" && keyFile == "" && !verifySet {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		InsecureSkipVerify: !c.options.GetAsBooleanWithDefault("ssl_verify", true),
+	}
+
+	if caFile != "" {
+		caCert, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, cerr.NewConfigError(correlationId, "INVALID_SSL_CA", "Failed to read SSL CA file").
+				WithDetails("file", caFile).WithCause(err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, cerr.NewConfigError(correlationId, "INVALID_SSL_CA", "SSL CA file contains no valid certificates").
+				WithDetails("file", caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, cerr.NewConfigError(correlationId, "INVALID_SSL_CERT", "Both SSL certificate and key files must be set")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, cerr.NewConfigError(correlationId, "INVALID_SSL_CERT", "Failed to load SSL client certificate").
+				WithDetails("cert_file", certFile).WithCause(err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
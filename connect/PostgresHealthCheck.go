@@ -0,0 +1,154 @@
+package connect
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	cref "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
+)
+
+// PostgresHealthStatus describes the result of a database health check.
+type PostgresHealthStatus struct {
+	// True if the database responded successfully.
+	Up bool
+	// Time spent to execute the check query.
+	Latency time.Duration
+	// Time when the check was performed.
+	CheckedAt time.Time
+	// Error returned by the database or nil if it is up.
+	Error error
+}
+
+// PostgresHealthCheck is a component that checks availability of the database
+// by running "SELECT 1" over the shared PostgresConnection.
+// It can be used in heartbeat or status endpoints of the service.
+//
+//	Configuration parameters
+//		- dependencies:
+//			- connection:           (optional) descriptor of the PostgresConnection (default: *:connection:postgres:*:1.0)
+//		- options:
+//			- timeout:              (optional) number of milliseconds to wait for the check query (default: 5000)
+//
+//	References
+//		- *:logger:*:*:1.0           (optional) ILogger components to pass log messages
+//		- *:connection:postgres:*:1.0 PostgresConnection to check
+type PostgresHealthCheck struct {
+	defaultConfig *cconf.ConfigParams
+	// The dependency resolver.
+	DependencyResolver *cref.DependencyResolver
+	// The logger.
+	Logger *clog.CompositeLogger
+	// The PostgreSQL connection component.
+	Connection *PostgresConnection
+
+	timeout    time.Duration
+	lock       sync.RWMutex
+	lastStatus PostgresHealthStatus
+}
+
+const DefaultHealthCheckTimeout = 5000
+
+// NewPostgresHealthCheck creates a new instance of the health check component.
+func NewPostgresHealthCheck() *PostgresHealthCheck {
+	c := &PostgresHealthCheck{
+		defaultConfig: cconf.NewConfigParamsFromTuples(
+			"dependencies.connection", "*:connection:postgres:*:1.0",
+			"options.timeout", DefaultHealthCheckTimeout,
+		),
+		Logger:  clog.NewCompositeLogger(),
+		timeout: DefaultHealthCheckTimeout * time.Millisecond,
+	}
+	c.DependencyResolver = cref.NewDependencyResolver()
+	c.DependencyResolver.Configure(context.Background(), c.defaultConfig)
+	return c
+}
+
+// Configure component by passing configuration parameters.
+//	Parameters:
+//		- ctx context.Context
+//		- config configuration parameters to be set.
+func (c *PostgresHealthCheck) Configure(ctx context.Context, config *cconf.ConfigParams) {
+	config = config.SetDefaults(c.defaultConfig)
+	c.DependencyResolver.Configure(ctx, config)
+	timeout := config.GetAsIntegerWithDefault("options.timeout", DefaultHealthCheckTimeout)
+	c.timeout = time.Duration(timeout) * time.Millisecond
+}
+
+// SetReferences references to dependent components.
+//	Parameters:
+//		- ctx context.Context
+//		- references references to locate the component dependencies.
+func (c *PostgresHealthCheck) SetReferences(ctx context.Context, references cref.IReferences) {
+	c.Logger.SetReferences(ctx, references)
+	c.DependencyResolver.SetReferences(ctx, references)
+
+	if dep, ok := c.DependencyResolver.GetOneOptional("connection").(*PostgresConnection); ok {
+		c.Connection = dep
+	}
+}
+
+// UnsetReferences (clears) previously set references to dependent components.
+func (c *PostgresHealthCheck) UnsetReferences() {
+	c.Connection = nil
+}
+
+// Check runs the check query against the database and records its result.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//	Returns: status of the database and error if the database is down.
+func (c *PostgresHealthCheck) Check(ctx context.Context, correlationId string) (PostgresHealthStatus, error) {
+	status := PostgresHealthStatus{CheckedAt: time.Now()}
+
+	var pool *pgxpool.Pool
+	if c.Connection != nil {
+		pool = c.Connection.GetConnection()
+	}
+	if pool == nil {
+		status.Error = cerr.NewInvalidStateError(correlationId, "NO_CONNECTION", "PostgreSQL connection is not opened")
+	} else {
+		checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		var result int
+		err := pool.QueryRow(checkCtx, "SELECT 1").Scan(&result)
+		cancel()
+
+		status.Latency = time.Since(status.CheckedAt)
+		if err != nil {
+			status.Error = cerr.NewConnectionError(correlationId, "HEALTH_CHECK_FAILED", "PostgreSQL database is not available").
+				WithCause(err)
+		} else {
+			status.Up = true
+		}
+	}
+
+	if status.Error != nil {
+		c.Logger.Warn(ctx, correlationId, "PostgreSQL health check failed: %s", status.Error.Error())
+	} else {
+		c.Logger.Trace(ctx, correlationId, "PostgreSQL health check succeeded in %d ms", status.Latency.Milliseconds())
+	}
+
+	c.lock.Lock()
+	c.lastStatus = status
+	c.lock.Unlock()
+
+	return status, status.Error
+}
+
+// GetStatus gets the result of the last check.
+//	Returns: status of the last check.
+func (c *PostgresHealthCheck) GetStatus() PostgresHealthStatus {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lastStatus
+}
+
+// IsUp checks if the database was available during the last check.
+//	Returns: true if the database is up and false otherwise.
+func (c *PostgresHealthCheck) IsUp() bool {
+	return c.GetStatus().Up
+}
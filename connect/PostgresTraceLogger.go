@@ -0,0 +1,125 @@
+package connect
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5/tracelog"
+	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
+)
+
+type correlationIdContextKey struct{}
+
+// NewContextWithCorrelationId creates a child context which carries the correlationId.
+// It is used to attribute driver-level log messages to the operation that executed the query.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId transaction id to trace execution through call chain.
+//	Returns: a new context.Context
+func NewContextWithCorrelationId(ctx context.Context, correlationId string) context.Context {
+	if correlationId == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, correlationIdContextKey{}, correlationId)
+}
+
+// CorrelationIdFromContext retrieves a correlationId previously bound to the context.
+//	Parameters:
+//		- ctx context.Context
+//	Returns: the correlationId or empty string if it was not set.
+func CorrelationIdFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	correlationId, _ := ctx.Value(correlationIdContextKey{}).(string)
+	return correlationId
+}
+
+// PostgresTraceLogger forwards log messages of the pgx driver into the pip-services logger.
+// Query arguments are never logged to avoid leaking sensitive data.
+// Messages are logged one level lower than the driver reports them, because
+// query errors are also returned to the callers: successful queries appear at debug level
+// and failed queries at warn level.
+type PostgresTraceLogger struct {
+	logger *clog.CompositeLogger
+}
+
+// NewPostgresTraceLogger creates a new adapter for the driver logs.
+//	Parameters:
+//		- logger a logger to write messages to.
+//	Returns: *PostgresTraceLogger
+func NewPostgresTraceLogger(logger *clog.CompositeLogger) *PostgresTraceLogger {
+	return &PostgresTraceLogger{logger: logger}
+}
+
+// NewPostgresTracer creates a pgx tracer which writes driver logs into the pip-services logger.
+//	Parameters:
+//		- logger a logger to write messages to.
+//	Returns: *tracelog.TraceLog
+func NewPostgresTracer(logger *clog.CompositeLogger) *tracelog.TraceLog {
+	return &tracelog.TraceLog{
+		Logger:   NewPostgresTraceLogger(logger),
+		LogLevel: tracelog.LogLevelTrace,
+	}
+}
+
+// Log writes a driver log message to the logger.
+//	Parameters:
+//		- ctx context.Context of the executed operation
+//		- level driver log level
+//		- msg log message
+//		- data additional message attributes
+func (c *PostgresTraceLogger) Log(ctx context.Context, level tracelog.LogLevel, msg string, data map[string]any) {
+	if c.logger == nil {
+		return
+	}
+
+	var err error
+	keys := make([]string, 0, len(data))
+	for key, value := range data {
+		if key == "err" {
+			err, _ = value.(error)
+			continue
+		}
+		if key == "args" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	builder.WriteString("postgres: ")
+	builder.WriteString(msg)
+	for _, key := range keys {
+		builder.WriteString(" ")
+		builder.WriteString(key)
+		builder.WriteString("=")
+		builder.WriteString(fmt.Sprint(data[key]))
+	}
+	message := redactText(builder.String())
+	if err != nil {
+		err = RedactError(err)
+	}
+
+	// The message is passed as an argument to avoid interpreting % in SQL
+	c.logger.Log(ctx, traceLogLevel(level), CorrelationIdFromContext(ctx), err, "%s", message)
+}
+
+// traceLogLevel converts driver log level into the pip-services log level.
+func traceLogLevel(level tracelog.LogLevel) clog.LevelType {
+	switch level {
+	case tracelog.LogLevelError:
+		return clog.LevelWarn
+	case tracelog.LogLevelWarn:
+		return clog.LevelInfo
+	case tracelog.LogLevelInfo:
+		return clog.LevelDebug
+	case tracelog.LogLevelNone:
+		return clog.LevelNone
+	default:
+		return clog.LevelTrace
+	}
+}
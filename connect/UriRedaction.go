@@ -0,0 +1,110 @@
+package connect
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// RedactedSecret is a placeholder that replaces secrets in logs and errors.
+const RedactedSecret = "*****"
+
+var (
+	keyValuePasswordPattern = regexp.MustCompile(`(?i)(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+	uriUserInfoPattern      = regexp.MustCompile(`(://[^:/@\s]*:)[^@\s]*@`)
+)
+
+// RedactUri replaces passwords in a PostgreSQL connection URI or key/value connection string
+// with a placeholder, so the result can be safely written to logs and errors.
+//
+//	Parameters:
+//		- uri a connection URI or key/value connection string.
+//	Returns: the connection string with masked passwords.
+func RedactUri(uri string) string {
+	if uri == "" {
+		return uri
+	}
+
+	if !strings.Contains(uri, "://") {
+		return keyValuePasswordPattern.ReplaceAllString(uri, "${1}"+RedactedSecret)
+	}
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		// Fallback for malformed URIs which still may carry credentials
+		result := uriUserInfoPattern.ReplaceAllString(uri, "${1}"+RedactedSecret+"@")
+		return keyValuePasswordPattern.ReplaceAllString(result, "${1}"+RedactedSecret)
+	}
+
+	if parsed.User != nil {
+		if _, ok := parsed.User.Password(); ok {
+			parsed.User = url.UserPassword(parsed.User.Username(), RedactedSecret)
+		}
+	}
+	query := parsed.Query()
+	if query.Has("password") {
+		query.Set("password", RedactedSecret)
+		parsed.RawQuery = query.Encode()
+	}
+
+	// Keep the placeholder readable instead of percent-encoded
+	return strings.ReplaceAll(parsed.String(), url.QueryEscape(RedactedSecret), RedactedSecret)
+}
+
+// RedactError removes the given secrets and passwords in connection strings
+// from the error message. The original error is returned when it contains no secrets.
+//
+//	Parameters:
+//		- err an error to be cleaned up.
+//		- secrets values that must not appear in the error message.
+//	Returns: an error with masked secrets.
+func RedactError(err error, secrets ...string) error {
+	if err == nil {
+		return nil
+	}
+
+	message := err.Error()
+	redacted := redactText(message, secrets...)
+	if redacted == message {
+		return err
+	}
+	return errors.New(redacted)
+}
+
+// redactText masks the given secrets and passwords in connection strings within an arbitrary text.
+func redactText(text string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, RedactedSecret)
+		}
+	}
+	text = uriUserInfoPattern.ReplaceAllString(text, "${1}"+RedactedSecret+"@")
+	return keyValuePasswordPattern.ReplaceAllString(text, "${1}"+RedactedSecret)
+}
+
+// uriPassword extracts the password from a connection URI or key/value connection string.
+func uriPassword(uri string) string {
+	if strings.Contains(uri, "://") {
+		if parsed, err := url.Parse(uri); err == nil {
+			if parsed.User != nil {
+				if password, ok := parsed.User.Password(); ok {
+					return password
+				}
+			}
+			return parsed.Query().Get("password")
+		}
+		if match := uriUserInfoPattern.FindString(uri); match != "" {
+			userInfo := strings.TrimSuffix(strings.TrimPrefix(match, "://"), "@")
+			if index := strings.Index(userInfo, ":"); index >= 0 {
+				return userInfo[index+1:]
+			}
+		}
+		return ""
+	}
+
+	if match := keyValuePasswordPattern.FindStringSubmatch(uri); len(match) == 3 {
+		return strings.Trim(match[2], "'")
+	}
+	return ""
+}
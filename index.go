@@ -1,7 +1,10 @@
 package postgres
 
 import (
-	_ "github.com/pip-services3-gox/pip-services3-postgres-gox/build"
-	_ "github.com/pip-services3-gox/pip-services3-postgres-gox/connect"
-	_ "github.com/pip-services3-gox/pip-services3-postgres-gox/persistence"
+	_ "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/build"
+	_ "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/cache"
+	_ "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+	_ "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/lock"
+	_ "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	_ "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/state"
 )
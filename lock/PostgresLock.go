@@ -0,0 +1,377 @@
+package lock
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	cref "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	clock "github.com/pip-services3-gox/pip-services3-components-gox/lock"
+	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+)
+
+// DefaultLockReleaseTimeout is a default number of milliseconds to wait for releasing of expired locks.
+const DefaultLockReleaseTimeout = 5000
+
+// PostgresLock is a distributed lock that is implemented with PostgreSQL advisory locks,
+// so services without Redis can coordinate their work through the database they already use.
+//
+// Locks are held by a dedicated session taken out of the connection pool, so all locks of the component
+// are released by the database when the process dies or the session is lost. Advisory locks don't expire,
+// so the time to live is emulated: locks are released by the component when their ttl elapses.
+// Locks are identified by 64-bit hashes of their keys and shared by all components of the database.
+//
+//	Configuration parameters
+//		- connection(s):
+//			- discovery_key:        (optional) a key to retrieve the connection from IDiscovery
+//			- host:                 host name or IP address
+//			- port:                 port number (default: 5432)
+//			- uri:                  resource URI or connection string with all parameters in it
+//		- credential(s):
+//			- store_key:            (optional) a key to retrieve the credentials from ICredentialStore
+//			- username:             (optional) user name
+//			- password:             (optional) user password
+//		- dependencies:
+//			- connection:           (optional) descriptor of the shared PostgresConnection (default: *:connection:postgres:*:1.0)
+//		- options:
+//			- retry_timeout:        (optional) number of milliseconds to retry lock acquisition by AcquireLock (default: 100)
+//			- release_timeout:      (optional) number of milliseconds to wait for releasing of expired locks (default: 5000)
+//
+//	References
+//		- *:logger:*:*:1.0            (optional) ILogger components to pass log messages
+//		- *:connection:postgres:*:1.0 (optional) shared PostgresConnection, otherwise a local connection is created
+//		- *:discovery:*:*:1.0         (optional) IDiscovery services
+//		- *:credential-store:*:*:1.0  (optional) Credential stores to resolve credentials
+//
+//	Example:
+//		lock := lock.NewPostgresLock()
+//		lock.Configure(ctx, cconf.NewConfigParamsFromTuples(
+//			"connection.host", "localhost",
+//			"connection.port", 5432,
+//			"connection.database", "test",
+//		))
+//		_ = lock.Open(ctx, "123")
+//
+//		if err := lock.AcquireLock(ctx, "123", "key1", 10000, 5000); err == nil {
+//			defer lock.ReleaseLock(ctx, "123", "key1")
+//			// Processing...
+//		}
+type PostgresLock struct {
+	*clock.Lock
+
+	defaultConfig *cconf.ConfigParams
+	config        *cconf.ConfigParams
+	references    cref.IReferences
+
+	// The dependency resolver.
+	DependencyResolver *cref.DependencyResolver
+	// The logger.
+	Logger *clog.CompositeLogger
+	// The PostgreSQL connection component.
+	Connection *conn.PostgresConnection
+
+	localConnection bool
+	opened          bool
+	releaseTimeout  time.Duration
+	mux             sync.Mutex
+	session         *pgx.Conn
+	locks           map[string]*heldLock
+}
+
+// heldLock is a lock held by the session. Every acquisition has its own entry,
+// so an expiration timer can't release the lock acquired again after it.
+type heldLock struct {
+	expiration *time.Timer
+}
+
+// NewPostgresLock creates a new instance of the lock component.
+//
+//	Returns: *PostgresLock
+func NewPostgresLock() *PostgresLock {
+	c := &PostgresLock{
+		defaultConfig: cconf.NewConfigParamsFromTuples(
+			"dependencies.connection", "*:connection:postgres:*:1.0",
+			"options.release_timeout", DefaultLockReleaseTimeout,
+		),
+		Logger:         clog.NewCompositeLogger(),
+		releaseTimeout: DefaultLockReleaseTimeout * time.Millisecond,
+		locks:          make(map[string]*heldLock),
+	}
+	c.Lock = clock.InheritLock(c)
+	c.DependencyResolver = cref.NewDependencyResolver()
+	c.DependencyResolver.Configure(context.Background(), c.defaultConfig)
+	return c
+}
+
+// Configure component by passing configuration parameters.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- config configuration parameters to be set.
+func (c *PostgresLock) Configure(ctx context.Context, config *cconf.ConfigParams) {
+	config = config.SetDefaults(c.defaultConfig)
+	c.config = config
+
+	c.Lock.Configure(ctx, config)
+	c.DependencyResolver.Configure(ctx, config)
+	timeout := config.GetAsIntegerWithDefault("options.release_timeout", DefaultLockReleaseTimeout)
+	c.releaseTimeout = time.Duration(timeout) * time.Millisecond
+}
+
+// SetReferences references to dependent components.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- references references to locate the component dependencies.
+func (c *PostgresLock) SetReferences(ctx context.Context, references cref.IReferences) {
+	c.references = references
+	c.Logger.SetReferences(ctx, references)
+	c.DependencyResolver.SetReferences(ctx, references)
+
+	if dep, ok := c.DependencyResolver.GetOneOptional("connection").(*conn.PostgresConnection); ok {
+		c.Connection = dep
+		c.localConnection = false
+	}
+	// Or create a local one
+	if c.Connection == nil {
+		c.Connection = c.createConnection(ctx)
+		c.localConnection = true
+	}
+}
+
+// UnsetReferences (clears) previously set references to dependent components.
+func (c *PostgresLock) UnsetReferences() {
+	c.Connection = nil
+}
+
+func (c *PostgresLock) createConnection(ctx context.Context) *conn.PostgresConnection {
+	connection := conn.NewPostgresConnection()
+	if c.config != nil {
+		connection.Configure(ctx, c.config)
+	}
+	if c.references != nil {
+		connection.SetReferences(ctx, c.references)
+	}
+	return connection
+}
+
+// IsOpen checks if the component is opened.
+//
+//	Returns: true if the component has been opened and false otherwise.
+func (c *PostgresLock) IsOpen() bool {
+	return c.opened
+}
+
+// Open the component.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occurred.
+func (c *PostgresLock) Open(ctx context.Context, correlationId string) (err error) {
+	if c.opened {
+		return nil
+	}
+
+	if c.Connection == nil {
+		c.Connection = c.createConnection(ctx)
+		c.localConnection = true
+	}
+	if c.localConnection && !c.Connection.IsOpen() {
+		if err = c.Connection.Open(ctx, correlationId); err != nil {
+			return err
+		}
+	}
+	if !c.Connection.IsOpen() {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "PostgreSQL connection is not opened")
+	}
+
+	c.opened = true
+	c.Logger.Debug(ctx, correlationId, "Opened postgres lock on database %s", c.Connection.GetDatabaseName())
+	return nil
+}
+
+// Close component and releases all acquired locks.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occurred.
+func (c *PostgresLock) Close(ctx context.Context, correlationId string) (err error) {
+	if !c.opened {
+		return nil
+	}
+
+	// Closing of the session releases all its advisory locks
+	c.mux.Lock()
+	c.closeSession(ctx)
+	c.mux.Unlock()
+
+	if c.localConnection && c.Connection != nil {
+		if err = c.Connection.Close(ctx, correlationId); err != nil {
+			return err
+		}
+		c.Connection = nil
+	}
+	c.opened = false
+	return nil
+}
+
+// TryAcquireLock makes a single attempt to acquire a lock by its key.
+// It returns immediately a positive or negative result. A lock which is already held
+// by this component is not acquired again until it is released or expires.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- key a unique lock key to acquire.
+//		- ttl a lock timeout (time to live) in milliseconds, or 0 to hold the lock until it is released.
+//	Returns: true if the lock is acquired or error.
+func (c *PostgresLock) TryAcquireLock(ctx context.Context, correlationId string, key string, ttl int64) (bool, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if _, ok := c.locks[key]; ok {
+		return false, nil
+	}
+	session, err := c.getSession(ctx, correlationId)
+	if err != nil {
+		return false, err
+	}
+
+	var locked bool
+	if err = session.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", lockId(key)).Scan(&locked); err != nil {
+		c.checkSession(ctx, correlationId)
+		return false, cerr.NewConnectionError(correlationId, "LOCK_FAILED", "Failed to acquire lock "+key).
+			WithDetails("key", key).
+			WithCause(err)
+	}
+	if !locked {
+		return false, nil
+	}
+
+	held := &heldLock{}
+	if ttl > 0 {
+		held.expiration = time.AfterFunc(time.Duration(ttl)*time.Millisecond, func() {
+			c.expireLock(correlationId, key, held)
+		})
+	}
+	c.locks[key] = held
+	c.Logger.Trace(ctx, correlationId, "Acquired lock %s", key)
+	return true, nil
+}
+
+// ReleaseLock releases a previously acquired lock by its key.
+// Locks which are not held by this component are ignored.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- key a unique lock key to release.
+//	Returns: error or nil no errors occurred.
+func (c *PostgresLock) ReleaseLock(ctx context.Context, correlationId string, key string) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	held, ok := c.locks[key]
+	if !ok {
+		return nil
+	}
+	if held.expiration != nil {
+		held.expiration.Stop()
+	}
+	return c.releaseLock(ctx, correlationId, key)
+}
+
+// expireLock releases the lock when its ttl elapses, unless it was released and acquired again since then.
+func (c *PostgresLock) expireLock(correlationId string, key string, held *heldLock) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if current, ok := c.locks[key]; !ok || current != held {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.releaseTimeout)
+	defer cancel()
+	if err := c.releaseLock(ctx, correlationId, key); err != nil {
+		c.Logger.Warn(ctx, correlationId, "Failed to release expired lock %s: %s", key, err.Error())
+	} else {
+		c.Logger.Debug(ctx, correlationId, "Released expired lock %s", key)
+	}
+}
+
+// releaseLock releases the advisory lock of the key. It must be called under the mutex.
+func (c *PostgresLock) releaseLock(ctx context.Context, correlationId string, key string) error {
+	delete(c.locks, key)
+	if c.session == nil {
+		return nil
+	}
+
+	if _, err := c.session.Exec(ctx, "SELECT pg_advisory_unlock($1)", lockId(key)); err != nil {
+		c.checkSession(ctx, correlationId)
+		return cerr.NewConnectionError(correlationId, "UNLOCK_FAILED", "Failed to release lock "+key).
+			WithDetails("key", key).
+			WithCause(err)
+	}
+	c.Logger.Trace(ctx, correlationId, "Released lock %s", key)
+	return nil
+}
+
+// getSession returns the session which holds locks, and takes a new one out of the pool when it is missing.
+// It must be called under the mutex.
+func (c *PostgresLock) getSession(ctx context.Context, correlationId string) (*pgx.Conn, error) {
+	if c.session != nil {
+		return c.session, nil
+	}
+
+	if !c.opened || c.Connection == nil || c.Connection.GetConnection() == nil {
+		return nil, cerr.NewInvalidStateError(correlationId, "NO_CONNECTION", "PostgreSQL connection is not opened")
+	}
+	poolConn, err := c.Connection.GetConnection().Acquire(ctx)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to connect postgres lock").
+			WithCause(err)
+	}
+	// The session holds locks as long as the component, so it doesn't return to the pool
+	c.session = poolConn.Hijack()
+	return c.session, nil
+}
+
+// checkSession drops the session when it is lost, so all its locks are forgotten, as the database released them.
+// It must be called under the mutex.
+func (c *PostgresLock) checkSession(ctx context.Context, correlationId string) {
+	if c.session == nil || !c.session.IsClosed() {
+		return
+	}
+	if len(c.locks) > 0 {
+		c.Logger.Warn(ctx, correlationId, "Lost postgres lock session, %d locks are released", len(c.locks))
+	}
+	c.closeSession(ctx)
+}
+
+// closeSession closes the session and forgets all its locks. It must be called under the mutex.
+func (c *PostgresLock) closeSession(ctx context.Context) {
+	for key, held := range c.locks {
+		if held.expiration != nil {
+			held.expiration.Stop()
+		}
+		delete(c.locks, key)
+	}
+	if c.session != nil {
+		_ = c.session.Close(ctx)
+		c.session = nil
+	}
+}
+
+// lockId converts the lock key into an id of the advisory lock.
+func lockId(key string) int64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte("lock:" + key))
+	return int64(hash.Sum64())
+}
@@ -2,17 +2,24 @@ package persistence
 
 import (
 	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
 
-	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v5"
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
 	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
 	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
 )
 
 // IdentifiableJsonPostgresPersistence is an abstract persistence component that stores data in PostgreSQL in JSON or JSONB fields
 // and implements a number of CRUD operations over data items with unique ids.
 // The data items must implement IIdentifiable interface.
 //
-// The JSON table has only two fields: id and data.
+// The JSON table has only two fields: id and data. Their names can be changed by options.id_column
+// and options.data_column to map the persistence onto existing tables.
 //
 // In basic scenarios child classes shall only override getPageByFilter,
 // getListByFilter or deleteByFilter operations with specific filter function.
@@ -37,6 +44,27 @@ import (
 //			- connect_timeout:      (optional) number of milliseconds to wait before timing out when connecting a new client (default: 0)
 //			- idle_timeout:         (optional) number of milliseconds a client must sit idle in the pool and not be checked out (default: 10000)
 //			- max_pool_size:        (optional) maximum number of clients the pool should contain (default: 10)
+//			- data_storage:         (optional) storage mode of the data column: plain, main, external or extended.
+//			                        External skips compression of large documents to speed up their reads (default: extended)
+//			- data_compression:     (optional) compression method of the data column: pglz or lz4 (PostgreSQL 14+)
+//			- toast_tuple_target:   (optional) row size in bytes above which values are compressed and moved into the TOAST table
+//			- storage:              (optional) storage parameters of the table, including TOAST ones, e.g. storage.toast.autovacuum_enabled=false
+//			- id_column:            (optional) a name of the id column (default: id)
+//			- data_column:          (optional) a name of the JSON data column (default: data)
+//			- history:              (optional) copies previous versions of updated and deleted documents with the operation,
+//			                        correlationId and time into the <table>_history table, see GetHistoryById (default: false).
+//			                        The correlationId is passed to the database by a run-time setting of every operation
+//			- partitions:           (optional) a number of hash partitions of new tables by the id column, which keep
+//			                        indexes of large tables small. Unique data keys can't be enforced across partitions
+//			- attachments:          (optional) stores binary attachments of data items in the <table>_attachments table,
+//			                        see WriteAttachment and ReadAttachment (default: false)
+//			- attachment_chunk_size: (optional) size in bytes of chunks attachments are stored in (default: 262144)
+//			- encryption_key:       (optional) a base64 encoded 16, 24 or 32 bytes long AES key which encrypts
+//			                        documents at rest, see SetKeyProvider
+//			- masked_fields:        (optional) a comma-separated list of fields masked in returned items, see SetMaskedFields
+//			- mask:                 (optional) a value which replaces masked string fields (default: ***)
+//			- deep_merge:           (optional) merges nested objects of UpdatePartially with stored ones instead of
+//			                        replacing top-level fields, so sibling nested fields are kept (default: false)
 //
 //	References
 //		- *:logger:*:*:1.0           (optional) ILogger components to pass log messages components to pass log messages
@@ -61,17 +89,18 @@ import (
 //			c.EnsureIndex(c.TableName+"_key", map[string]string{"(data->'key')": "1"}, map[string]string{"unique": "true"})
 //		}
 //
+//		func (c *DummyJsonPostgresPersistence) composeFilter(filter cdata.FilterParams) (string, []any) {
+//			return persist.NewPostgresFilterBuilder().
+//				Equal("Key", persist.JsonField("data", "key")).
+//				Build(filter)
+//		}
+//
 //		func (c *DummyJsonPostgresPersistence) GetPageByFilter(ctx context.Context, correlationId string,
 //			filter cdata.FilterParams, paging cdata.PagingParams) (page cdata.DataPage[fixtures.Dummy], err error) {
 //
-//			key, ok := filter.GetAsNullableString("Key")
-//			filterObj := ""
-//			if ok && key != "" {
-//				filterObj += "data->key='" + key + "'"
-//			}
-//
-//			return c.IdentifiableJsonPostgresPersistence.GetPageByFilter(ctx, correlationId,
-//				filterObj, paging,
+//			where, params := c.composeFilter(filter)
+//			return c.IdentifiableJsonPostgresPersistence.GetPageByFilterWithParams(ctx, correlationId,
+//				where, params, paging,
 //				"", "",
 //			)
 //		}
@@ -79,12 +108,8 @@ import (
 //		func (c *DummyJsonPostgresPersistence) GetCountByFilter(ctx context.Context, correlationId string,
 //			filter cdata.FilterParams) (count int64, err error) {
 //
-//			filterObj := ""
-//			if key, ok := filter.GetAsNullableString("Key"); ok && key != "" {
-//				filterObj += "data->key='" + key + "'"
-//			}
-//
-//			return c.IdentifiableJsonPostgresPersistence.GetCountByFilter(ctx, correlationId, filterObj)
+//			where, params := c.composeFilter(filter)
+//			return c.IdentifiableJsonPostgresPersistence.GetCountByFilterWithParams(ctx, correlationId, where, params)
 //		}
 //
 //		func (c *DummyJsonPostgresPersistence) GetOneRandom(ctx context.Context, correlationId string) (item fixtures.Dummy, err error) {
@@ -92,6 +117,13 @@ import (
 //		}
 type IdentifiableJsonPostgresPersistence[T any, K any] struct {
 	*IdentifiablePostgresPersistence[T, K]
+
+	history         bool
+	attachments     bool
+	attachmentChunk int
+	keyProvider     IPostgresKeyProvider
+	partitions      int
+	keyError        *cerr.ApplicationError
 }
 
 // InheritIdentifiableJsonPostgresPersistence creates a new instance of the persistence component.
@@ -101,13 +133,63 @@ type IdentifiableJsonPostgresPersistence[T any, K any] struct {
 func InheritIdentifiableJsonPostgresPersistence[T any, K any](overrides IPostgresPersistenceOverrides[T], tableName string) *IdentifiableJsonPostgresPersistence[T, K] {
 	c := &IdentifiableJsonPostgresPersistence[T, K]{}
 	c.IdentifiablePostgresPersistence = InheritIdentifiablePostgresPersistence[T, K](overrides, tableName)
+	c.jsonColumn = "data"
 	return c
 }
 
-// EnsureTable Adds DML statement to automatically create JSON(B) table
+// Configure component by passing configuration parameters.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- config configuration parameters to be set.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) Configure(ctx context.Context, config *cconf.ConfigParams) {
+	c.IdentifiablePostgresPersistence.Configure(ctx, config)
+
+	c.idColumn = config.GetAsStringWithDefault("options.id_column", c.idColumn)
+	c.jsonColumn = config.GetAsStringWithDefault("options.data_column", c.jsonColumn)
+	c.history = config.GetAsBooleanWithDefault("options.history", c.history)
+	c.partitions = config.GetAsIntegerWithDefault("options.partitions", c.partitions)
+	c.attachments = config.GetAsBooleanWithDefault("options.attachments", c.attachments)
+	c.attachmentChunk = config.GetAsIntegerWithDefault("options.attachment_chunk_size", c.attachmentChunk)
+	if key, ok := config.GetAsNullableString("options.encryption_key"); ok {
+		c.keyProvider, c.keyError = newConfiguredKeyProvider(key)
+	}
+	c.correlationSetting = ""
+	if c.history {
+		c.correlationSetting = historyCorrelationSetting
+	}
+	c.resetStatements()
+}
+
+// Open the component.
+// It fails with a ConfigError when options.encryption_key is not a valid key.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occurred.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) Open(ctx context.Context, correlationId string) error {
+	if c.keyError != nil {
+		return c.keyError.WithCorrelationId(correlationId)
+	}
+	return c.IdentifiablePostgresPersistence.Open(ctx, correlationId)
+}
+
+// EnsureTable Adds DML statement to automatically create JSON(B) table.
+// The table type is set by options.table_type, see CreateTableClause,
+// and the storage by options.storage and options.tablespace, see TableStorageClause.
+// The storage mode and compression of the data column and the TOAST tuple target
+// set by options.data_storage, options.data_compression and options.toast_tuple_target
+// are applied every time the persistence is opened, so they can be changed for existing tables.
+// When options.partitions is set the table is hash-partitioned by the id column into the number of partitions
+// named <table>_p<n>, which PostgreSQL selects transparently for all operations.
+// When options.history is set the history table and its trigger are created as well,
+// and when options.attachments is set the attachments table is created.
+// A JSON data column keeps documents as they were written, and changes, filters and constraints
+// cast it into JSONB, so they behave the same as with a JSONB column but can't use its GIN indexes.
 //	Parameters:
 //   - idType type of the id column (default: TEXT)
-//   - dataType type of the data column (default: JSONB)
+//   - dataType type of the data column: JSONB or JSON (default: JSONB)
 func (c *IdentifiableJsonPostgresPersistence[T, K]) EnsureTable(idType string, dataType string) {
 	if idType == "" {
 		idType = "TEXT"
@@ -115,10 +197,322 @@ func (c *IdentifiableJsonPostgresPersistence[T, K]) EnsureTable(idType string, d
 	if dataType == "" {
 		dataType = "JSONB"
 	}
+	c.plainJson = strings.EqualFold(strings.TrimSpace(dataType), "JSON")
+
+	definitions := c.quotedIdColumn() + " " + idType + " PRIMARY KEY, " + c.QuoteIdentifier(c.jsonColumn) + " " + dataType
+	if definition := c.composeTenantColumn(); definition != "" {
+		definitions += ", " + definition
+	}
+	c.declareColumn(c.idColumn, idType)
+	c.declareColumn(c.jsonColumn, dataType)
+	c.declaredTable = true
+	c.EnsureSchema(c.composePartitionedTable(definitions))
+	for _, statement := range c.partitionStatements() {
+		c.EnsureSchema(statement)
+	}
+	c.updateStatements = append(c.updateStatements, c.columnStorageStatements(c.jsonColumn, c.toastTables())...)
+	if c.history {
+		c.ensureHistory(idType, dataType)
+	}
+	if c.attachments {
+		c.ensureAttachments(idType)
+	}
+}
+
+// RemoveFields removes fields from the JSON data of a data item without rewriting the whole document.
+// Top-level fields are removed with the - operator and nested ones with the #- operator.
+// Missing fields are ignored.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- id                an id of data item to be updated.
+//		- paths             field names or dot-separated paths of nested fields to be removed.
+// Returns: updated item or error.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) RemoveFields(ctx context.Context, correlationId string,
+	id K, paths []string) (result T, err error) {
+
+	if err = c.checkUnencrypted(correlationId, "RemoveFields"); err != nil {
+		return result, err
+	}
+	result, err = withRetries(ctx, c.PostgresPersistence, correlationId, "RemoveFields", func() (T, error) {
+		return c.removeFields(ctx, correlationId, id, paths)
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, c.afterUpdate(ctx, correlationId, result)
+}
+
+// removeFields is a single attempt of RemoveFields.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) removeFields(ctx context.Context, correlationId string,
+	id K, paths []string) (result T, err error) {
+
+	defer c.forgetIdentity(ctx, id)
+	quoted := c.QuoteIdentifier(c.jsonColumn)
+	expr := c.jsonbData()
+	values := []any{id}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if segments := strings.Split(path, "."); len(segments) > 1 {
+			values = append(values, segments)
+			expr += " #- $" + strconv.Itoa(len(values)) + "::text[]"
+		} else {
+			values = append(values, path)
+			expr += " - $" + strconv.Itoa(len(values)) + "::text"
+		}
+	}
+
+	removed := len(values) - 1
+
+	query, values, err := c.applyTenantCondition(ctx, correlationId,
+		"UPDATE "+c.QuotedTableName()+" SET "+quoted+"="+c.jsonData(expr)+" WHERE "+c.quotedIdColumn()+"=$1 RETURNING *", values)
+	if err != nil {
+		return result, err
+	}
+
+	items, err := c.queryItems(ctx, correlationId, query, values...)
+	if err != nil || len(items) == 0 {
+		return result, err
+	}
+	c.Logger.Trace(ctx, correlationId, "Removed %d fields in %s with id = %s", removed, c.TableName, id)
+	return items[0], nil
+}
+
+// UpdateByFilter changes all JSON documents matching a parameterized filter with a single statement.
+// Top-level fields of the data are merged into documents with the || operator, or field by field
+// when options.deep_merge is set, and fields with dot-separated paths, e.g. address.city,
+// are set with jsonb_set creating missing intermediate objects.
+// Interceptors are not called and items of the table are removed from the identity map of the context.
+//
+//	Example:
+//		count, err := c.UpdateByFilter(ctx, correlationId, persist.JsonField("data", "status")+"=$1", []any{"new"},
+//			*cdata.NewAnyValueMapFromTuples("status", "active", "stats.updated", true))
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- filter            (optional) a WHERE clause with parameter placeholders, all documents are changed without it.
+//		- params            (optional) values of the filter parameters
+//		- data              a map with fields to be changed.
+//	Returns: number of changed documents or error.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) UpdateByFilter(ctx context.Context, correlationId string,
+	filter string, params []any, data cdata.AnyValueMap) (int64, error) {
+
+	if err := c.checkUnencrypted(correlationId, "UpdateByFilter"); err != nil {
+		return 0, err
+	}
+	defer c.forgetTable(ctx)
+	filter, values, err := c.applyTenantFilter(ctx, correlationId, filter, params)
+	if err != nil {
+		return 0, err
+	}
+	// Mutation parameters follow the filter ones
+	values = append(make([]any, 0, len(values)), values...)
+	placeholder := func(value any, cast string) string {
+		values = append(values, value)
+		return "$" + strconv.Itoa(len(values)) + "::" + cast
+	}
+
+	// Structs are converted into maps to be merged like other nested objects
+	buf, err := cconv.JsonConverter.ToJson(data.Value())
+	if err != nil {
+		return 0, err
+	}
+	plain, nested := splitNestedFields(cconv.JsonConverter.ToMap(buf))
+
+	quoted := c.QuoteIdentifier(c.jsonColumn)
+	expr := c.jsonbData()
+	fields := make([]nestedField, 0, len(nested))
+	if c.deepMerge {
+		fields = flattenNestedFields(nil, plain, fields)
+	} else if len(plain) > 0 {
+		document, err := cconv.JsonConverter.ToJson(plain)
+		if err != nil {
+			return 0, err
+		}
+		expr = "(COALESCE(" + expr + ",'{}'::jsonb)||" + placeholder(document, "jsonb") + ")"
+	}
+	paths := make([]string, 0, len(nested))
+	for path := range nested {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fields = append(fields, nestedField{path: strings.Split(path, "."), value: nested[path]})
+	}
+	if len(fields) > 0 {
+		if expr, err = composeJsonbSet(expr, fields, placeholder); err != nil {
+			return 0, err
+		}
+	}
+
+	query := "UPDATE " + c.QuotedTableName() + " SET " + quoted + "=" + c.jsonData(expr)
+	if len(filter) > 0 {
+		query += " WHERE " + filter
+	}
+	tag, err := c.exec(ctx, correlationId, query, values...)
+	if err != nil {
+		return 0, err
+	}
+
+	count := tag.RowsAffected()
+	c.Logger.Trace(ctx, correlationId, "Updated %d documents by filter in %s", count, c.TableName)
+	return count, nil
+}
+
+// composeDeepMerge builds an UPDATE statement which sets leaf values of the data with jsonb_set,
+// so nested objects are merged with stored ones and their other fields are kept.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) composeDeepMerge(id K, data cdata.AnyValueMap) (string, []any, error) {
+	// Structs are converted into maps to be merged like other nested objects
+	buf, err := cconv.JsonConverter.ToJson(data.Value())
+	if err != nil {
+		return "", nil, err
+	}
+	fields := flattenNestedFields(nil, cconv.JsonConverter.ToMap(buf), nil)
+
+	values := []any{id}
+	placeholder := func(value any, cast string) string {
+		values = append(values, value)
+		return "$" + strconv.Itoa(len(values)) + "::" + cast
+	}
+	quoted := c.QuoteIdentifier(c.jsonColumn)
+	expr, err := composeJsonbSet(c.jsonbData(), fields, placeholder)
+	if err != nil {
+		return "", nil, err
+	}
+	return "UPDATE " + c.QuotedTableName() + " SET " + quoted + "=" + c.jsonData(expr) + " WHERE " + c.quotedIdColumn() + "=$1 RETURNING *", values, nil
+}
+
+// jsonbData returns an expression of the data column as JSONB, which is cast from a JSON column.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) jsonbData() string {
+	if c.plainJson {
+		return c.QuoteIdentifier(c.jsonColumn) + "::jsonb"
+	}
+	return c.QuoteIdentifier(c.jsonColumn)
+}
 
-	query := "CREATE TABLE IF NOT EXISTS " + c.QuotedTableName() +
-		" (\"id\" " + idType + " PRIMARY KEY, \"data\" " + dataType + ")"
-	c.EnsureSchema(query)
+// jsonData casts the JSONB expression back into the type of the data column.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) jsonData(expr string) string {
+	if c.plainJson {
+		return "(" + expr + ")::json"
+	}
+	return expr
+}
+
+// Operator classes of GIN indexes on the JSON data column created by EnsureDataIndex.
+const (
+	// JsonbOps supports all JSONB operators, including key existence with ?, ?| and ?&.
+	JsonbOps = "jsonb_ops"
+	// JsonbPathOps supports only containment and JSON path operators, but is smaller and faster.
+	JsonbPathOps = "jsonb_path_ops"
+)
+
+// EnsureDataIndex adds a GIN index on the JSON data column to create it with the table on opening,
+// so containment and JSON path filters, e.g. built by JsonFilterBuilder, don't scan the whole table.
+//
+//	Parameters:
+//		- options index options:
+//			- name: (optional) an index name (default: <table>_data)
+//			- ops: (optional) an operator class: JsonbOps supporting all JSONB operators
+//			  or smaller and faster JsonbPathOps supporting only @>, @? and @@ (default: JsonbOps)
+//			- where, with, tablespace: (optional) see EnsureIndex
+func (c *IdentifiableJsonPostgresPersistence[T, K]) EnsureDataIndex(options map[string]string) {
+	name := options["name"]
+	if name == "" {
+		name = c.TableName + "_" + c.jsonColumn
+	}
+
+	key := c.quoteColumn(c.jsonColumn)
+	if c.plainJson {
+		// JSON values have no operator classes, so the index is built on the JSONB expression
+		key = "(" + c.jsonbData() + ")"
+	}
+	if strings.ToLower(options["ops"]) == JsonbPathOps {
+		key += " " + JsonbPathOps
+	}
+
+	indexOptions := map[string]string{"type": "gin"}
+	for _, option := range []string{"where", "with", "tablespace"} {
+		if value, ok := options[option]; ok {
+			indexOptions[option] = value
+		}
+	}
+	c.EnsureIndexWithKeys(name, []PostgresIndexKey{IndexKey(key)}, indexOptions)
+}
+
+// EnsureGeneratedColumn adds a stored column generated from a field of the JSON data to the table on opening,
+// so hot fields can be indexed and filtered natively, e.g. with Column(name) in filters.
+// Like EnsureColumn it is executed every time the persistence is opened, so the column is added to existing tables.
+// The conversion into the column type must be immutable, e.g. text can't be converted into a timestamp with time zone.
+//
+//	Parameters:
+//		- name a column name.
+//		- jsonPath a field name or a dot-separated path of a nested field.
+//		- pgType a PostgreSQL type of the column, e.g. TEXT or INTEGER.
+//		- indexed true to create an index on the column.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) EnsureGeneratedColumn(name string, jsonPath string, pgType string, indexed bool) {
+	if pgType == "" {
+		pgType = "TEXT"
+	}
+
+	expr := "(" + JsonField(c.jsonColumn, jsonPath) + ")::" + pgType
+	c.declareColumn(name, pgType)
+	c.updateStatements = append(c.updateStatements, "ALTER TABLE "+c.QuotedTableName()+
+		" ADD COLUMN IF NOT EXISTS "+c.QuoteIdentifier(name)+" "+pgType+" GENERATED ALWAYS AS ("+expr+") STORED")
+
+	if indexed {
+		// The index is created after the column, so it can't be a regular schema statement
+		indexName := c.TableName + "_" + name
+		c.declaredIndexes = append(c.declaredIndexes, indexName)
+		c.updateStatements = append(c.updateStatements, "CREATE INDEX IF NOT EXISTS "+c.QuoteIdentifier(indexName)+
+			" ON "+c.QuotedTableName()+" ("+c.QuoteIdentifier(name)+")")
+	}
+}
+
+// GetPageByFilterWithProjection gets a page of data items retrieved by a parameterized filter
+// with only the projected fields of the JSON data, see ComposeSelect. Other fields of the items get zero values,
+// which cuts the amount of transferred data when items are large. An empty projection selects whole items.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- filter            (optional) a WHERE clause with parameter placeholders
+//		- params            (optional) values of the filter parameters
+//		- paging            (optional) paging parameters
+//		- sort              (optional) ORDER BY clause composed by ComposeSort
+//		- projection        (optional) field names or dot-separated paths of nested fields to be selected
+//	Returns: receives a data page or error.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) GetPageByFilterWithProjection(ctx context.Context, correlationId string,
+	filter string, params []any, paging cdata.PagingParams, sort string, projection cdata.ProjectionParams) (page cdata.DataPage[T], err error) {
+
+	selection, err := c.ComposeSelect(ctx, correlationId, projection)
+	if err != nil {
+		return page, err
+	}
+	return c.GetPageByFilterWithParams(ctx, correlationId, filter, params, paging, sort, selection)
+}
+
+// GetListByFilterWithProjection gets a list of data items retrieved by a parameterized filter
+// with only the projected fields of the JSON data, see GetPageByFilterWithProjection.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- filter            (optional) a WHERE clause with parameter placeholders
+//		- params            (optional) values of the filter parameters
+//		- sort              (optional) ORDER BY clause composed by ComposeSort
+//		- projection        (optional) field names or dot-separated paths of nested fields to be selected
+//	Returns: data list or error.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) GetListByFilterWithProjection(ctx context.Context, correlationId string,
+	filter string, params []any, sort string, projection cdata.ProjectionParams) (items []T, err error) {
+
+	selection, err := c.ComposeSelect(ctx, correlationId, projection)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetListByFilterWithParams(ctx, correlationId, filter, params, sort, selection)
 }
 
 // ConvertToPublic converts object value from internal to public format.
@@ -140,9 +534,23 @@ func (c *IdentifiableJsonPostgresPersistence[T, K]) ConvertToPublic(rows pgx.Row
 		buf[(string)(column.Name)] = values[index]
 	}
 
-	item, ok := buf["data"]
+	item, ok := buf[c.jsonColumn]
 	if !ok {
+		// Projections of the id only have no data column
+		if id, ok := buf[c.idColumn]; ok {
+			delete(buf, c.idColumn)
+			buf["id"] = id
+		}
 		item = buf
+	} else if c.keyProvider != nil {
+		var err error
+		if item, err = c.decryptData(item); err != nil {
+			return defaultValue, err
+		}
+	}
+	item, err := c.maskDocument(item)
+	if err != nil {
+		return defaultValue, err
 	}
 
 	_buf, toJsonErr := cconv.JsonConverter.ToJson(item)
@@ -154,6 +562,44 @@ func (c *IdentifiableJsonPostgresPersistence[T, K]) ConvertToPublic(rows pgx.Row
 	return _item, fromJsonErr
 }
 
+// maskDocument replaces values of the masked fields in the document, see SetMaskedFields.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) maskDocument(document any) (any, error) {
+	if len(c.maskedFields) == 0 {
+		return document, nil
+	}
+	fields, ok := document.(map[string]any)
+	if !ok {
+		buf, err := cconv.JsonConverter.ToJson(document)
+		if err != nil {
+			return nil, err
+		}
+		fields = cconv.JsonConverter.ToMap(buf)
+	}
+	c.maskFields(fields)
+	return fields, nil
+}
+
+// publicDocument converts the JSON document read from the data column, e.g. of the history table,
+// into the document of a data item: it's decrypted and its masked fields are replaced like by ConvertToPublic.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) publicDocument(data string) (string, error) {
+	if c.keyProvider == nil && len(c.maskedFields) == 0 {
+		return data, nil
+	}
+	var document any
+	if err := json.Unmarshal([]byte(data), &document); err != nil {
+		return "", err
+	}
+	document, err := c.decryptData(document)
+	if err != nil {
+		return "", err
+	}
+	if document, err = c.maskDocument(document); err != nil {
+		return "", err
+	}
+	buf, err := json.Marshal(document)
+	return string(buf), err
+}
+
 // ConvertFromPublic convert object value from public to internal format.
 //	Parameters:
 //    - value     an object in public format to convert.
@@ -162,8 +608,15 @@ func (c *IdentifiableJsonPostgresPersistence[T, K]) ConvertFromPublic(value T) (
 	id := GetObjectId[K](value)
 
 	result := map[string]any{
-		"id":   id,
-		"data": value,
+		c.idColumn:   id,
+		c.jsonColumn: value,
+	}
+	if c.keyProvider != nil {
+		data, err := c.encryptData(value)
+		if err != nil {
+			return nil, err
+		}
+		result[c.jsonColumn] = data
 	}
 	return result, nil
 }
@@ -185,6 +638,7 @@ func (c *IdentifiableJsonPostgresPersistence[T, K]) ConvertFromPublicPartial(val
 }
 
 // UpdatePartially updates only few selected fields in a data item.
+// Top-level fields are replaced, unless options.deep_merge is set to merge nested objects field by field.
 //	Parameters:
 //		- ctx context.Context
 //		- correlation_id    (optional) transaction id to trace execution through call chain.
@@ -194,10 +648,31 @@ func (c *IdentifiableJsonPostgresPersistence[T, K]) ConvertFromPublicPartial(val
 func (c *IdentifiableJsonPostgresPersistence[T, K]) UpdatePartially(ctx context.Context, correlationId string,
 	id K, data cdata.AnyValueMap) (result T, err error) {
 
-	query := "UPDATE " + c.QuotedTableName() + " SET \"data\"=\"data\"||$2 WHERE \"id\"=$1 RETURNING *"
-	values := []any{id, data.Value()}
+	if data, err = c.beforeUpdatePartially(ctx, correlationId, id, data); err != nil {
+		return result, err
+	}
+	defer c.forgetIdentity(ctx, id)
+	if c.keyProvider != nil {
+		if result, err = c.updateEncryptedPartially(ctx, correlationId, id, data); err != nil {
+			return result, err
+		}
+		c.Logger.Trace(ctx, correlationId, "Updated partially in %s with id = %s", c.TableName, id)
+		return result, c.afterUpdate(ctx, correlationId, result)
+	}
+	quoted := c.QuoteIdentifier(c.jsonColumn)
+	statement, params := "UPDATE "+c.QuotedTableName()+" SET "+quoted+"="+c.jsonData(c.jsonbData()+"||$2::jsonb")+" WHERE "+c.quotedIdColumn()+"=$1 RETURNING *",
+		[]any{id, data.Value()}
+	if c.deepMerge {
+		if statement, params, err = c.composeDeepMerge(id, data); err != nil {
+			return result, err
+		}
+	}
+	query, values, err := c.applyTenantCondition(ctx, correlationId, statement, params)
+	if err != nil {
+		return result, err
+	}
 
-	rows, err := c.IdentifiablePostgresPersistence.Client.Query(ctx, query, values...)
+	rows, err := c.query(ctx, correlationId, query, values...)
 	if err != nil {
 		return result, err
 	}
@@ -214,7 +689,7 @@ func (c *IdentifiableJsonPostgresPersistence[T, K]) UpdatePartially(ctx context.
 			return result, convErr
 		}
 		c.IdentifiablePostgresPersistence.Logger.Trace(ctx, correlationId, "Updated partially in %s with id = %s", c.IdentifiablePostgresPersistence.TableName, id)
-		return result, nil
+		return result, c.afterUpdate(ctx, correlationId, result)
 	}
 	return result, rows.Err()
 }
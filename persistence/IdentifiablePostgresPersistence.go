@@ -2,13 +2,13 @@ package persistence
 
 import (
 	"context"
+	"reflect"
 	"strconv"
-
-	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
-	cpersist "github.com/pip-services3-gox/pip-services3-data-gox/persistence"
+	"strings"
 
 	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
 	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
 )
 
 // IdentifiablePostgresPersistence Abstract persistence component that stores data in PostgreSQL
@@ -22,6 +22,10 @@ import (
 // In complex scenarios child classes can implement additional operations by
 // accessing c._collection and c._model properties.
 //
+// Integer ids (K of int, int32 or int64 types) are generated by the database when created items
+// have zero ids, so the id column must be an identity or BIGSERIAL column. EnsureTableFromStruct
+// creates an identity column automatically.
+//
 //	Configuration parameters
 //		- collection:               (optional) PostgreSQL collection name
 //		- connection(s):
@@ -37,6 +41,28 @@ import (
 //			- connect_timeout:      (optional) number of milliseconds to wait before timing out when connecting a new client (default: 0)
 //			- idle_timeout:         (optional) number of milliseconds a client must sit idle in the pool and not be checked out (default: 10000)
 //			- max_pool_size:        (optional) maximum number of clients the pool should contain (default: 10)
+//			- id_sequence:          (optional) a sequence to take ids of created items without ids from, see EnsureSequence.
+//			                        The id column must have a nextval default, what EnsureTableFromStruct adds automatically.
+//			                        It's not supported by JSON persistences.
+//			- version_column:       (optional) a column with the item version incremented by Update and UpdatePartially, which are not retried then.
+//			                        When the updated item has a version different from the stored one, ConflictError is returned.
+//			                        It's not supported by JSON persistences.
+//			- server_ids:           (optional) omits empty ids of created items, so they are generated by the id column default,
+//			                        e.g. DEFAULT gen_random_uuid(), and returned by RETURNING. EnsureTableFromStruct adds the default
+//			                        automatically. It's not supported by JSON persistences (default: false)
+//			- conflict_target:      (optional) a comma-separated list of columns or expressions in parentheses of a unique key
+//			                        which matches items set by Set, SetMany and QueueSet with existing rows (default: id)
+//			- conflict_action:      (optional) an action on existing rows: update or nothing. With nothing Set returns
+//			                        an empty item when the row exists (default: update)
+//			- conflict_update_columns: (optional) a comma-separated list of columns updated in existing rows (default: all columns
+//			                        except the id and columns of another conflict target)
+//			- merge_strategy:       (optional) how Set and Update merge items with stored rows: replace, ignore_nulls or ignore_empty.
+//			                        Ignored columns keep stored values in existing rows and get column defaults in created ones.
+//			                        UpdatePartially always sets the given fields (default: replace)
+//			- skip_returning:       (optional) executes Create, Update and DeleteById without RETURNING and conversion of stored rows.
+//			                        Create and Update return the given items, so values set by the database are not refreshed,
+//			                        and DeleteById returns an item with only the id set. Create still returns rows when ids
+//			                        are generated by the database (default: false)
 //
 //	References
 //		- *:logger:*:*:1.0           (optional) ILogger components to pass log messages components to pass log messages
@@ -62,31 +88,27 @@ import (
 //			c.EnsureIndex(c.IdentifiablePostgresPersistence.TableName+"_key", map[string]string{"key": "1"}, map[string]string{"unique": "true"})
 //		}
 //
+//		func (c *DummyPostgresPersistence) composeFilter(filter cdata.FilterParams) (string, []any) {
+//			return persist.NewPostgresFilterBuilder().
+//				Equal("Key", persist.Column("key")).
+//				Build(filter)
+//		}
+//
 //		func (c *DummyPostgresPersistence) GetPageByFilter(ctx context.Context, correlationId string,
 //			filter cdata.FilterParams, paging cdata.PagingParams) (page cdata.DataPage[fixtures.Dummy], err error) {
 //
-//			key, ok := filter.GetAsNullableString("Key")
-//			filterObj := ""
-//			if ok && key != "" {
-//				filterObj += "key='" + key + "'"
-//			}
-//			sorting := ""
-//
-//			return c.IdentifiablePostgresPersistence.GetPageByFilter(ctx, correlationId,
-//				filterObj, paging,
-//				sorting, "",
+//			where, params := c.composeFilter(filter)
+//			return c.IdentifiablePostgresPersistence.GetPageByFilterWithParams(ctx, correlationId,
+//				where, params, paging,
+//				"", "",
 //			)
 //		}
 //
 //		func (c *DummyPostgresPersistence) GetCountByFilter(ctx context.Context, correlationId string,
 //			filter cdata.FilterParams) (count int64, err error) {
 //
-//			key, ok := filter.GetAsNullableString("Key")
-//			filterObj := ""
-//			if ok && key != "" {
-//				filterObj += "key='" + key + "'"
-//			}
-//			return c.IdentifiablePostgresPersistence.GetCountByFilter(ctx, correlationId, filterObj)
+//			where, params := c.composeFilter(filter)
+//			return c.IdentifiablePostgresPersistence.GetCountByFilterWithParams(ctx, correlationId, where, params)
 //		}
 //
 //		func (c *DummyPostgresPersistence) GetOneRandom(ctx context.Context, correlationId string) (item fixtures.Dummy, err error) {
@@ -109,6 +131,12 @@ func InheritIdentifiablePostgresPersistence[T any, K any](overrides IPostgresPer
 	c := &IdentifiablePostgresPersistence[T, K]{}
 	c.PostgresPersistence = InheritPostgresPersistence[T](overrides, tableName)
 
+	var id K
+	c.integerIds = isIntegerKind(reflect.TypeOf(id))
+	if idType := reflect.TypeOf(id); idType != nil {
+		c.idType = composeColumnType(idType)
+	}
+
 	return c
 }
 
@@ -120,12 +148,26 @@ func InheritIdentifiablePostgresPersistence[T any, K any](overrides IPostgresPer
 //	Returns: a data list or error.
 func (c *IdentifiablePostgresPersistence[T, K]) GetListByIds(ctx context.Context, correlationId string,
 	ids []K) (items []T, err error) {
+	return withRetries(ctx, c.PostgresPersistence, correlationId, "GetListByIds", func() ([]T, error) {
+		return c.getListByIds(ctx, correlationId, ids)
+	})
+}
 
-	ln := len(ids)
-	params := c.GenerateParameters(ln)
-	query := "SELECT * FROM " + c.QuotedTableName() + " WHERE \"id\" IN(" + params + ")"
+// getListByIds is a single attempt of GetListByIds.
+func (c *IdentifiablePostgresPersistence[T, K]) getListByIds(ctx context.Context, correlationId string,
+	ids []K) (items []T, err error) {
 
-	rows, err := c.Client.Query(ctx, query, ItemsToAnySlice(ids)...)
+	if len(ids) == 0 {
+		return []T{}, nil
+	}
+	// Ids are passed in a single array parameter, so their number isn't limited by the number of bound parameters
+	query, params, err := c.applyTenantCondition(ctx, correlationId,
+		"SELECT * FROM "+c.QuotedTableName()+" WHERE "+c.quotedIdColumn()+"=ANY($1)", []any{ids})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.queryRead(ctx, correlationId, query, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -133,11 +175,9 @@ func (c *IdentifiablePostgresPersistence[T, K]) GetListByIds(ctx context.Context
 
 	items = make([]T, 0)
 	for rows.Next() {
-		if c.IsTerminated() {
+		if err := c.checkInterrupted(ctx, correlationId); err != nil {
 			rows.Close()
-			return nil, cerr.
-				NewError("query terminated").
-				WithCorrelationId(correlationId)
+			return nil, err
 		}
 		item, convErr := c.Overrides.ConvertToPublic(rows)
 		if convErr != nil {
@@ -153,17 +193,72 @@ func (c *IdentifiablePostgresPersistence[T, K]) GetListByIds(ctx context.Context
 	return items, rows.Err()
 }
 
+// GetListByIdsInOrder gets a list of data items retrieved by given unique ids
+// in the same order as the ids. Duplicated ids are returned once.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- ids of data items to be retrieved
+//	Returns: a data list, ids of items which were not found or error.
+func (c *IdentifiablePostgresPersistence[T, K]) GetListByIdsInOrder(ctx context.Context, correlationId string,
+	ids []K) (items []T, missing []K, err error) {
+
+	found, err := c.GetListByIds(ctx, correlationId, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	itemsById := make(map[any]T, len(found))
+	for _, item := range found {
+		itemsById[GetObjectId[K](item)] = item
+	}
+
+	items = make([]T, 0, len(found))
+	missing = make([]K, 0)
+	seen := make(map[any]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if item, ok := itemsById[id]; ok {
+			items = append(items, item)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return items, missing, nil
+}
+
 // GetOneById gets a data item by its unique id.
+// When the context has an identity map, see NewContextWithIdentityMap, items already read
+// within the request are returned from memory.
 //	Parameters:
 //		- ctx context.Context
 //		- correlationId     (optional) transaction id to trace execution through call chain.
 //		- id                an id of data item to be retrieved.
 // Returns: data item or error.
 func (c *IdentifiablePostgresPersistence[T, K]) GetOneById(ctx context.Context, correlationId string, id K) (item T, err error) {
+	if item, ok := c.recallIdentity(ctx, id); ok {
+		c.Logger.Trace(ctx, correlationId, "Retrieved from identity map of %s with id = %s", c.TableName, id)
+		return item, nil
+	}
+	return withRetries(ctx, c.PostgresPersistence, correlationId, "GetOneById", func() (T, error) {
+		return c.getOneById(ctx, correlationId, id)
+	})
+}
+
+// getOneById is a single attempt of GetOneById.
+func (c *IdentifiablePostgresPersistence[T, K]) getOneById(ctx context.Context, correlationId string, id K) (item T, err error) {
 
-	query := "SELECT * FROM " + c.QuotedTableName() + " WHERE \"id\"=$1"
+	query, params, err := c.applyTenantCondition(ctx, correlationId,
+		"SELECT * FROM "+c.QuotedTableName()+" WHERE "+c.quotedIdColumn()+"=$1", []any{id})
+	if err != nil {
+		return item, err
+	}
 
-	rows, err := c.Client.Query(ctx, query, id)
+	rows, err := c.queryRead(ctx, correlationId, query, params...)
 	if err != nil {
 		return item, err
 	}
@@ -176,12 +271,72 @@ func (c *IdentifiablePostgresPersistence[T, K]) GetOneById(ctx context.Context,
 	values, err := rows.Values()
 	if err == nil && len(values) > 0 {
 		c.Logger.Trace(ctx, correlationId, "Retrieved from %s with id = %s", c.TableName, id)
-		return c.Overrides.ConvertToPublic(rows)
+		item, err = c.Overrides.ConvertToPublic(rows)
+		if err == nil {
+			c.rememberIdentity(ctx, id, item)
+		}
+		return item, err
 	}
 	c.Logger.Trace(ctx, correlationId, "Nothing found from %s with id = %s", c.TableName, id)
 	return item, err
 }
 
+// GetOneByIdForUpdate gets a data item by its unique id and locks its row until the end of the transaction,
+// so the item can be safely changed and saved in a read-modify-write workflow.
+// The context must carry a transaction started by PostgresConnection.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- id                an id of data item to be retrieved.
+//		- lock              (optional) a row lock: RowLockForUpdate (default), RowLockForUpdateNoWait,
+//		                    RowLockForUpdateSkipLocked or RowLockForShare.
+// Returns: data item or error. With RowLockForUpdateSkipLocked an empty item is returned when the row is locked,
+// with RowLockForUpdateNoWait ConflictError is returned.
+func (c *IdentifiablePostgresPersistence[T, K]) GetOneByIdForUpdate(ctx context.Context, correlationId string,
+	id K, lock string) (item T, err error) {
+
+	if lock == "" {
+		lock = RowLockForUpdate
+	}
+	lockClause, err := c.composeRowLock(ctx, correlationId, lock)
+	if err != nil {
+		return item, err
+	}
+
+	query, params, err := c.applyTenantCondition(ctx, correlationId,
+		"SELECT * FROM "+c.QuotedTableName()+" WHERE "+c.quotedIdColumn()+"=$1", []any{id})
+	if err != nil {
+		return item, err
+	}
+
+	rows, err := c.query(ctx, correlationId, query+lockClause, params...)
+	if err != nil {
+		return item, c.wrapRowLockError(correlationId, err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return item, c.wrapRowLockError(correlationId, rows.Err())
+	}
+
+	values, err := rows.Values()
+	if err == nil && len(values) > 0 {
+		c.Logger.Trace(ctx, correlationId, "Retrieved and locked from %s with id = %s", c.TableName, id)
+		return c.Overrides.ConvertToPublic(rows)
+	}
+	return item, err
+}
+
+// ExistsById checks if a data item with the given id exists without retrieving it.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- id                an id of data item to be checked.
+// Returns: true if the item exists or error.
+func (c *IdentifiablePostgresPersistence[T, K]) ExistsById(ctx context.Context, correlationId string, id K) (bool, error) {
+	return c.ExistsByFilterWithParams(ctx, correlationId, c.quotedIdColumn()+"=$1", []any{id})
+}
+
 // Create a data item.
 //	Parameters:
 //		- ctx context.Context
@@ -190,39 +345,109 @@ func (c *IdentifiablePostgresPersistence[T, K]) GetOneById(ctx context.Context,
 //	Returns: (optional)  created item or error.
 func (c *IdentifiablePostgresPersistence[T, K]) Create(ctx context.Context, correlationId string, item T) (result T, err error) {
 	newItem := c.cloneItem(item)
-	newItem = GenerateObjectIdIfNotExists[T](newItem)
+	if !c.generatesIds() {
+		newItem = GenerateObjectIdIfNotExists[T](newItem)
+	}
 
 	return c.PostgresPersistence.Create(ctx, correlationId, newItem)
 }
 
+// CreateMany creates multiple data items using multi-row INSERT statements.
+// Items without ids get generated ids. Large lists are split into chunks of up to
+// max_batch_size items, so they are created atomically only within a transaction.
+//	Parameters:
+//		- ctx context.Context
+//		- correlation_id    (optional) transaction id to trace execution through call chain.
+//		- items             a list of items to be created.
+//	Returns: (optional)  created items or error.
+func (c *IdentifiablePostgresPersistence[T, K]) CreateMany(ctx context.Context, correlationId string, items []T) ([]T, error) {
+	if len(items) == 0 {
+		return []T{}, nil
+	}
+
+	objMaps := make([]map[string]any, 0, len(items))
+	newItems := make([]any, 0, len(items))
+	for _, item := range items {
+		newItem := c.cloneItem(item)
+		if !c.generatesIds() {
+			newItem = GenerateObjectIdIfNotExists[T](newItem)
+		}
+		newItem, err := c.beforeCreate(ctx, correlationId, newItem)
+		if err != nil {
+			return nil, err
+		}
+		newItems = append(newItems, newItem)
+
+		objMap, convErr := c.Overrides.ConvertFromPublic(newItem)
+		if convErr != nil {
+			return nil, convErr
+		}
+		c.omitGeneratedId(objMap)
+		objMaps = append(objMaps, objMap)
+	}
+	if err := c.inferColumns(ctx, correlationId, newItems...); err != nil {
+		return nil, err
+	}
+
+	results, err := c.insertMany(ctx, correlationId, objMaps, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.Logger.Trace(ctx, correlationId, "Created %d items in %s", len(results), c.TableName)
+
+	for _, result := range results {
+		if err := c.afterCreate(ctx, correlationId, result); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
 // Set a data item. If the data item exists it updates it,
 // otherwise it creates a new data item.
+// Items are matched by the id unless options.conflict_target sets another unique key,
+// and options.conflict_action and options.conflict_update_columns choose how existing items are changed.
 //	Parameters:
 //		- ctx context.Context
 //		- correlation_id    (optional) transaction id to trace execution through call chain.
 //		- item              an item to be set.
 //	Returns: (optional)  updated item or error.
 func (c *IdentifiablePostgresPersistence[T, K]) Set(ctx context.Context, correlationId string, item T) (result T, err error) {
+	if item, err = c.beforeUpdate(ctx, correlationId, item); err != nil {
+		return result, err
+	}
+	result, err = withRetries(ctx, c.PostgresPersistence, correlationId, "Set", func() (T, error) {
+		return c.set(ctx, correlationId, item)
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, c.afterUpdate(ctx, correlationId, result)
+}
+
+// set is a single attempt of Set.
+func (c *IdentifiablePostgresPersistence[T, K]) set(ctx context.Context, correlationId string, item T) (result T, err error) {
+	if err := c.inferColumns(ctx, correlationId, item); err != nil {
+		return result, err
+	}
 	objMap, convErr := c.Overrides.ConvertFromPublic(item)
 	if convErr != nil {
 		return result, convErr
 	}
 
-	GenerateObjectMapIdIfNotExists(objMap)
+	c.generateObjectMapId(objMap)
+	objMap = c.applyMergeStrategy(objMap)
+	if err := c.applyTenantValue(ctx, correlationId, objMap); err != nil {
+		return result, err
+	}
 
 	columns, values := c.GenerateColumnsAndValues(objMap)
+	id := c.objectMapId(objMap)
+	defer c.forgetIdentity(ctx, id)
 
-	paramsStr := c.GenerateParameters(len(values))
-	columnsStr := c.GenerateColumns(columns)
-	setParams := c.GenerateSetParameters(columns)
-
-	id := cpersist.GetObjectId(objMap)
+	query := c.getSetStatement(columns)
 
-	query := "INSERT INTO " + c.QuotedTableName() + " (" + columnsStr + ")" +
-		" VALUES (" + paramsStr + ")" +
-		" ON CONFLICT (\"id\") DO UPDATE SET " + setParams + " RETURNING *"
-
-	rows, err := c.Client.Query(ctx, query, values...)
+	rows, err := c.query(ctx, correlationId, query, values...)
 	if err != nil {
 		return result, err
 	}
@@ -245,6 +470,99 @@ func (c *IdentifiablePostgresPersistence[T, K]) Set(ctx context.Context, correla
 
 }
 
+// SetMany sets multiple data items using multi-row INSERT ... ON CONFLICT DO UPDATE statements.
+// Existing data items are updated, others are created. Items without ids get generated ids.
+// When the list contains the same id several times, only the last item is set.
+//	Parameters:
+//		- ctx context.Context
+//		- correlation_id    (optional) transaction id to trace execution through call chain.
+//		- items             a list of items to be set.
+//	Returns: (optional)  resulting items or error.
+func (c *IdentifiablePostgresPersistence[T, K]) SetMany(ctx context.Context, correlationId string, items []T) ([]T, error) {
+	if len(c.interceptors) > 0 {
+		intercepted := make([]T, 0, len(items))
+		for _, item := range items {
+			item, err := c.beforeUpdate(ctx, correlationId, item)
+			if err != nil {
+				return nil, err
+			}
+			intercepted = append(intercepted, item)
+		}
+		items = intercepted
+	}
+
+	results, err := withRetries(ctx, c.PostgresPersistence, correlationId, "SetMany", func() ([]T, error) {
+		return c.setMany(ctx, correlationId, items)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		if err := c.afterUpdate(ctx, correlationId, result); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// setMany is a single attempt of SetMany.
+func (c *IdentifiablePostgresPersistence[T, K]) setMany(ctx context.Context, correlationId string, items []T) ([]T, error) {
+	if len(items) == 0 {
+		return []T{}, nil
+	}
+
+	objMaps := make([]map[string]any, 0, len(items))
+	positions := make(map[any]int, len(items))
+	defer func() {
+		for id := range positions {
+			c.forgetIdentity(ctx, id)
+		}
+	}()
+	for _, item := range items {
+		objMap, convErr := c.Overrides.ConvertFromPublic(item)
+		if convErr != nil {
+			return nil, convErr
+		}
+		c.generateObjectMapId(objMap)
+		objMap = c.applyMergeStrategy(objMap)
+
+		id := c.objectMapId(objMap)
+		if position, ok := positions[id]; ok {
+			objMaps[position] = objMap
+			continue
+		}
+		positions[id] = len(objMaps)
+		objMaps = append(objMaps, objMap)
+	}
+
+	inferred := make([]any, 0, len(items))
+	for _, item := range items {
+		inferred = append(inferred, item)
+	}
+	if err := c.inferColumns(ctx, correlationId, inferred...); err != nil {
+		return nil, err
+	}
+
+	results, err := c.insertMany(ctx, correlationId, objMaps, c.composeConflictClause)
+	if err != nil {
+		return nil, err
+	}
+	c.Logger.Trace(ctx, correlationId, "Set %d items in %s", len(results), c.TableName)
+	return results, nil
+}
+
+// getSetStatement returns a cached upsert statement for the set of columns.
+func (c *IdentifiablePostgresPersistence[T, K]) getSetStatement(columns []string) string {
+	return c.GetStatement("set:"+strings.Join(columns, ","), func() string {
+		paramsStr := c.GenerateParameters(len(columns))
+		columnsStr := c.GenerateColumns(columns)
+
+		return "INSERT INTO " + c.QuotedTableName() + " (" + columnsStr + ")" +
+			" VALUES (" + paramsStr + ")" +
+			c.composeConflictClause(columns) + " RETURNING *"
+	})
+}
+
 // Update a data item.
 //	Parameters:
 //		- ctx context.Context
@@ -252,25 +570,66 @@ func (c *IdentifiablePostgresPersistence[T, K]) Set(ctx context.Context, correla
 //		- item              an item to be updated.
 //	Returns          (optional)  updated item or error.
 func (c *IdentifiablePostgresPersistence[T, K]) Update(ctx context.Context, correlationId string, item T) (result T, err error) {
+	if item, err = c.beforeUpdate(ctx, correlationId, item); err != nil {
+		return result, err
+	}
+	// Versioned updates increment the version, so they are not idempotent
+	result, err = withRetriesIf(ctx, c.PostgresPersistence, correlationId, "Update", c.versionColumn == "", func() (T, error) {
+		return c.update(ctx, correlationId, item)
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, c.afterUpdate(ctx, correlationId, result)
+}
+
+// update is a single attempt of Update.
+func (c *IdentifiablePostgresPersistence[T, K]) update(ctx context.Context, correlationId string, item T) (result T, err error) {
+	if err := c.inferColumns(ctx, correlationId, item); err != nil {
+		return result, err
+	}
 	objMap, convErr := c.Overrides.ConvertFromPublic(item)
 	if convErr != nil {
 		return result, convErr
 	}
-	columns, values := c.GenerateColumnsAndValues(objMap)
-	paramsStr := c.GenerateSetParameters(columns)
-	id := cpersist.GetObjectId(objMap)
-	values = append(values, id)
+	objMap = c.applyMergeStrategy(objMap)
+	if err := c.applyTenantValue(ctx, correlationId, objMap); err != nil {
+		return result, err
+	}
+	id := c.objectMapId(objMap)
+	defer c.forgetIdentity(ctx, id)
+	query, values, versionChecked := c.composeUpdate(objMap, id)
+	query, values, err = c.applyTenantCondition(ctx, correlationId, query, values)
+	if err != nil {
+		return result, err
+	}
 
-	query := "UPDATE " + c.QuotedTableName() +
-		" SET " + paramsStr + " WHERE \"id\"=$" + strconv.FormatInt((int64)(len(values)), 10) + " RETURNING *"
+	if c.skipReturning {
+		tag, err := c.exec(ctx, correlationId, strings.TrimSuffix(query, " RETURNING *"), values...)
+		if err != nil {
+			return result, err
+		}
+		if tag.RowsAffected() == 0 {
+			if versionChecked {
+				return result, c.checkVersionConflict(ctx, correlationId, id)
+			}
+			return result, nil
+		}
+		c.Logger.Trace(ctx, correlationId, "Updated in %s with id = %s", c.TableName, id)
+		return item, nil
+	}
 
-	rows, err := c.Client.Query(ctx, query, values...)
+	rows, err := c.query(ctx, correlationId, query, values...)
 	if err != nil {
 		return result, err
 	}
 	defer rows.Close()
 	if !rows.Next() {
-		return result, rows.Err()
+		if err = rows.Err(); err != nil || !versionChecked {
+			return result, err
+		}
+		rows.Close()
+		return result, c.checkVersionConflict(ctx, correlationId, id)
 	}
 
 	_values, err := rows.Values()
@@ -286,6 +645,8 @@ func (c *IdentifiablePostgresPersistence[T, K]) Update(ctx context.Context, corr
 }
 
 // UpdatePartially updates only few selected fields in a data item.
+// Fields with dotted paths, e.g. address.city, update nested fields of JSONB columns
+// with jsonb_set, while other fields update their columns as a whole.
 //	Parameters:
 //		- ctx context.Context
 //		- correlation_id    (optional) transaction id to trace execution through call chain.
@@ -293,25 +654,64 @@ func (c *IdentifiablePostgresPersistence[T, K]) Update(ctx context.Context, corr
 //		- data              a map with fields to be updated.
 //	Returns: updated item or error.
 func (c *IdentifiablePostgresPersistence[T, K]) UpdatePartially(ctx context.Context, correlationId string, id K, data cdata.AnyValueMap) (result T, err error) {
-	objMap, convErr := c.Overrides.ConvertFromPublicPartial(data.Value())
+	if data, err = c.beforeUpdatePartially(ctx, correlationId, id, data); err != nil {
+		return result, err
+	}
+	// Versioned updates increment the version, so they are not idempotent
+	result, err = withRetriesIf(ctx, c.PostgresPersistence, correlationId, "UpdatePartially", c.versionColumn == "", func() (T, error) {
+		return c.updatePartially(ctx, correlationId, id, data)
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, c.afterUpdate(ctx, correlationId, result)
+}
+
+// updatePartially is a single attempt of UpdatePartially.
+func (c *IdentifiablePostgresPersistence[T, K]) updatePartially(ctx context.Context, correlationId string, id K, data cdata.AnyValueMap) (result T, err error) {
+	defer c.forgetIdentity(ctx, id)
+	fields, nested := splitNestedFields(data.Value())
+	if err := c.inferColumns(ctx, correlationId, fields); err != nil {
+		return result, err
+	}
+	objMap, convErr := c.Overrides.ConvertFromPublicPartial(fields)
 	if convErr != nil {
 		return result, convErr
 	}
-	columns, values := c.GenerateColumnsAndValues(objMap)
-	paramsStr := c.GenerateSetParameters(columns)
-	values = append(values, id)
 
-	query := "UPDATE " + c.QuotedTableName() +
-		" SET " + paramsStr + " WHERE \"id\"=$" + strconv.FormatInt((int64)(len(values)), 10) + " RETURNING *"
+	// Items can't be moved to other tenants
+	if _, ok := objMap[c.NamingStrategy.ColumnName(c.tenantColumn)]; ok && c.tenantColumn != "" {
+		if err := c.applyTenantValue(ctx, correlationId, objMap); err != nil {
+			return result, err
+		}
+	}
+
+	query, values, versionChecked := "", []any(nil), false
+	if len(nested) > 0 {
+		query, values, versionChecked, err = c.composeNestedUpdate(correlationId, objMap, nested, id)
+		if err != nil {
+			return result, err
+		}
+	} else {
+		query, values, versionChecked = c.composeUpdate(objMap, id)
+	}
+	query, values, err = c.applyTenantCondition(ctx, correlationId, query, values)
+	if err != nil {
+		return result, err
+	}
 
-	rows, err := c.Client.Query(ctx, query, values...)
+	rows, err := c.query(ctx, correlationId, query, values...)
 	if err != nil {
 		return result, err
 	}
 	defer rows.Close()
 
 	if !rows.Next() {
-		return result, rows.Err()
+		if err = rows.Err(); err != nil || !versionChecked {
+			return result, err
+		}
+		rows.Close()
+		return result, c.checkVersionConflict(ctx, correlationId, id)
 	}
 
 	_values, err := rows.Values()
@@ -326,6 +726,80 @@ func (c *IdentifiablePostgresPersistence[T, K]) UpdatePartially(ctx context.Cont
 	return result, rows.Err()
 }
 
+// getUpdateStatement returns a cached UPDATE statement for the set of columns with the id as the last parameter.
+func (c *IdentifiablePostgresPersistence[T, K]) getUpdateStatement(columns []string) string {
+	return c.GetStatement("update:"+strings.Join(columns, ","), func() string {
+		paramsStr := c.GenerateSetParameters(columns)
+		return "UPDATE " + c.QuotedTableName() +
+			" SET " + paramsStr + " WHERE " + c.quotedIdColumn() + "=$" + strconv.FormatInt((int64)(len(columns)+1), 10) + " RETURNING *"
+	})
+}
+
+// composeUpdate builds an UPDATE statement with its parameters for the data item map.
+// When options.version_column is set the version is incremented and, if the map contains
+// the expected version, compared with the stored one to detect concurrent changes.
+//
+//	Returns: the statement, its parameters and true if the version is checked.
+func (c *IdentifiablePostgresPersistence[T, K]) composeUpdate(objMap map[string]any, id any) (string, []any, bool) {
+	if c.versionColumn == "" {
+		columns, values := c.GenerateColumnsAndValues(objMap)
+		return c.getUpdateStatement(columns), append(values, id), false
+	}
+
+	version, checked := objMap[c.versionColumn]
+	checked = checked && version != nil
+	delete(objMap, c.versionColumn)
+
+	columns, values := c.GenerateColumnsAndValues(objMap)
+	values = append(values, id)
+	if checked {
+		values = append(values, version)
+	}
+	return c.getVersionedUpdateStatement(columns, checked), values, checked
+}
+
+// getVersionedUpdateStatement returns a cached UPDATE statement for the set of columns which increments the version.
+// The id is the parameter after the columns followed by the expected version when it's checked.
+func (c *IdentifiablePostgresPersistence[T, K]) getVersionedUpdateStatement(columns []string, checked bool) string {
+	key := "update:version:" + strconv.FormatBool(checked) + ":" + strings.Join(columns, ",")
+	return c.GetStatement(key, func() string {
+		version := c.QuoteIdentifier(c.versionColumn)
+		paramsStr := version + "=" + version + "+1"
+		if len(columns) > 0 {
+			paramsStr = c.GenerateSetParameters(columns) + "," + paramsStr
+		}
+
+		query := "UPDATE " + c.QuotedTableName() + " SET " + paramsStr +
+			" WHERE " + c.quotedIdColumn() + "=$" + strconv.Itoa(len(columns)+1)
+		if checked {
+			query += " AND " + version + "=$" + strconv.Itoa(len(columns)+2)
+		}
+		return query + " RETURNING *"
+	})
+}
+
+// checkVersionConflict returns ConflictError when the item with the id exists,
+// what means that a versioned update didn't match the stored version.
+func (c *IdentifiablePostgresPersistence[T, K]) checkVersionConflict(ctx context.Context, correlationId string, id any) error {
+	query, params, err := c.applyTenantCondition(ctx, correlationId,
+		"SELECT 1 FROM "+c.QuotedTableName()+" WHERE "+c.quotedIdColumn()+"=$1", []any{id})
+	if err != nil {
+		return err
+	}
+	rows, err := c.query(ctx, correlationId, query, params...)
+	if err != nil {
+		return err
+	}
+	exists := rows.Next()
+	rows.Close()
+	if err = rows.Err(); err != nil || !exists {
+		return err
+	}
+	return cerr.NewConflictError(correlationId, "VERSION_CONFLICT",
+		"Item in "+c.TableName+" was changed concurrently").
+		WithDetails("id", id)
+}
+
 // DeleteById deletes a data item by its unique id.
 //	Parameters:
 //		- ctx context.Context
@@ -333,9 +807,37 @@ func (c *IdentifiablePostgresPersistence[T, K]) UpdatePartially(ctx context.Cont
 //		- id                an id of the item to be deleted
 //	Returns: (optional)  deleted item or error.
 func (c *IdentifiablePostgresPersistence[T, K]) DeleteById(ctx context.Context, correlationId string, id K) (result T, err error) {
-	query := "DELETE FROM " + c.QuotedTableName() + " WHERE \"id\"=$1 RETURNING *"
+	if err = c.beforeDelete(ctx, correlationId, id); err != nil {
+		return result, err
+	}
+	result, err = withRetries(ctx, c.PostgresPersistence, correlationId, "DeleteById", func() (T, error) {
+		return c.deleteById(ctx, correlationId, id)
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, c.afterDelete(ctx, correlationId, result)
+}
+
+// deleteById is a single attempt of DeleteById.
+func (c *IdentifiablePostgresPersistence[T, K]) deleteById(ctx context.Context, correlationId string, id K) (result T, err error) {
+	defer c.forgetIdentity(ctx, id)
+	query, params, err := c.applyTenantCondition(ctx, correlationId,
+		"DELETE FROM "+c.QuotedTableName()+" WHERE "+c.quotedIdColumn()+"=$1", []any{id})
+	if err != nil {
+		return result, err
+	}
+
+	if c.skipReturning {
+		tag, err := c.exec(ctx, correlationId, query, params...)
+		if err != nil || tag.RowsAffected() == 0 {
+			return result, err
+		}
+		c.Logger.Trace(ctx, correlationId, "Deleted from %s with id = %s", c.TableName, id)
+		return c.itemWithId(id)
+	}
 
-	rows, err := c.Client.Query(ctx, query, id)
+	rows, err := c.query(ctx, correlationId, query+" RETURNING *", params...)
 	if err != nil {
 		return result, err
 	}
@@ -357,6 +859,110 @@ func (c *IdentifiablePostgresPersistence[T, K]) DeleteById(ctx context.Context,
 	return result, rows.Err()
 }
 
+// itemWithId creates a data item with only the id set.
+func (c *IdentifiablePostgresPersistence[T, K]) itemWithId(id K) (T, error) {
+	buf, err := cconv.JsonConverter.ToJson(map[string]any{"id": id})
+	if err != nil {
+		var item T
+		return item, err
+	}
+	return c.JsonConvertor.FromJson(buf)
+}
+
+// QueueCreate adds creation of a data item to the batch.
+// If the item has no id, a new id is generated.
+//	Parameters:
+//		- batch a batch to add the statement to.
+//		- item an item to be created.
+//	Returns: error if the item can't be converted.
+func (c *IdentifiablePostgresPersistence[T, K]) QueueCreate(batch *PostgresBatch[T], item T) error {
+	newItem := c.cloneItem(item)
+	if !c.generatesIds() {
+		newItem = GenerateObjectIdIfNotExists[T](newItem)
+	}
+
+	return c.PostgresPersistence.QueueCreate(batch, newItem)
+}
+
+// QueueSet adds setting of a data item to the batch.
+// If the data item exists it is updated, otherwise a new data item is created.
+//	Parameters:
+//		- batch a batch to add the statement to.
+//		- item an item to be set.
+//	Returns: error if the item can't be converted.
+func (c *IdentifiablePostgresPersistence[T, K]) QueueSet(batch *PostgresBatch[T], item T) error {
+	objMap, err := c.Overrides.ConvertFromPublic(item)
+	if err != nil {
+		return err
+	}
+
+	c.generateObjectMapId(objMap)
+	objMap = c.applyMergeStrategy(objMap)
+
+	columns, values := c.GenerateColumnsAndValues(objMap)
+	batch.Queue(c.getSetStatement(columns), values...)
+	return nil
+}
+
+// QueueUpdate adds update of a data item to the batch.
+//	Parameters:
+//		- batch a batch to add the statement to.
+//		- item an item to be updated.
+//	Returns: error if the item can't be converted.
+func (c *IdentifiablePostgresPersistence[T, K]) QueueUpdate(batch *PostgresBatch[T], item T) error {
+	objMap, err := c.Overrides.ConvertFromPublic(item)
+	if err != nil {
+		return err
+	}
+	objMap = c.applyMergeStrategy(objMap)
+	columns, values := c.GenerateColumnsAndValues(objMap)
+	values = append(values, c.objectMapId(objMap))
+
+	batch.Queue(c.getUpdateStatement(columns), values...)
+	return nil
+}
+
+// QueueUpdatePartially adds update of few selected fields in a data item to the batch.
+//	Parameters:
+//		- batch a batch to add the statement to.
+//		- id an id of data item to be updated.
+//		- data a map with fields to be updated.
+//	Returns: error if the data can't be converted.
+func (c *IdentifiablePostgresPersistence[T, K]) QueueUpdatePartially(batch *PostgresBatch[T], id K, data cdata.AnyValueMap) error {
+	objMap, err := c.Overrides.ConvertFromPublicPartial(data.Value())
+	if err != nil {
+		return err
+	}
+	columns, values := c.GenerateColumnsAndValues(objMap)
+	values = append(values, id)
+
+	batch.Queue(c.getUpdateStatement(columns), values...)
+	return nil
+}
+
+// RestoreById restores a soft-deleted data item by its unique id
+// by clearing its deletion marker in the column set by options.deleted_column.
+//	Parameters:
+//		- ctx context.Context
+//		- correlation_id    (optional) transaction id to trace execution through call chain.
+//		- id                an id of the item to be restored
+//	Returns: (optional)  restored item or error.
+func (c *IdentifiablePostgresPersistence[T, K]) RestoreById(ctx context.Context, correlationId string, id K) (result T, err error) {
+	items, err := c.RestoreByFilterWithParams(ctx, correlationId, c.quotedIdColumn()+"=$1", []any{id})
+	if err != nil || len(items) == 0 {
+		return result, err
+	}
+	return items[0], nil
+}
+
+// QueueDeleteById adds deletion of a data item by its unique id to the batch.
+//	Parameters:
+//		- batch a batch to add the statement to.
+//		- id an id of the item to be deleted.
+func (c *IdentifiablePostgresPersistence[T, K]) QueueDeleteById(batch *PostgresBatch[T], id K) {
+	batch.Queue("DELETE FROM "+c.QuotedTableName()+" WHERE "+c.quotedIdColumn()+"=$1 RETURNING *", id)
+}
+
 // DeleteByIds deletes multiple data items by their unique ids.
 //	Parameters:
 //		- ctx context.Context
@@ -364,29 +970,61 @@ func (c *IdentifiablePostgresPersistence[T, K]) DeleteById(ctx context.Context,
 //		- ids                of data items to be deleted.
 //	Returns: (optional)  error or null for success.
 func (c *IdentifiablePostgresPersistence[T, K]) DeleteByIds(ctx context.Context, correlationId string, ids []K) error {
-
-	ln := len(ids)
-	paramsStr := c.GenerateParameters(ln)
-
-	query := "DELETE FROM " + c.QuotedTableName() + " WHERE \"id\" IN(" + paramsStr + ")"
-
-	rows, err := c.Client.Query(ctx, query, ItemsToAnySlice[K](ids)...)
+	for _, id := range ids {
+		if err := c.beforeDelete(ctx, correlationId, id); err != nil {
+			return err
+		}
+	}
+	items, err := withRetries(ctx, c.PostgresPersistence, correlationId, "DeleteByIds", func() ([]T, error) {
+		return c.deleteByIds(ctx, correlationId, ids)
+	})
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
+	for _, item := range items {
+		if err := c.afterDelete(ctx, correlationId, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	if !rows.Next() {
-		return rows.Err()
+// deleteByIds is a single attempt of DeleteByIds.
+// Deleted items are returned only when interceptors are added.
+func (c *IdentifiablePostgresPersistence[T, K]) deleteByIds(ctx context.Context, correlationId string, ids []K) ([]T, error) {
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	defer func() {
+		for _, id := range ids {
+			c.forgetIdentity(ctx, id)
+		}
+	}()
+	// Ids are passed in a single array parameter, so their number isn't limited by the number of bound parameters
+	query, params, err := c.applyTenantCondition(ctx, correlationId,
+		"DELETE FROM "+c.QuotedTableName()+" WHERE "+c.quotedIdColumn()+"=ANY($1)", []any{ids})
+	if err != nil {
+		return nil, err
 	}
 
-	var count int64 = 0
-	_values, err := rows.Values()
-	if err == nil && len(_values) == 1 {
-		count = cconv.LongConverter.ToLong(_values[0])
-		if count != 0 {
-			c.Logger.Trace(ctx, correlationId, "Deleted %d items from %s", count, c.TableName)
+	// Deleted items are passed to AfterDelete of interceptors
+	if len(c.interceptors) > 0 {
+		items, err := c.queryItems(ctx, correlationId, query+" RETURNING *", params...)
+		if err != nil {
+			return nil, err
 		}
+		c.Logger.Trace(ctx, correlationId, "Deleted %d items from %s", len(items), c.TableName)
+		return items, nil
+	}
+
+	tag, err := c.exec(ctx, correlationId, query, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	if count := tag.RowsAffected(); count != 0 {
+		c.Logger.Trace(ctx, correlationId, "Deleted %d items from %s", count, c.TableName)
 	}
-	return rows.Err()
+	return nil, nil
 }
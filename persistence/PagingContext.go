@@ -0,0 +1,33 @@
+package persistence
+
+import (
+	"context"
+)
+
+type maxPageSizeContextKey struct{}
+
+// NewContextWithMaxPageSize creates a child context which overrides the maximum page size
+// of GetPageByFilter calls. It allows callers to explicitly retrieve pages larger than
+// the configured options.max_page_size, e.g. for data exports.
+//	Parameters:
+//		- ctx context.Context
+//		- maxPageSize a maximum number of items in a page. Values less than 1 are ignored.
+//	Returns: a new context.Context
+func NewContextWithMaxPageSize(ctx context.Context, maxPageSize int64) context.Context {
+	return context.WithValue(ctx, maxPageSizeContextKey{}, maxPageSize)
+}
+
+// MaxPageSizeFromContext retrieves a maximum page size previously bound to the context.
+//	Parameters:
+//		- ctx context.Context
+//	Returns: the maximum page size and true if it was found or 0 and false otherwise.
+func MaxPageSizeFromContext(ctx context.Context) (int64, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	maxPageSize, ok := ctx.Value(maxPageSizeContextKey{}).(int64)
+	if !ok || maxPageSize < 1 {
+		return 0, false
+	}
+	return maxPageSize, true
+}
@@ -0,0 +1,85 @@
+package persistence
+
+import "strings"
+
+// PostgresAggregate defines an aggregate function calculated over groups of data items.
+type PostgresAggregate struct {
+	// Aggregate function: COUNT, SUM, AVG, MIN or MAX.
+	Function string
+	// Field to aggregate. It is ignored by COUNT.
+	Field string
+	// Name of the result column. By default it is composed from the function and the field names.
+	Alias string
+}
+
+// AggregateCount creates an aggregate that counts items in a group.
+//
+//	Parameters:
+//		- alias (optional) name of the result column (default: count).
+//	Returns: PostgresAggregate
+func AggregateCount(alias string) PostgresAggregate {
+	return PostgresAggregate{Function: "COUNT", Alias: alias}
+}
+
+// AggregateSum creates an aggregate that sums values of the field in a group.
+//
+//	Parameters:
+//		- field a field to sum.
+//		- alias (optional) name of the result column (default: sum_<field>).
+//	Returns: PostgresAggregate
+func AggregateSum(field string, alias string) PostgresAggregate {
+	return PostgresAggregate{Function: "SUM", Field: field, Alias: alias}
+}
+
+// AggregateAvg creates an aggregate that calculates an average value of the field in a group.
+//
+//	Parameters:
+//		- field a field to average.
+//		- alias (optional) name of the result column (default: avg_<field>).
+//	Returns: PostgresAggregate
+func AggregateAvg(field string, alias string) PostgresAggregate {
+	return PostgresAggregate{Function: "AVG", Field: field, Alias: alias}
+}
+
+// AggregateMin creates an aggregate that finds a minimum value of the field in a group.
+//
+//	Parameters:
+//		- field a field to check.
+//		- alias (optional) name of the result column (default: min_<field>).
+//	Returns: PostgresAggregate
+func AggregateMin(field string, alias string) PostgresAggregate {
+	return PostgresAggregate{Function: "MIN", Field: field, Alias: alias}
+}
+
+// AggregateMax creates an aggregate that finds a maximum value of the field in a group.
+//
+//	Parameters:
+//		- field a field to check.
+//		- alias (optional) name of the result column (default: max_<field>).
+//	Returns: PostgresAggregate
+func AggregateMax(field string, alias string) PostgresAggregate {
+	return PostgresAggregate{Function: "MAX", Field: field, Alias: alias}
+}
+
+// isValid checks if the aggregate function is supported.
+func (c PostgresAggregate) isValid() bool {
+	switch strings.ToUpper(c.Function) {
+	case "COUNT":
+		return true
+	case "SUM", "AVG", "MIN", "MAX":
+		return c.Field != ""
+	}
+	return false
+}
+
+// alias returns the name of the result column.
+func (c PostgresAggregate) alias() string {
+	if c.Alias != "" {
+		return c.Alias
+	}
+	function := strings.ToLower(c.Function)
+	if function == "count" {
+		return function
+	}
+	return function + "_" + strings.ReplaceAll(c.Field, ".", "_")
+}
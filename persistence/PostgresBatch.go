@@ -0,0 +1,54 @@
+package persistence
+
+import (
+	"github.com/jackc/pgx/v5"
+)
+
+// PostgresBatchResult is a result of a single statement executed in a batch.
+type PostgresBatchResult[T any] struct {
+	// Data item returned by the statement, if any.
+	Item T
+	// True if the statement returned a data item.
+	Found bool
+	// Number of rows affected by the statement.
+	RowsAffected int64
+	// Error of the statement or nil if it succeeded.
+	Err error
+}
+
+// PostgresBatch queues multiple statements to send them to the database in a single round trip.
+// Batches are created by NewBatch method of a persistence and executed by its ExecuteBatch method.
+//
+// All statements in a batch run in one implicit transaction unless the context carries
+// an explicit one: when a statement fails the following statements fail as well and
+// changes of the previous statements are rolled back. Run-time settings bound to the context
+// with NewContextWithSessionSettings are set for the time of that transaction.
+//
+//	Example:
+//		batch := persistence.NewBatch()
+//		for _, item := range items {
+//			if err := persistence.QueueCreate(batch, item); err != nil {
+//				return err
+//			}
+//		}
+//		results, err := persistence.ExecuteBatch(ctx, correlationId, batch)
+type PostgresBatch[T any] struct {
+	batch pgx.Batch
+}
+
+// Queue adds an arbitrary parameterized statement to the batch.
+// Rows returned by the statement are converted into data items.
+//
+//	Parameters:
+//		- query an SQL statement with parameter placeholders ($1, $2, ...)
+//		- args (optional) values of the statement parameters
+func (c *PostgresBatch[T]) Queue(query string, args ...any) {
+	c.batch.Queue(query, args...)
+}
+
+// Len gets a number of queued statements.
+//
+//	Returns: number of queued statements.
+func (c *PostgresBatch[T]) Len() int {
+	return c.batch.Len()
+}
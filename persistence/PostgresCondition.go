@@ -0,0 +1,257 @@
+package persistence
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PostgresCondition is a composable condition of a WHERE clause.
+// Values are never concatenated into SQL, they are passed as bound parameters
+// numbered when the condition is built.
+//
+//	Example:
+//		where, params := And(
+//			Eq(Column("key"), "Key 1"),
+//			Or(
+//				Like(JsonField("data", "content"), "%test%"),
+//				Between(Column("created"), from, to),
+//			),
+//		).Build()
+//		page, err := c.GetPageByFilterWithParams(ctx, correlationId, where, params, paging, "", "")
+type PostgresCondition struct {
+	operator   string
+	expr       string
+	values     []any
+	conditions []PostgresCondition
+}
+
+// Eq creates a condition where the expression is equal to the value.
+//
+//	Parameters:
+//		- expr a column or an expression created with Column or JsonField.
+//		- value a value to compare with.
+//	Returns: PostgresCondition
+func Eq(expr string, value any) PostgresCondition {
+	return compare(expr, "=", value)
+}
+
+// NotEq creates a condition where the expression is not equal to the value.
+//
+//	Parameters:
+//		- expr a column or an expression created with Column or JsonField.
+//		- value a value to compare with.
+//	Returns: PostgresCondition
+func NotEq(expr string, value any) PostgresCondition {
+	return compare(expr, "<>", value)
+}
+
+// Lt creates a condition where the expression is less than the value.
+//
+//	Parameters:
+//		- expr a column or an expression created with Column or JsonField.
+//		- value a value to compare with.
+//	Returns: PostgresCondition
+func Lt(expr string, value any) PostgresCondition {
+	return compare(expr, "<", value)
+}
+
+// Lte creates a condition where the expression is less than or equal to the value.
+//
+//	Parameters:
+//		- expr a column or an expression created with Column or JsonField.
+//		- value a value to compare with.
+//	Returns: PostgresCondition
+func Lte(expr string, value any) PostgresCondition {
+	return compare(expr, "<=", value)
+}
+
+// Gt creates a condition where the expression is greater than the value.
+//
+//	Parameters:
+//		- expr a column or an expression created with Column or JsonField.
+//		- value a value to compare with.
+//	Returns: PostgresCondition
+func Gt(expr string, value any) PostgresCondition {
+	return compare(expr, ">", value)
+}
+
+// Gte creates a condition where the expression is greater than or equal to the value.
+//
+//	Parameters:
+//		- expr a column or an expression created with Column or JsonField.
+//		- value a value to compare with.
+//	Returns: PostgresCondition
+func Gte(expr string, value any) PostgresCondition {
+	return compare(expr, ">=", value)
+}
+
+// In creates a condition where the expression is equal to one of the values.
+//
+//	Parameters:
+//		- expr a column or an expression created with Column or JsonField.
+//		- values a slice of values, e.g. []string or []int64.
+//	Returns: PostgresCondition
+func In(expr string, values any) PostgresCondition {
+	return PostgresCondition{operator: "IN", expr: expr, values: []any{values}}
+}
+
+// Like creates a condition where the expression matches the LIKE pattern.
+//
+//	Parameters:
+//		- expr a column or an expression created with Column or JsonField.
+//		- pattern a pattern with % and _ wildcards.
+//	Returns: PostgresCondition
+func Like(expr string, pattern string) PostgresCondition {
+	return compare(expr, " LIKE ", pattern)
+}
+
+// ILike creates a condition where the expression matches the LIKE pattern case insensitive.
+//
+//	Parameters:
+//		- expr a column or an expression created with Column or JsonField.
+//		- pattern a pattern with % and _ wildcards.
+//	Returns: PostgresCondition
+func ILike(expr string, pattern string) PostgresCondition {
+	return compare(expr, " ILIKE ", pattern)
+}
+
+// Between creates a condition where the expression is within the range including its bounds.
+//
+//	Parameters:
+//		- expr a column or an expression created with Column or JsonField.
+//		- from a lower bound of the range.
+//		- to an upper bound of the range.
+//	Returns: PostgresCondition
+func Between(expr string, from any, to any) PostgresCondition {
+	return PostgresCondition{operator: "BETWEEN", expr: expr, values: []any{from, to}}
+}
+
+// IsNull creates a condition where the expression is NULL.
+//
+//	Parameters:
+//		- expr a column or an expression created with Column or JsonField.
+//	Returns: PostgresCondition
+func IsNull(expr string) PostgresCondition {
+	return PostgresCondition{operator: " IS NULL", expr: expr}
+}
+
+// IsNotNull creates a condition where the expression is not NULL.
+//
+//	Parameters:
+//		- expr a column or an expression created with Column or JsonField.
+//	Returns: PostgresCondition
+func IsNotNull(expr string) PostgresCondition {
+	return PostgresCondition{operator: " IS NOT NULL", expr: expr}
+}
+
+// And creates a condition which is true when all the conditions are true.
+// Empty conditions are skipped.
+//
+//	Parameters:
+//		- conditions conditions to combine.
+//	Returns: PostgresCondition
+func And(conditions ...PostgresCondition) PostgresCondition {
+	return PostgresCondition{operator: "AND", conditions: conditions}
+}
+
+// Or creates a condition which is true when any of the conditions is true.
+// Empty conditions are skipped.
+//
+//	Parameters:
+//		- conditions conditions to combine.
+//	Returns: PostgresCondition
+func Or(conditions ...PostgresCondition) PostgresCondition {
+	return PostgresCondition{operator: "OR", conditions: conditions}
+}
+
+// Not creates a condition which negates the given condition.
+//
+//	Parameters:
+//		- condition a condition to negate.
+//	Returns: PostgresCondition
+func Not(condition PostgresCondition) PostgresCondition {
+	return PostgresCondition{operator: "NOT", conditions: []PostgresCondition{condition}}
+}
+
+// IsEmpty checks if the condition doesn't restrict anything.
+//
+//	Returns: true if the condition is empty.
+func (c PostgresCondition) IsEmpty() bool {
+	switch c.operator {
+	case "":
+		return true
+	case "AND", "OR", "NOT":
+		for _, condition := range c.conditions {
+			if !condition.IsEmpty() {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Build translates the condition into a WHERE clause and a list of bound parameters.
+// Parameters are numbered starting from $1.
+//
+//	Returns: a WHERE clause without the WHERE keyword and a list of parameter values.
+func (c PostgresCondition) Build() (string, []any) {
+	return c.BuildFrom(1)
+}
+
+// BuildFrom translates the condition into a WHERE clause and a list of bound parameters.
+// Parameters are numbered starting from the given index to combine the clause with other parameterized statements.
+//
+//	Parameters:
+//		- startIndex a number of the first parameter.
+//	Returns: a WHERE clause without the WHERE keyword and a list of parameter values.
+func (c PostgresCondition) BuildFrom(startIndex int) (string, []any) {
+	params := make([]any, 0)
+	clause := c.build(startIndex, &params)
+	return clause, params
+}
+
+func (c PostgresCondition) build(startIndex int, params *[]any) string {
+	placeholder := func(value any) string {
+		*params = append(*params, value)
+		return "$" + strconv.Itoa(startIndex+len(*params)-1)
+	}
+
+	switch c.operator {
+	case "":
+		return ""
+	case "AND", "OR":
+		clauses := make([]string, 0, len(c.conditions))
+		for _, condition := range c.conditions {
+			if clause := condition.build(startIndex, params); clause != "" {
+				clauses = append(clauses, clause)
+			}
+		}
+		if len(clauses) == 0 {
+			return ""
+		}
+		if len(clauses) == 1 {
+			return clauses[0]
+		}
+		return "(" + strings.Join(clauses, " "+c.operator+" ") + ")"
+	case "NOT":
+		clause := c.conditions[0].build(startIndex, params)
+		if clause == "" {
+			return ""
+		}
+		return "NOT (" + clause + ")"
+	case "IN":
+		return c.expr + "=ANY(" + placeholder(c.values[0]) + ")"
+	case "BETWEEN":
+		return c.expr + " BETWEEN " + placeholder(c.values[0]) + " AND " + placeholder(c.values[1])
+	case " IS NULL", " IS NOT NULL":
+		return c.expr + c.operator
+	default:
+		return c.expr + c.operator + placeholder(c.values[0])
+	}
+}
+
+// compare creates a condition comparing the expression with the value.
+func compare(expr string, operator string, value any) PostgresCondition {
+	return PostgresCondition{operator: operator, expr: expr, values: []any{value}}
+}
@@ -0,0 +1,101 @@
+package persistence
+
+import (
+	"strings"
+)
+
+// Actions taken by Set when the item conflicts with an existing row.
+const (
+	// ConflictActionUpdate updates the existing row with values of the item.
+	ConflictActionUpdate = "update"
+	// ConflictActionNothing keeps the existing row unchanged. Set returns an empty item in this case.
+	ConflictActionNothing = "nothing"
+)
+
+// splitConflictList splits a comma-separated list of columns and expressions in parentheses,
+// e.g. key, (lower(name)), keeping commas inside of parentheses.
+func splitConflictList(list string) []string {
+	items := make([]string, 0)
+	depth, start := 0, 0
+	for index, r := range list {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				if item := strings.TrimSpace(list[start:index]); item != "" {
+					items = append(items, item)
+				}
+				start = index + 1
+			}
+		}
+	}
+	if item := strings.TrimSpace(list[start:]); item != "" {
+		items = append(items, item)
+	}
+	return items
+}
+
+// quoteConflictItem quotes a column of the conflict target. Expressions in parentheses are kept as is.
+func (c *PostgresPersistence[T]) quoteConflictItem(item string) string {
+	if strings.HasPrefix(item, "(") {
+		return item
+	}
+	return c.quoteColumn(item)
+}
+
+// composeConflictClause returns the ON CONFLICT clause of upsert statements inserting the columns,
+// configured by options.conflict_target, options.conflict_action and options.conflict_update_columns.
+// By default conflicts on the id update all inserted columns. When the target is another unique key
+// the id and the target columns are not updated, so existing rows keep their ids.
+func (c *PostgresPersistence[T]) composeConflictClause(columns []string) string {
+	target := c.conflictTarget
+	if len(target) == 0 {
+		target = []string{c.idColumn}
+	}
+	quotedTarget := make([]string, 0, len(target))
+	for _, item := range target {
+		quotedTarget = append(quotedTarget, c.quoteConflictItem(item))
+	}
+	clause := " ON CONFLICT (" + strings.Join(quotedTarget, ",") + ")"
+
+	if c.conflictAction == ConflictActionNothing {
+		return clause + " DO NOTHING"
+	}
+
+	excluded := make(map[string]bool)
+	if len(c.conflictTarget) > 0 {
+		excluded[c.NamingStrategy.ColumnName(c.idColumn)] = true
+		for _, item := range c.conflictTarget {
+			excluded[c.NamingStrategy.ColumnName(item)] = true
+		}
+	}
+	included := make(map[string]bool)
+	for _, column := range c.conflictUpdate {
+		included[c.NamingStrategy.ColumnName(column)] = true
+	}
+
+	assignments := make([]string, 0, len(columns))
+	for _, column := range columns {
+		column = c.NamingStrategy.ColumnName(column)
+		if excluded[column] || (len(included) > 0 && !included[column]) {
+			continue
+		}
+		quoted := c.quoteColumn(column)
+		assignments = append(assignments, quoted+"=EXCLUDED."+quoted)
+	}
+	if len(assignments) == 0 {
+		// Keep the conflicting row unchanged, but still return it by RETURNING
+		id := c.quoteColumn(c.idColumn)
+		assignments = append(assignments, id+"="+c.QuoteIdentifier(c.TableName)+"."+id)
+	}
+	clause += " DO UPDATE SET " + strings.Join(assignments, ",")
+	if c.tenantColumn != "" {
+		// Rows of other tenants with the same key are never overwritten
+		tenant := c.quoteColumn(c.tenantColumn)
+		clause += " WHERE " + c.QuoteIdentifier(c.TableName) + "." + tenant + "=EXCLUDED." + tenant
+	}
+	return clause
+}
@@ -0,0 +1,37 @@
+package persistence
+
+import "strings"
+
+// extractedColumn is a column generated from a field of the JSON data.
+type extractedColumn struct {
+	column string
+	pgType string
+}
+
+// EnsureExtractedColumn adds an indexed column generated from a field of the JSON data, see EnsureGeneratedColumn,
+// and uses it instead of the JSON field in filters of JsonFilterBuilder and in ComposeSort and ComposeField,
+// so the field is filtered and sorted by the index while documents stay the source of truth.
+// The column is named after the field with dots replaced by underscores, e.g. address_city.
+//
+//	Example:
+//		c.EnsureExtractedColumn("key", "TEXT")
+//		c.EnsureExtractedColumn("stats.count", "INTEGER")
+//
+//	Parameters:
+//		- field a field name or a dot-separated path of a nested field.
+//		- pgType a PostgreSQL type of the column, e.g. TEXT or INTEGER (default: TEXT).
+func (c *IdentifiableJsonPostgresPersistence[T, K]) EnsureExtractedColumn(field string, pgType string) {
+	if pgType == "" {
+		pgType = "TEXT"
+	}
+	if !sortCastPattern.MatchString(pgType) {
+		return
+	}
+
+	column := strings.ReplaceAll(field, ".", "_")
+	c.EnsureGeneratedColumn(column, field, pgType, true)
+	if c.extractedColumns == nil {
+		c.extractedColumns = make(map[string]extractedColumn)
+	}
+	c.extractedColumns[field] = extractedColumn{column: column, pgType: pgType}
+}
@@ -0,0 +1,195 @@
+package persistence
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+)
+
+// Column returns a safely quoted column name to be used in filter conditions.
+//
+//	Parameters:
+//		- name a column name.
+//	Returns: a quoted column name, e.g. "name".
+func Column(name string) string {
+	return pgx.Identifier{name}.Sanitize()
+}
+
+// JsonField returns an expression that extracts a text value of the JSONB field, e.g. "data"->>'key'.
+// Nested fields can be separated by dots.
+//
+//	Parameters:
+//		- column a name of the JSON column.
+//		- field a field name or a dot-separated path.
+//	Returns: an SQL expression to extract the field as text.
+func JsonField(column string, field string) string {
+	path := strings.Split(field, ".")
+	expr := Column(column)
+	for index, key := range path {
+		if index == len(path)-1 {
+			expr += "->>"
+		} else {
+			expr += "->"
+		}
+		expr += QuoteLiteral(key)
+	}
+	return expr
+}
+
+// QuoteLiteral quotes a string literal to be safely used in SQL statements.
+//
+//	Parameters:
+//		- value a string value.
+//	Returns: a quoted string literal, e.g. 'value'.
+func QuoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+type filterCondition struct {
+	key      string
+	expr     string
+	operator string
+}
+
+// PostgresFilterBuilder translates FilterParams into a parameterized WHERE clause.
+// Only explicitly declared filter keys are translated. Values are never concatenated
+// into SQL, they are passed as bound parameters instead.
+//
+//	Example:
+//		builder := NewPostgresFilterBuilder().
+//			Equal("key", Column("key")).
+//			In("ids", Column("id")).
+//			Like("search", JsonField("data", "content"))
+//		where, params := builder.Build(filter)
+//		page, err := c.GetPageByFilterWithParams(ctx, correlationId, where, params, paging, "", "")
+type PostgresFilterBuilder struct {
+	conditions []filterCondition
+}
+
+// NewPostgresFilterBuilder creates a new instance of the filter builder.
+//
+//	Returns: *PostgresFilterBuilder
+func NewPostgresFilterBuilder() *PostgresFilterBuilder {
+	return &PostgresFilterBuilder{
+		conditions: make([]filterCondition, 0),
+	}
+}
+
+// Equal declares a filter key compared for equality with the expression.
+//
+//	Parameters:
+//		- key a filter key.
+//		- expr a column or an expression created with Column or JsonField.
+//	Returns: the builder to chain calls.
+func (c *PostgresFilterBuilder) Equal(key string, expr string) *PostgresFilterBuilder {
+	return c.Compare(key, expr, "=")
+}
+
+// NotEqual declares a filter key compared for inequality with the expression.
+//
+//	Parameters:
+//		- key a filter key.
+//		- expr a column or an expression created with Column or JsonField.
+//	Returns: the builder to chain calls.
+func (c *PostgresFilterBuilder) NotEqual(key string, expr string) *PostgresFilterBuilder {
+	return c.Compare(key, expr, "<>")
+}
+
+// Compare declares a filter key compared with the expression using the given operator.
+// Supported operators are =, <>, !=, <, <=, > and >=. Other operators are replaced with =.
+//
+//	Parameters:
+//		- key a filter key.
+//		- expr a column or an expression created with Column or JsonField.
+//		- operator a comparison operator.
+//	Returns: the builder to chain calls.
+func (c *PostgresFilterBuilder) Compare(key string, expr string, operator string) *PostgresFilterBuilder {
+	switch operator {
+	case "=", "<>", "<", "<=", ">", ">=":
+	case "!=":
+		operator = "<>"
+	default:
+		operator = "="
+	}
+	c.conditions = append(c.conditions, filterCondition{key: key, expr: expr, operator: operator})
+	return c
+}
+
+// In declares a filter key with a comma-separated list of values the expression must be equal to.
+//
+//	Parameters:
+//		- key a filter key.
+//		- expr a column or an expression created with Column or JsonField.
+//	Returns: the builder to chain calls.
+func (c *PostgresFilterBuilder) In(key string, expr string) *PostgresFilterBuilder {
+	c.conditions = append(c.conditions, filterCondition{key: key, expr: expr, operator: "IN"})
+	return c
+}
+
+// Like declares a filter key which value must be contained in the expression (case insensitive).
+//
+//	Parameters:
+//		- key a filter key.
+//		- expr a column or an expression created with Column or JsonField.
+//	Returns: the builder to chain calls.
+func (c *PostgresFilterBuilder) Like(key string, expr string) *PostgresFilterBuilder {
+	c.conditions = append(c.conditions, filterCondition{key: key, expr: expr, operator: "LIKE"})
+	return c
+}
+
+// Build translates the filter into a WHERE clause joined with AND and a list of bound parameters.
+// Parameters are numbered starting from $1.
+//
+//	Parameters:
+//		- filter filter parameters.
+//	Returns: a WHERE clause without the WHERE keyword and a list of parameter values.
+func (c *PostgresFilterBuilder) Build(filter cdata.FilterParams) (string, []any) {
+	return c.BuildFrom(filter, 1)
+}
+
+// BuildFrom translates the filter into a WHERE clause joined with AND and a list of bound parameters.
+// Parameters are numbered starting from the given index to combine the clause with other parameterized statements.
+//
+//	Parameters:
+//		- filter filter parameters.
+//		- startIndex a number of the first parameter.
+//	Returns: a WHERE clause without the WHERE keyword and a list of parameter values.
+func (c *PostgresFilterBuilder) BuildFrom(filter cdata.FilterParams, startIndex int) (string, []any) {
+	clauses := make([]string, 0, len(c.conditions))
+	params := make([]any, 0, len(c.conditions))
+
+	for _, condition := range c.conditions {
+		value, ok := filter.GetAsNullableString(condition.key)
+		if !ok || value == "" {
+			continue
+		}
+
+		placeholder := "$" + strconv.Itoa(startIndex+len(params))
+		switch condition.operator {
+		case "IN":
+			values := strings.Split(value, ",")
+			for index := range values {
+				values[index] = strings.TrimSpace(values[index])
+			}
+			clauses = append(clauses, condition.expr+"=ANY("+placeholder+")")
+			params = append(params, values)
+		case "LIKE":
+			clauses = append(clauses, condition.expr+" ILIKE "+placeholder)
+			params = append(params, "%"+escapeLikePattern(value)+"%")
+		default:
+			clauses = append(clauses, condition.expr+condition.operator+placeholder)
+			params = append(params, value)
+		}
+	}
+
+	return strings.Join(clauses, " AND "), params
+}
+
+// escapeLikePattern escapes wildcard characters of the LIKE pattern.
+func escapeLikePattern(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	value = strings.ReplaceAll(value, "%", "\\%")
+	return strings.ReplaceAll(value, "_", "\\_")
+}
@@ -0,0 +1,71 @@
+package persistence
+
+import (
+	"reflect"
+
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	cpersist "github.com/pip-services3-gox/pip-services3-data-gox/persistence"
+)
+
+// generatesIds checks if ids of created items without ids are generated by the database.
+// Integer ids are always generated by the database, except for JSON persistences which keep ids in the data.
+func (c *PostgresPersistence[T]) generatesIds() bool {
+	return c.idSequence != "" || c.serverIds || (c.integerIds && c.jsonColumn == "")
+}
+
+// isIntegerKind checks if the value of the type is an integer number.
+func isIntegerKind(valueType reflect.Type) bool {
+	if valueType == nil {
+		return false
+	}
+	switch valueType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// omitGeneratedId removes an empty id from the data item map when ids are generated by the database,
+// so the id column gets its default value.
+func (c *PostgresPersistence[T]) omitGeneratedId(objMap map[string]any) {
+	if !c.generatesIds() {
+		return
+	}
+	if id, ok := objMap[c.idColumn]; ok && (id == nil || reflect.ValueOf(id).IsZero()) {
+		delete(objMap, c.idColumn)
+	}
+}
+
+// objectMapId returns the id of the data item map converted by ConvertFromPublic.
+func (c *PostgresPersistence[T]) objectMapId(objMap map[string]any) any {
+	if id, ok := objMap[c.idColumn]; ok {
+		return id
+	}
+	return cpersist.GetObjectId(objMap)
+}
+
+// generateObjectMapId sets a new id to the data item map with an empty string id, like GenerateObjectMapIdIfNotExists.
+func (c *PostgresPersistence[T]) generateObjectMapId(objMap map[string]any) {
+	if id, ok := objMap[c.idColumn]; ok && id != nil {
+		if reflect.ValueOf(id).IsZero() && reflect.TypeOf(id).Kind() == reflect.String {
+			objMap[c.idColumn] = cdata.IdGenerator.NextLong()
+		}
+	}
+}
+
+// composeIdGeneration returns a clause of the id column definition of the given type
+// which generates ids in the database, or an empty string when ids are generated by clients.
+func (c *PostgresPersistence[T]) composeIdGeneration(pgType string) string {
+	switch {
+	case c.idSequence != "":
+		return " DEFAULT " + c.NextValExpression(c.idSequence)
+	case c.serverIds && normalizeType(pgType) == "uuid":
+		return " DEFAULT gen_random_uuid()"
+	case c.serverIds:
+		return " DEFAULT gen_random_uuid()::text"
+	case c.integerIds && c.jsonColumn == "":
+		return " GENERATED BY DEFAULT AS IDENTITY"
+	}
+	return ""
+}
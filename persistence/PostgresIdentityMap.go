@@ -0,0 +1,151 @@
+package persistence
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+type identityMapContextKey struct{}
+
+// IdentityMap keeps items read by GetOneById during a request, so repeated reads
+// of the same items are served from memory instead of the database.
+// Items are removed when they are changed through the persistence with the same context.
+// Changes made by other requests are not seen until the map is cleared.
+type IdentityMap struct {
+	lock  sync.Mutex
+	items map[identityKey]any
+}
+
+// identityKey identifies an item in the identity map by its table, tenant and id.
+type identityKey struct {
+	table  string
+	tenant string
+	id     any
+}
+
+// NewContextWithIdentityMap creates a child context with a new empty identity map.
+// GetOneById of identifiable persistences called with the context, or contexts derived from it,
+// returns items already read within the request without querying the database.
+// Returned items are shared, so items of pointer or map types must not be modified.
+//
+//	Example:
+//		ctx = persist.NewContextWithIdentityMap(ctx)
+//		item, err := persistence.GetOneById(ctx, correlationId, id)
+//		item, err = persistence.GetOneById(ctx, correlationId, id) // served from memory
+//
+//	Parameters:
+//		- ctx context.Context
+//	Returns: a new context.Context
+func NewContextWithIdentityMap(ctx context.Context) context.Context {
+	return context.WithValue(ctx, identityMapContextKey{}, &IdentityMap{items: make(map[identityKey]any)})
+}
+
+// IdentityMapFromContext retrieves an identity map previously bound to the context.
+//
+//	Parameters:
+//		- ctx context.Context
+//	Returns: the identity map or nil if the context has none.
+func IdentityMapFromContext(ctx context.Context) *IdentityMap {
+	if ctx == nil {
+		return nil
+	}
+	identityMap, _ := ctx.Value(identityMapContextKey{}).(*IdentityMap)
+	return identityMap
+}
+
+// Clear removes all items from the identity map, e.g. after changes made by custom SQL
+// or when the request must see changes made by others.
+func (m *IdentityMap) Clear() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.items = make(map[identityKey]any)
+}
+
+// Len returns the number of items kept in the identity map.
+func (m *IdentityMap) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.items)
+}
+
+func (m *IdentityMap) get(key identityKey) (any, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	item, ok := m.items[key]
+	return item, ok
+}
+
+func (m *IdentityMap) put(key identityKey, item any) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.items[key] = item
+}
+
+func (m *IdentityMap) remove(key identityKey) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.items, key)
+}
+
+// removeTable removes all items of the table.
+func (m *IdentityMap) removeTable(table string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key := range m.items {
+		if key.table == table {
+			delete(m.items, key)
+		}
+	}
+}
+
+// identityKey returns the key of the item with the id in the identity map of the context.
+// It returns false when the context has no identity map or the id can't be used as a key.
+func (c *PostgresPersistence[T]) identityKey(ctx context.Context, id any) (*IdentityMap, identityKey, bool) {
+	identityMap := IdentityMapFromContext(ctx)
+	if identityMap == nil || id == nil || !reflect.TypeOf(id).Comparable() {
+		return nil, identityKey{}, false
+	}
+	// Items of different tenants are kept apart, as the same context may be used with another tenant
+	tenant := ""
+	if c.tenantColumn != "" {
+		tenant, _ = TenantFromContext(ctx)
+	}
+	return identityMap, identityKey{table: c.QuotedTableName(), tenant: tenant, id: id}, true
+}
+
+// rememberIdentity keeps the item read by its id in the identity map of the context.
+func (c *PostgresPersistence[T]) rememberIdentity(ctx context.Context, id any, item T) {
+	if identityMap, key, ok := c.identityKey(ctx, id); ok {
+		identityMap.put(key, item)
+	}
+}
+
+// recallIdentity returns the item with the id from the identity map of the context.
+func (c *PostgresPersistence[T]) recallIdentity(ctx context.Context, id any) (item T, ok bool) {
+	identityMap, key, ok := c.identityKey(ctx, id)
+	if !ok {
+		return item, false
+	}
+	value, ok := identityMap.get(key)
+	if !ok {
+		return item, false
+	}
+	item, ok = value.(T)
+	return item, ok
+}
+
+// forgetIdentity removes the item with the id from the identity map of the context.
+func (c *PostgresPersistence[T]) forgetIdentity(ctx context.Context, id any) {
+	if identityMap, key, ok := c.identityKey(ctx, id); ok {
+		identityMap.remove(key)
+	}
+}
+
+// forgetTable removes all items of the table from the identity map of the context,
+// after statements which may change any number of rows.
+func (c *PostgresPersistence[T]) forgetTable(ctx context.Context) {
+	if identityMap := IdentityMapFromContext(ctx); identityMap != nil {
+		identityMap.removeTable(c.QuotedTableName())
+	}
+}
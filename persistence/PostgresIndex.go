@@ -0,0 +1,46 @@
+package persistence
+
+import (
+	"regexp"
+	"strings"
+)
+
+var indexIdentifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// PostgresIndexKey is a key of the index: a column or an expression.
+type PostgresIndexKey struct {
+	// Column name or an expression in parentheses, e.g. (data->>'key') or (lower(name)).
+	Name string
+	// True to sort the key in descending order.
+	Descending bool
+}
+
+// IndexKey creates an ascending index key.
+//
+//	Parameters:
+//		- name a column name or an expression in parentheses.
+//	Returns: PostgresIndexKey
+func IndexKey(name string) PostgresIndexKey {
+	return PostgresIndexKey{Name: name}
+}
+
+// IndexKeyDesc creates a descending index key.
+//
+//	Parameters:
+//		- name a column name or an expression in parentheses.
+//	Returns: PostgresIndexKey
+func IndexKeyDesc(name string) PostgresIndexKey {
+	return PostgresIndexKey{Name: name, Descending: true}
+}
+
+// composeIndexMethod validates the index access method.
+// Values in the legacy "USING <method>" form are accepted as well.
+func composeIndexMethod(method string) string {
+	method = strings.ToLower(strings.TrimSpace(method))
+	method = strings.TrimSpace(strings.TrimPrefix(method, "using "))
+	switch method {
+	case "btree", "hash", "gist", "spgist", "gin", "brin":
+		return method
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package persistence
+
+import (
+	"context"
+	"reflect"
+
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+)
+
+// IPostgresInterceptor intercepts CRUD operations of persistences to change items,
+// veto operations or emit domain events, e.g. for audit logs or messaging integrations.
+// Before hooks are called once per operation, not per retry, and an error returned by them
+// cancels the operation. After hooks are called only for changed items and their errors
+// are returned to the caller, while the change is already made unless it runs in a transaction.
+// Embed PostgresInterceptor to implement only the needed hooks.
+type IPostgresInterceptor[T any] interface {
+	// BeforeCreate is called before the item is created by Create or CreateMany.
+	// It returns the item to be created.
+	BeforeCreate(ctx context.Context, correlationId string, item T) (T, error)
+	// AfterCreate is called with the created item.
+	AfterCreate(ctx context.Context, correlationId string, item T) error
+	// BeforeUpdate is called before the item is updated by Update, Set or SetMany.
+	// It returns the item to be saved.
+	BeforeUpdate(ctx context.Context, correlationId string, item T) (T, error)
+	// BeforeUpdatePartially is called before fields of the item with the id are updated by UpdatePartially.
+	// It returns the fields to be updated.
+	BeforeUpdatePartially(ctx context.Context, correlationId string, id any, data cdata.AnyValueMap) (cdata.AnyValueMap, error)
+	// AfterUpdate is called with the updated item after Update, Set, SetMany and UpdatePartially.
+	AfterUpdate(ctx context.Context, correlationId string, item T) error
+	// BeforeDelete is called before the item with the id is deleted by DeleteById or DeleteByIds.
+	BeforeDelete(ctx context.Context, correlationId string, id any) error
+	// AfterDelete is called with the deleted item.
+	AfterDelete(ctx context.Context, correlationId string, item T) error
+}
+
+// PostgresInterceptor is an interceptor which doesn't change operations.
+// Embed it into interceptors to override only the needed hooks.
+//
+//	Example:
+//		type AuditInterceptor struct {
+//			persist.PostgresInterceptor[MyData]
+//		}
+//
+//		func (c *AuditInterceptor) AfterDelete(ctx context.Context, correlationId string, item MyData) error {
+//			return c.events.Publish(ctx, correlationId, "deleted", item.Id)
+//		}
+//
+//		persistence.AddInterceptor(&AuditInterceptor{})
+type PostgresInterceptor[T any] struct{}
+
+// BeforeCreate returns the item without changes.
+func (c *PostgresInterceptor[T]) BeforeCreate(ctx context.Context, correlationId string, item T) (T, error) {
+	return item, nil
+}
+
+// AfterCreate does nothing.
+func (c *PostgresInterceptor[T]) AfterCreate(ctx context.Context, correlationId string, item T) error {
+	return nil
+}
+
+// BeforeUpdate returns the item without changes.
+func (c *PostgresInterceptor[T]) BeforeUpdate(ctx context.Context, correlationId string, item T) (T, error) {
+	return item, nil
+}
+
+// BeforeUpdatePartially returns the fields without changes.
+func (c *PostgresInterceptor[T]) BeforeUpdatePartially(ctx context.Context, correlationId string,
+	id any, data cdata.AnyValueMap) (cdata.AnyValueMap, error) {
+	return data, nil
+}
+
+// AfterUpdate does nothing.
+func (c *PostgresInterceptor[T]) AfterUpdate(ctx context.Context, correlationId string, item T) error {
+	return nil
+}
+
+// BeforeDelete does nothing.
+func (c *PostgresInterceptor[T]) BeforeDelete(ctx context.Context, correlationId string, id any) error {
+	return nil
+}
+
+// AfterDelete does nothing.
+func (c *PostgresInterceptor[T]) AfterDelete(ctx context.Context, correlationId string, item T) error {
+	return nil
+}
+
+// AddInterceptor adds an interceptor of CRUD operations. Interceptors are called in the order they were added.
+// Operations by filter and custom SQL are not intercepted.
+//
+//	Parameters:
+//		- interceptor an interceptor to be added.
+func (c *PostgresPersistence[T]) AddInterceptor(interceptor IPostgresInterceptor[T]) {
+	if interceptor != nil {
+		c.interceptors = append(c.interceptors, interceptor)
+	}
+}
+
+// ClearInterceptors removes all added interceptors.
+func (c *PostgresPersistence[T]) ClearInterceptors() {
+	c.interceptors = nil
+}
+
+// isEmptyItem checks if the item is a zero value, returned by operations which didn't find the item.
+func isEmptyItem[T any](item T) bool {
+	return reflect.ValueOf(&item).Elem().IsZero()
+}
+
+func (c *PostgresPersistence[T]) beforeCreate(ctx context.Context, correlationId string, item T) (T, error) {
+	var err error
+	for _, interceptor := range c.interceptors {
+		if item, err = interceptor.BeforeCreate(ctx, correlationId, item); err != nil {
+			return item, err
+		}
+	}
+	return item, nil
+}
+
+func (c *PostgresPersistence[T]) afterCreate(ctx context.Context, correlationId string, item T) error {
+	for _, interceptor := range c.interceptors {
+		if err := interceptor.AfterCreate(ctx, correlationId, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *PostgresPersistence[T]) beforeUpdate(ctx context.Context, correlationId string, item T) (T, error) {
+	var err error
+	for _, interceptor := range c.interceptors {
+		if item, err = interceptor.BeforeUpdate(ctx, correlationId, item); err != nil {
+			return item, err
+		}
+	}
+	return item, nil
+}
+
+func (c *PostgresPersistence[T]) beforeUpdatePartially(ctx context.Context, correlationId string,
+	id any, data cdata.AnyValueMap) (cdata.AnyValueMap, error) {
+
+	var err error
+	for _, interceptor := range c.interceptors {
+		if data, err = interceptor.BeforeUpdatePartially(ctx, correlationId, id, data); err != nil {
+			return data, err
+		}
+	}
+	return data, nil
+}
+
+func (c *PostgresPersistence[T]) afterUpdate(ctx context.Context, correlationId string, item T) error {
+	if isEmptyItem(item) {
+		return nil
+	}
+	for _, interceptor := range c.interceptors {
+		if err := interceptor.AfterUpdate(ctx, correlationId, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *PostgresPersistence[T]) beforeDelete(ctx context.Context, correlationId string, id any) error {
+	for _, interceptor := range c.interceptors {
+		if err := interceptor.BeforeDelete(ctx, correlationId, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *PostgresPersistence[T]) afterDelete(ctx context.Context, correlationId string, item T) error {
+	if isEmptyItem(item) {
+		return nil
+	}
+	for _, interceptor := range c.interceptors {
+		if err := interceptor.AfterDelete(ctx, correlationId, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package persistence
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PostgresJoin defines a related table joined to the persistence table in read queries.
+// Joins are defined by child persistences with DefineJoin method and used by
+// GetPageByFilter, GetListByFilter, GetCountByFilter, ExistsByFilter and GetOneRandom methods.
+//
+// The ON clause is inserted into SQL as is, so it must never contain user input.
+// Columns of the persistence table in filters and sorts shall be qualified
+// with the table name when joined tables have columns with the same names.
+//
+//	Example:
+//		c.DefineJoin(persist.LeftJoin("customers", "c", "c.\"id\"=\"orders\".\"customer_id\"").
+//			Select("name").
+//			SelectAs("email", "customer_email"))
+type PostgresJoin struct {
+	// Join type: INNER, LEFT, RIGHT or FULL.
+	Type string
+	// Name of the joined table. It can be qualified with a schema, e.g. "schema.table".
+	Table string
+	// Alias of the joined table used in the ON clause and selected columns.
+	Alias string
+	// Join condition.
+	On string
+	// Selected columns of the joined table mapped to names of the result columns.
+	Columns map[string]string
+}
+
+// InnerJoin defines a related table joined with INNER JOIN.
+//
+//	Parameters:
+//		- table a name of the joined table.
+//		- alias (optional) an alias of the joined table.
+//		- on a join condition.
+//	Returns: PostgresJoin
+func InnerJoin(table string, alias string, on string) PostgresJoin {
+	return PostgresJoin{Type: "INNER", Table: table, Alias: alias, On: on}
+}
+
+// LeftJoin defines a related table joined with LEFT JOIN.
+//
+//	Parameters:
+//		- table a name of the joined table.
+//		- alias (optional) an alias of the joined table.
+//		- on a join condition.
+//	Returns: PostgresJoin
+func LeftJoin(table string, alias string, on string) PostgresJoin {
+	return PostgresJoin{Type: "LEFT", Table: table, Alias: alias, On: on}
+}
+
+// RightJoin defines a related table joined with RIGHT JOIN.
+//
+//	Parameters:
+//		- table a name of the joined table.
+//		- alias (optional) an alias of the joined table.
+//		- on a join condition.
+//	Returns: PostgresJoin
+func RightJoin(table string, alias string, on string) PostgresJoin {
+	return PostgresJoin{Type: "RIGHT", Table: table, Alias: alias, On: on}
+}
+
+// FullJoin defines a related table joined with FULL JOIN.
+//
+//	Parameters:
+//		- table a name of the joined table.
+//		- alias (optional) an alias of the joined table.
+//		- on a join condition.
+//	Returns: PostgresJoin
+func FullJoin(table string, alias string, on string) PostgresJoin {
+	return PostgresJoin{Type: "FULL", Table: table, Alias: alias, On: on}
+}
+
+// Select adds columns of the joined table to the result under their own names.
+//
+//	Parameters:
+//		- columns names of the columns.
+//	Returns: the join with added columns.
+func (c PostgresJoin) Select(columns ...string) PostgresJoin {
+	for _, column := range columns {
+		c = c.SelectAs(column, column)
+	}
+	return c
+}
+
+// SelectAs adds a column of the joined table to the result under the given name.
+//
+//	Parameters:
+//		- column a name of the column.
+//		- name a name of the result column.
+//	Returns: the join with the added column.
+func (c PostgresJoin) SelectAs(column string, name string) PostgresJoin {
+	columns := make(map[string]string, len(c.Columns)+1)
+	for key, value := range c.Columns {
+		columns[key] = value
+	}
+	columns[column] = name
+	c.Columns = columns
+	return c
+}
+
+// isValid checks if the join type is supported and the join is complete.
+func (c PostgresJoin) isValid() bool {
+	switch strings.ToUpper(c.Type) {
+	case "INNER", "LEFT", "RIGHT", "FULL":
+		return c.Table != "" && c.On != ""
+	}
+	return false
+}
+
+// reference returns a quoted name to refer to the joined table.
+func (c PostgresJoin) reference() string {
+	if c.Alias != "" {
+		return Column(c.Alias)
+	}
+	return pgx.Identifier(strings.Split(c.Table, ".")).Sanitize()
+}
+
+// composeClause returns the JOIN clause.
+func (c PostgresJoin) composeClause() string {
+	clause := " " + strings.ToUpper(c.Type) + " JOIN " + pgx.Identifier(strings.Split(c.Table, ".")).Sanitize()
+	if c.Alias != "" {
+		clause += " AS " + Column(c.Alias)
+	}
+	return clause + " ON " + c.On
+}
+
+// composeColumns returns the list of selected columns in a stable order.
+func (c PostgresJoin) composeColumns() []string {
+	names := make([]string, 0, len(c.Columns))
+	for column := range c.Columns {
+		names = append(names, column)
+	}
+	sort.Strings(names)
+
+	columns := make([]string, 0, len(names))
+	for _, column := range names {
+		columns = append(columns, c.reference()+"."+Column(column)+" AS "+Column(c.Columns[column]))
+	}
+	return columns
+}
@@ -0,0 +1,286 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+)
+
+// DefaultAttachmentChunkSize is a default size in bytes of chunks attachments are stored in.
+const DefaultAttachmentChunkSize = 256 * 1024
+
+// PostgresAttachment describes a binary attachment of a data item.
+type PostgresAttachment struct {
+	// The name of the attachment, unique for the data item.
+	Name string
+	// The size of the attachment in bytes.
+	Size int64
+}
+
+// attachmentsTableName returns a name of the attachments table of the persistence.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) attachmentsTableName() string {
+	return c.TableName + "_attachments"
+}
+
+// ensureAttachments adds statements which create the attachments table. Attachments are split into bytea chunks,
+// so they are written and read by parts without holding whole attachments in memory, and they are removed
+// together with their data items by the foreign key.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) ensureAttachments(idType string) {
+	name := c.attachmentsTableName()
+	table := c.quotedSchemaObjectName(name)
+	id := c.quotedIdColumn()
+
+	definitions := id + " " + idType + " NOT NULL REFERENCES " + c.QuotedTableName() + " (" + id + ") ON DELETE CASCADE," +
+		" \"name\" TEXT NOT NULL, \"chunk\" INTEGER NOT NULL, \"content\" BYTEA NOT NULL," +
+		" PRIMARY KEY (" + id + ", \"name\", \"chunk\")"
+
+	// Attachments are kept for existing tables as well, so they're created by update statements.
+	// Binary content is usually compressed already, so it's stored without compression
+	c.updateStatements = append(c.updateStatements,
+		"CREATE TABLE IF NOT EXISTS "+table+" ("+definitions+")",
+		"ALTER TABLE "+table+" ALTER COLUMN \"content\" SET STORAGE EXTERNAL")
+}
+
+// checkAttachments returns an error when attachments are not enabled by options.attachments.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) checkAttachments(correlationId string) error {
+	if !c.attachments {
+		return cerr.NewConfigError(correlationId, "NO_ATTACHMENTS",
+			"Attachments of "+c.TableName+" are not enabled by options.attachments").
+			WithDetails("table", c.TableName)
+	}
+	return nil
+}
+
+// composeAttachmentItem returns a filter of the data item with the id in the first parameter, restricted
+// to items of the tenant, so attachments are isolated the same way as their data items.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) composeAttachmentItem(ctx context.Context, correlationId string,
+	params []any) (string, []any, error) {
+
+	return c.applyTenantFilter(ctx, correlationId, c.QuotedTableName()+"."+c.quotedIdColumn()+"=$1", params)
+}
+
+// WriteAttachment stores the binary attachment of the data item read from the reader, replacing the attachment
+// with the same name. The content is written by chunks of options.attachment_chunk_size within a transaction,
+// so readers see either the previous or the new content. The write isn't retried, as the reader can't be rewound.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- id                an id of the data item.
+//		- name              a name of the attachment.
+//		- reader            a reader of the attachment content.
+//	Returns: number of written bytes or error.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) WriteAttachment(ctx context.Context, correlationId string,
+	id K, name string, reader io.Reader) (size int64, err error) {
+
+	if err = c.checkAttachments(correlationId); err != nil {
+		return 0, err
+	}
+	if err = c.ensureOpen(ctx, correlationId); err != nil {
+		return 0, err
+	}
+	item, params, err := c.composeAttachmentItem(ctx, correlationId, []any{id})
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := c.GetClient(ctx).Begin(ctx)
+	if err != nil {
+		return 0, c.wrapConnectionError(correlationId, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+	txCtx := conn.NewContextWithTransaction(ctx, tx)
+
+	// The owner is locked, so the item can't be deleted while its attachment is written
+	table := c.quotedSchemaObjectName(c.attachmentsTableName())
+	rows, err := c.query(txCtx, correlationId, "SELECT 1 FROM "+c.QuotedTableName()+" WHERE "+item+" FOR UPDATE", params...)
+	if err != nil {
+		return 0, err
+	}
+	found := rows.Next()
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+	if !found {
+		err = cerr.NewNotFoundError(correlationId, "ITEM_NOT_FOUND", "Data item of the attachment is not found").
+			WithDetails("table", c.TableName).WithDetails("id", id)
+		return 0, err
+	}
+	if _, err = c.exec(txCtx, correlationId, "DELETE FROM "+table+" WHERE "+c.quotedIdColumn()+"=$1 AND \"name\"=$2", id, name); err != nil {
+		return 0, err
+	}
+
+	chunkSize := c.attachmentChunk
+	if chunkSize <= 0 {
+		chunkSize = DefaultAttachmentChunkSize
+	}
+	buf := make([]byte, chunkSize)
+	insert := "INSERT INTO " + table + " (" + c.quotedIdColumn() + ", \"name\", \"chunk\", \"content\") VALUES ($1, $2, $3, $4)"
+	// Empty attachments are stored as a single empty chunk
+	for chunk := 0; ; chunk++ {
+		if err = c.checkInterrupted(ctx, correlationId); err != nil {
+			return 0, err
+		}
+		count, readErr := io.ReadFull(reader, buf)
+		if readErr != nil && !errors.Is(readErr, io.EOF) && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+			err = readErr
+			return 0, err
+		}
+		if count > 0 || chunk == 0 {
+			if _, err = c.exec(txCtx, correlationId, insert, id, name, chunk, buf[:count]); err != nil {
+				return 0, err
+			}
+			size += int64(count)
+		}
+		if readErr != nil {
+			break
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, c.wrapConnectionError(correlationId, err)
+	}
+	c.Logger.Trace(ctx, correlationId, "Written attachment %s of %d bytes in %s with id = %s", name, size, c.TableName, id)
+	return size, nil
+}
+
+// ReadAttachment writes the binary attachment of the data item into the writer. Chunks are streamed
+// by a single query, so the content is consistent and only a chunk at a time is held in memory.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- id                an id of the data item.
+//		- name              a name of the attachment.
+//		- writer            a writer of the attachment content.
+//	Returns: number of read bytes or error. NotFoundError is returned when the attachment doesn't exist.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) ReadAttachment(ctx context.Context, correlationId string,
+	id K, name string, writer io.Writer) (int64, error) {
+
+	if err := c.checkAttachments(correlationId); err != nil {
+		return 0, err
+	}
+	item, params, err := c.composeAttachmentItem(ctx, correlationId, []any{id, name})
+	if err != nil {
+		return 0, err
+	}
+
+	query := "SELECT \"content\" FROM " + c.quotedSchemaObjectName(c.attachmentsTableName()) +
+		" WHERE " + c.quotedIdColumn() + "=$1 AND \"name\"=$2 AND EXISTS (SELECT 1 FROM " + c.QuotedTableName() +
+		" WHERE " + item + ") ORDER BY \"chunk\""
+	rows, err := c.queryRead(ctx, correlationId, query, params...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var size int64
+	found := false
+	for rows.Next() {
+		if err := c.checkInterrupted(ctx, correlationId); err != nil {
+			return size, err
+		}
+		found = true
+		var content []byte
+		if err := rows.Scan(&content); err != nil {
+			return size, err
+		}
+		count, err := writer.Write(content)
+		size += int64(count)
+		if err != nil {
+			return size, err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return size, err
+	}
+	if !found {
+		return 0, cerr.NewNotFoundError(correlationId, "ATTACHMENT_NOT_FOUND", "Attachment "+name+" is not found").
+			WithDetails("table", c.TableName).WithDetails("id", id).WithDetails("name", name)
+	}
+
+	c.Logger.Trace(ctx, correlationId, "Read attachment %s of %d bytes from %s with id = %s", name, size, c.TableName, id)
+	return size, nil
+}
+
+// GetAttachments gets names and sizes of attachments of the data item sorted by names.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- id                an id of the data item.
+//	Returns: attachments of the data item or error.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) GetAttachments(ctx context.Context, correlationId string,
+	id K) ([]PostgresAttachment, error) {
+
+	if err := c.checkAttachments(correlationId); err != nil {
+		return nil, err
+	}
+	item, params, err := c.composeAttachmentItem(ctx, correlationId, []any{id})
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT \"name\", sum(octet_length(\"content\"))::bigint FROM " + c.quotedSchemaObjectName(c.attachmentsTableName()) +
+		" WHERE " + c.quotedIdColumn() + "=$1 AND EXISTS (SELECT 1 FROM " + c.QuotedTableName() + " WHERE " + item + ")" +
+		" GROUP BY \"name\" ORDER BY \"name\""
+	rows, err := c.queryRead(ctx, correlationId, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := make([]PostgresAttachment, 0)
+	for rows.Next() {
+		var attachment PostgresAttachment
+		if err := rows.Scan(&attachment.Name, &attachment.Size); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(ctx, correlationId, "Retrieved %d attachments from %s with id = %s", len(attachments), c.TableName, id)
+	return attachments, nil
+}
+
+// DeleteAttachment deletes the attachment of the data item. Missing attachments are ignored.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- id                an id of the data item.
+//		- name              a name of the attachment.
+//	Returns: true if the attachment was deleted or error.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) DeleteAttachment(ctx context.Context, correlationId string,
+	id K, name string) (bool, error) {
+
+	if err := c.checkAttachments(correlationId); err != nil {
+		return false, err
+	}
+	item, params, err := c.composeAttachmentItem(ctx, correlationId, []any{id, name})
+	if err != nil {
+		return false, err
+	}
+
+	statement := "DELETE FROM " + c.quotedSchemaObjectName(c.attachmentsTableName()) +
+		" WHERE " + c.quotedIdColumn() + "=$1 AND \"name\"=$2 AND EXISTS (SELECT 1 FROM " + c.QuotedTableName() +
+		" WHERE " + item + ")"
+	tag, err := c.exec(ctx, correlationId, statement, params...)
+	if err != nil {
+		return false, err
+	}
+
+	c.Logger.Trace(ctx, correlationId, "Deleted attachment %s from %s with id = %s", name, c.TableName, id)
+	return tag.RowsAffected() > 0, nil
+}
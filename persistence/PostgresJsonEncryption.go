@@ -0,0 +1,315 @@
+package persistence
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+)
+
+// Fields of JSON documents which keep encrypted data in the data column.
+const (
+	encryptedKeyIdField = "key_id"
+	encryptedDataField  = "encrypted"
+)
+
+// IPostgresKeyProvider provides AES keys which encrypt JSON documents of persistences, e.g. keys
+// kept in a key management service. Keys are identified by ids stored with the encrypted documents,
+// so keys can be rotated while documents encrypted by previous keys can still be decrypted.
+// Keys are requested for every converted document, so providers shall cache them.
+type IPostgresKeyProvider interface {
+	// CurrentKey returns an id and a 16, 24 or 32 bytes long AES key which encrypts new documents.
+	CurrentKey() (string, []byte, error)
+	// GetKey returns the AES key with the id to decrypt documents.
+	GetKey(keyId string) ([]byte, error)
+}
+
+// PostgresStaticKeyProvider is a key provider with a fixed set of keys.
+type PostgresStaticKeyProvider struct {
+	currentKeyId string
+	keys         map[string][]byte
+}
+
+// NewPostgresStaticKeyProvider creates a new instance of the key provider.
+//
+//	Parameters:
+//		- currentKeyId an id of the key which encrypts new documents.
+//		- keys AES keys mapped to their ids, including previous keys to decrypt old documents.
+//	Returns: *PostgresStaticKeyProvider
+func NewPostgresStaticKeyProvider(currentKeyId string, keys map[string][]byte) *PostgresStaticKeyProvider {
+	c := &PostgresStaticKeyProvider{
+		currentKeyId: currentKeyId,
+		keys:         make(map[string][]byte, len(keys)),
+	}
+	for keyId, key := range keys {
+		c.keys[keyId] = key
+	}
+	return c
+}
+
+// CurrentKey returns the key which encrypts new documents.
+func (c *PostgresStaticKeyProvider) CurrentKey() (string, []byte, error) {
+	key, err := c.GetKey(c.currentKeyId)
+	return c.currentKeyId, key, err
+}
+
+// GetKey returns the key with the id.
+func (c *PostgresStaticKeyProvider) GetKey(keyId string) ([]byte, error) {
+	key, ok := c.keys[keyId]
+	if !ok {
+		return nil, cerr.NewConfigError("", "NO_ENCRYPTION_KEY", "Encryption key "+keyId+" is not found").
+			WithDetails("key_id", keyId)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, cerr.NewConfigError("", "INVALID_ENCRYPTION_KEY", "Encryption key "+keyId+" must be 16, 24 or 32 bytes long").
+		WithDetails("key_id", keyId)
+}
+
+// SetKeyProvider enables encryption of JSON documents with AES-GCM by keys of the provider, so documents
+// are encrypted at rest even when the database doesn't encrypt its storage. Encryption can be enabled
+// by options.encryption_key as well. Documents are encrypted when they're written, so documents stored
+// before encryption was enabled are read as is until they're updated.
+//
+// The database can't see fields of encrypted documents, so encrypted persistences must filter and sort
+// data items by other columns, and RemoveFields and UpdateByFilter fail. UpdatePartially reads, merges
+// and writes the document back within a transaction.
+//
+//	Parameters:
+//		- provider a key provider, nil to disable encryption.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) SetKeyProvider(provider IPostgresKeyProvider) {
+	c.keyProvider = provider
+}
+
+// newConfiguredKeyProvider creates a key provider with the key set by options.encryption_key,
+// or returns nil when the key is not set.
+func newConfiguredKeyProvider(encodedKey string) (IPostgresKeyProvider, *cerr.ApplicationError) {
+	if encodedKey == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+	if err != nil {
+		return nil, cerr.NewConfigError("", "INVALID_ENCRYPTION_KEY", "options.encryption_key is not a valid base64 string").
+			WithCause(err)
+	}
+	if _, err := newAead(key); err != nil {
+		return nil, cerr.NewConfigError("", "INVALID_ENCRYPTION_KEY", "options.encryption_key must be 16, 24 or 32 bytes long").
+			WithCause(err)
+	}
+	return NewPostgresStaticKeyProvider("default", map[string][]byte{"default": key}), nil
+}
+
+// checkUnencrypted returns an error when the operation can't change encrypted documents in the database.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) checkUnencrypted(correlationId string, operation string) error {
+	if c.keyProvider != nil {
+		return cerr.NewConfigError(correlationId, "ENCRYPTED_DATA",
+			operation+" can't change encrypted documents of "+c.TableName).
+			WithDetails("table", c.TableName)
+	}
+	return nil
+}
+
+// encryptData encrypts the JSON document into a document with the key id and base64 encoded nonce and ciphertext.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) encryptData(value any) (map[string]any, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	keyId, key, err := c.keyProvider.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAead(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := aead.Seal(nonce, nonce, plaintext, nil)
+	return map[string]any{
+		encryptedKeyIdField: keyId,
+		encryptedDataField:  base64.StdEncoding.EncodeToString(ciphertext),
+	}, nil
+}
+
+// decryptData decrypts the document written by encryptData into its JSON. Other documents are returned as is.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) decryptData(value any) (any, error) {
+	document, ok := value.(map[string]any)
+	if !ok || len(document) != 2 || c.keyProvider == nil {
+		return value, nil
+	}
+	keyId, ok1 := document[encryptedKeyIdField].(string)
+	encoded, ok2 := document[encryptedDataField].(string)
+	if !ok1 || !ok2 {
+		return value, nil
+	}
+
+	key, err := c.keyProvider.GetKey(keyId)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAead(key)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(ciphertext) < aead.NonceSize() {
+		return nil, cerr.NewInternalError("", "INVALID_ENCRYPTED_DATA", "Encrypted document of "+c.TableName+" is damaged").
+			WithCause(err)
+	}
+	plaintext, err := aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, cerr.NewInternalError("", "INVALID_ENCRYPTED_DATA", "Encrypted document of "+c.TableName+" can't be decrypted").
+			WithDetails("key_id", keyId).WithCause(err)
+	}
+	return json.RawMessage(plaintext), nil
+}
+
+// newAead creates an AES-GCM cipher with the key.
+func newAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, cerr.NewConfigError("", "INVALID_ENCRYPTION_KEY", "Encryption key must be 16, 24 or 32 bytes long").
+			WithCause(err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// updateEncryptedPartially updates fields of the encrypted document by reading, merging and writing it back
+// within a transaction. The document row is locked, so concurrent updates are not lost.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) updateEncryptedPartially(ctx context.Context, correlationId string,
+	id K, data cdata.AnyValueMap) (result T, err error) {
+
+	if err = c.ensureOpen(ctx, correlationId); err != nil {
+		return result, err
+	}
+	filter, params, err := c.applyTenantFilter(ctx, correlationId, c.quotedIdColumn()+"=$1", []any{id})
+	if err != nil {
+		return result, err
+	}
+
+	tx, err := c.GetClient(ctx).Begin(ctx)
+	if err != nil {
+		return result, c.wrapConnectionError(correlationId, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+	txCtx := conn.NewContextWithTransaction(ctx, tx)
+
+	// The stored document is decrypted without conversion into the data item,
+	// which masks fields, so masks are not written back
+	rows, err := c.query(txCtx, correlationId, "SELECT "+c.QuoteIdentifier(c.jsonColumn)+"::text FROM "+
+		c.QuotedTableName()+" WHERE "+filter+" FOR UPDATE", params...)
+	if err != nil {
+		return result, err
+	}
+	var stored *string
+	found := rows.Next()
+	if found {
+		err = rows.Scan(&stored)
+	}
+	rows.Close()
+	if err == nil {
+		err = rows.Err()
+	}
+	if err != nil {
+		return result, err
+	}
+	if !found {
+		_ = tx.Rollback(ctx)
+		return result, nil
+	}
+
+	document := make(map[string]any)
+	if stored != nil {
+		var value any
+		if err = json.Unmarshal([]byte(*stored), &value); err != nil {
+			return result, err
+		}
+		if value, err = c.decryptData(value); err != nil {
+			return result, err
+		}
+		buf, err := cconv.JsonConverter.ToJson(value)
+		if err != nil {
+			return result, err
+		}
+		if decrypted := cconv.JsonConverter.ToMap(buf); decrypted != nil {
+			document = decrypted
+		}
+	}
+
+	// Fields are merged the same way as by jsonb operators of unencrypted documents
+	buf, err := cconv.JsonConverter.ToJson(data.Value())
+	if err != nil {
+		return result, err
+	}
+	fields := cconv.JsonConverter.ToMap(buf)
+	if c.deepMerge {
+		for _, field := range flattenNestedFields(nil, fields, nil) {
+			setNestedValue(document, field.path, field.value)
+		}
+	} else {
+		for name, value := range fields {
+			document[name] = value
+		}
+	}
+
+	if buf, err = cconv.JsonConverter.ToJson(document); err != nil {
+		return result, err
+	}
+	item, err := c.JsonConvertor.FromJson(buf)
+	if err != nil {
+		return result, err
+	}
+	encrypted, err := c.encryptData(item)
+	if err != nil {
+		return result, err
+	}
+
+	query, values, err := c.applyTenantCondition(ctx, correlationId, "UPDATE "+c.QuotedTableName()+" SET "+
+		c.QuoteIdentifier(c.jsonColumn)+"=$2 WHERE "+c.quotedIdColumn()+"=$1 RETURNING *", []any{id, encrypted})
+	if err != nil {
+		return result, err
+	}
+	items, err := c.queryItems(txCtx, correlationId, query, values...)
+	if err != nil {
+		return result, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return result, c.wrapConnectionError(correlationId, err)
+	}
+	if len(items) > 0 {
+		result = items[0]
+	}
+	return result, nil
+}
+
+// setNestedValue sets the value at the path of the document creating missing intermediate objects, like jsonb_set.
+func setNestedValue(document map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		nested, ok := document[key].(map[string]any)
+		if !ok {
+			nested = make(map[string]any)
+			document[key] = nested
+		}
+		document = nested
+	}
+	document[path[len(path)-1]] = value
+}
@@ -0,0 +1,71 @@
+package persistence
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// Export streams JSON documents matching a parameterized filter into the writer as newline-delimited JSON,
+// one document per line ordered by ids. Rows are written as they are received from the database,
+// so exported tables don't have to fit into memory. Documents are written as stored, without
+// conversion into data items, except that they are decrypted and their masked fields are replaced.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- filter            (optional) a WHERE clause with parameter placeholders
+//		- params            (optional) values of the filter parameters
+//		- writer            a writer to stream documents into.
+//	Returns: number of exported documents or error.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) Export(ctx context.Context, correlationId string,
+	filter string, params []any, writer io.Writer) (count int64, err error) {
+
+	filter, params, err = c.applyTenantFilter(ctx, correlationId, filter, params)
+	if err != nil {
+		return 0, err
+	}
+
+	// Casting into jsonb keeps every document on a single line
+	query := "SELECT " + c.QuoteIdentifier(c.jsonColumn) + "::jsonb::text FROM " + c.QuotedTableName()
+	if len(filter) > 0 {
+		query += " WHERE " + filter
+	}
+	query += " ORDER BY " + c.quotedIdColumn()
+
+	rows, err := c.queryRead(ctx, correlationId, query, params...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	buffer := bufio.NewWriter(writer)
+	for rows.Next() {
+		if err := c.checkInterrupted(ctx, correlationId); err != nil {
+			return count, err
+		}
+		var document string
+		if err := rows.Scan(&document); err != nil {
+			return count, err
+		}
+		if document, err = c.publicDocument(document); err != nil {
+			return count, err
+		}
+		if _, err := buffer.WriteString(document); err != nil {
+			return count, err
+		}
+		if err := buffer.WriteByte('\n'); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+	if err := buffer.Flush(); err != nil {
+		return count, err
+	}
+
+	c.Logger.Trace(ctx, correlationId, "Exported %d documents from %s", count, c.TableName)
+	return count, nil
+}
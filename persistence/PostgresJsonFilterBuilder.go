@@ -0,0 +1,364 @@
+package persistence
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+)
+
+// Types of JSON values filter values are converted into by PostgresJsonFilterBuilder.
+const (
+	// JsonTypeString keeps filter values as JSON strings.
+	JsonTypeString = "string"
+	// JsonTypeNumber converts filter values into JSON numbers.
+	JsonTypeNumber = "number"
+	// JsonTypeBoolean converts filter values into JSON booleans.
+	JsonTypeBoolean = "boolean"
+)
+
+type jsonFilterCondition struct {
+	key      string
+	field    string
+	path     string
+	operator string
+	compare  string
+	jsonType string
+}
+
+// PostgresJsonFilterBuilder translates FilterParams into parameterized predicates over a JSONB column:
+// equality into containment, e.g. "data" @> $1, and other conditions into jsonb_path_exists("data", $1, $2)
+// with the value passed as the $value variable of the JSON path. Only explicitly declared filter keys are
+// translated and values are never concatenated into SQL. Nested fields are separated by dots.
+//
+//	Example:
+//		builder := NewPostgresJsonFilterBuilder("data").
+//			Equal("key", "key", JsonTypeString).
+//			In("statuses", "status", JsonTypeString).
+//			Compare("min_count", "stats.count", ">=", JsonTypeNumber).
+//			HasAnyTag("tags", "tags").
+//			Path("tag", "$.tags[*] ? (@ == $value)", JsonTypeString)
+//		where, params := builder.Build(filter)
+//		page, err := c.GetPageByFilterWithParams(ctx, correlationId, where, params, paging, "", "")
+type PostgresJsonFilterBuilder struct {
+	column     string
+	plainJson  bool
+	extracted  map[string]extractedColumn
+	conditions []jsonFilterCondition
+}
+
+// NewPostgresJsonFilterBuilder creates a new instance of the filter builder for the JSONB column.
+//
+//	Parameters:
+//		- column a name of the JSONB column (default: data).
+//	Returns: *PostgresJsonFilterBuilder
+func NewPostgresJsonFilterBuilder(column string) *PostgresJsonFilterBuilder {
+	if column == "" {
+		column = "data"
+	}
+	return &PostgresJsonFilterBuilder{
+		column:     column,
+		extracted:  make(map[string]extractedColumn),
+		conditions: make([]jsonFilterCondition, 0),
+	}
+}
+
+// WithExtractedColumn compares the field in Equal, In and Compare conditions with the table column
+// instead of the JSON data, e.g. with a column extracted by EnsureExtractedColumn. Filter values are cast
+// into the column type, so comparisons can use indexes of the column.
+//
+//	Parameters:
+//		- field a field name or a dot-separated path.
+//		- column a name of the table column.
+//		- pgType a PostgreSQL type of the column, e.g. TEXT or INTEGER.
+//	Returns: the builder to chain calls.
+func (c *PostgresJsonFilterBuilder) WithExtractedColumn(field string, column string, pgType string) *PostgresJsonFilterBuilder {
+	if column != "" && sortCastPattern.MatchString(pgType) {
+		c.extracted[field] = extractedColumn{column: column, pgType: pgType}
+	}
+	return c
+}
+
+// WithColumnType sets a type of the column. JSON columns are cast into JSONB in conditions,
+// so they are filtered the same way as JSONB columns, but without their GIN indexes.
+//
+//	Parameters:
+//		- dataType a type of the column: JSONB or JSON (default: JSONB).
+//	Returns: the builder to chain calls.
+func (c *PostgresJsonFilterBuilder) WithColumnType(dataType string) *PostgresJsonFilterBuilder {
+	c.plainJson = strings.EqualFold(strings.TrimSpace(dataType), "JSON")
+	return c
+}
+
+// Equal declares a filter key which value the field must be equal to. It's translated into
+// containment of the value, which can use GIN indexes of the column.
+//
+//	Parameters:
+//		- key a filter key.
+//		- field a field name or a dot-separated path.
+//		- jsonType a type of the field value: JsonTypeString, JsonTypeNumber or JsonTypeBoolean.
+//	Returns: the builder to chain calls.
+func (c *PostgresJsonFilterBuilder) Equal(key string, field string, jsonType string) *PostgresJsonFilterBuilder {
+	c.conditions = append(c.conditions,
+		jsonFilterCondition{key: key, field: field, operator: "@>", jsonType: jsonType})
+	return c
+}
+
+// In declares a filter key with a comma-separated list of values the field must be equal to.
+//
+//	Parameters:
+//		- key a filter key.
+//		- field a field name or a dot-separated path.
+//		- jsonType a type of the field value: JsonTypeString, JsonTypeNumber or JsonTypeBoolean.
+//	Returns: the builder to chain calls.
+func (c *PostgresJsonFilterBuilder) In(key string, field string, jsonType string) *PostgresJsonFilterBuilder {
+	c.conditions = append(c.conditions, jsonFilterCondition{key: key, field: field,
+		path: composeJsonPath(field) + " ? (@ == $value[*])", operator: "IN", jsonType: jsonType})
+	return c
+}
+
+// Compare declares a filter key compared with the field using the given operator.
+// Supported operators are ==, =, !=, <>, <, <=, > and >=. Other operators are replaced with ==.
+//
+//	Parameters:
+//		- key a filter key.
+//		- field a field name or a dot-separated path.
+//		- operator a comparison operator.
+//		- jsonType a type of the field value: JsonTypeString, JsonTypeNumber or JsonTypeBoolean.
+//	Returns: the builder to chain calls.
+func (c *PostgresJsonFilterBuilder) Compare(key string, field string, operator string,
+	jsonType string) *PostgresJsonFilterBuilder {
+
+	switch operator {
+	case "==", "!=", "<", "<=", ">", ">=":
+	case "<>":
+		operator = "!="
+	default:
+		operator = "=="
+	}
+	c.conditions = append(c.conditions, jsonFilterCondition{key: key, field: field,
+		path: composeJsonPath(field) + " ? (@ " + operator + " $value)", compare: operator, jsonType: jsonType})
+	return c
+}
+
+// HasTag declares a filter key which value must be an element of the array field, e.g. "data"->'tags' ? $1.
+// Only string elements are matched.
+//
+//	Parameters:
+//		- key a filter key.
+//		- field a field name or a dot-separated path of the array.
+//	Returns: the builder to chain calls.
+func (c *PostgresJsonFilterBuilder) HasTag(key string, field string) *PostgresJsonFilterBuilder {
+	c.conditions = append(c.conditions, jsonFilterCondition{key: key, field: field, operator: "?"})
+	return c
+}
+
+// HasAnyTag declares a filter key with a comma-separated list of values, any of which
+// must be an element of the array field, e.g. "data"->'tags' ?| $1.
+//
+//	Parameters:
+//		- key a filter key.
+//		- field a field name or a dot-separated path of the array.
+//	Returns: the builder to chain calls.
+func (c *PostgresJsonFilterBuilder) HasAnyTag(key string, field string) *PostgresJsonFilterBuilder {
+	c.conditions = append(c.conditions, jsonFilterCondition{key: key, field: field, operator: "?|"})
+	return c
+}
+
+// HasAllTags declares a filter key with a comma-separated list of values, all of which
+// must be elements of the array field, e.g. "data"->'tags' ?& $1.
+//
+//	Parameters:
+//		- key a filter key.
+//		- field a field name or a dot-separated path of the array.
+//	Returns: the builder to chain calls.
+func (c *PostgresJsonFilterBuilder) HasAllTags(key string, field string) *PostgresJsonFilterBuilder {
+	c.conditions = append(c.conditions, jsonFilterCondition{key: key, field: field, operator: "?&"})
+	return c
+}
+
+// Path declares a filter key matched by a custom JSON path which refers to the filter value as $value,
+// e.g. $.tags[*] ? (@ == $value) or $.items[*] ? (@.price > $value).
+//
+//	Parameters:
+//		- key a filter key.
+//		- path a JSON path which must return items for matching rows.
+//		- jsonType a type of the filter value: JsonTypeString, JsonTypeNumber or JsonTypeBoolean.
+//	Returns: the builder to chain calls.
+func (c *PostgresJsonFilterBuilder) Path(key string, path string, jsonType string) *PostgresJsonFilterBuilder {
+	c.conditions = append(c.conditions, jsonFilterCondition{key: key, path: path, jsonType: jsonType})
+	return c
+}
+
+// Build translates the filter into a WHERE clause joined with AND and a list of bound parameters.
+// Parameters are numbered starting from $1.
+//
+//	Parameters:
+//		- filter filter parameters.
+//	Returns: a WHERE clause without the WHERE keyword and a list of parameter values.
+func (c *PostgresJsonFilterBuilder) Build(filter cdata.FilterParams) (string, []any) {
+	return c.BuildFrom(filter, 1)
+}
+
+// BuildFrom translates the filter into a WHERE clause joined with AND and a list of bound parameters.
+// Parameters are numbered starting from the given index to combine the clause with other parameterized statements.
+//
+//	Parameters:
+//		- filter filter parameters.
+//		- startIndex a number of the first parameter.
+//	Returns: a WHERE clause without the WHERE keyword and a list of parameter values.
+func (c *PostgresJsonFilterBuilder) BuildFrom(filter cdata.FilterParams, startIndex int) (string, []any) {
+	clauses := make([]string, 0, len(c.conditions))
+	params := make([]any, 0, len(c.conditions))
+	column := Column(c.column)
+	if c.plainJson {
+		column += "::jsonb"
+	}
+
+	for _, condition := range c.conditions {
+		value, ok := filter.GetAsNullableString(condition.key)
+		if !ok || value == "" {
+			continue
+		}
+
+		placeholder := "$" + strconv.Itoa(startIndex+len(params))
+		if clause, param, ok := c.composeExtractedCondition(condition, value, placeholder); ok {
+			clauses = append(clauses, clause)
+			params = append(params, param)
+			continue
+		}
+		switch condition.operator {
+		case "?":
+			clauses = append(clauses, composeJsonValue(column, condition.field)+" ? "+placeholder)
+			params = append(params, value)
+			continue
+		case "?|", "?&":
+			tags := strings.Split(value, ",")
+			for index := range tags {
+				tags[index] = strings.TrimSpace(tags[index])
+			}
+			clauses = append(clauses, composeJsonValue(column, condition.field)+" "+condition.operator+" "+placeholder+"::text[]")
+			params = append(params, tags)
+			continue
+		}
+
+		var jsonValue any
+		if condition.operator == "IN" {
+			values := make([]any, 0)
+			for _, item := range strings.Split(value, ",") {
+				values = append(values, convertJsonFilterValue(strings.TrimSpace(item), condition.jsonType))
+			}
+			jsonValue = values
+		} else {
+			jsonValue = convertJsonFilterValue(value, condition.jsonType)
+		}
+
+		if condition.operator == "@>" {
+			clauses = append(clauses, column+" @> "+placeholder+"::jsonb")
+			params = append(params, composeJsonDocument(condition.field, jsonValue))
+			continue
+		}
+
+		vars, _ := json.Marshal(map[string]any{"value": jsonValue})
+		clauses = append(clauses, "jsonb_path_exists("+column+", "+placeholder+"::jsonpath, $"+
+			strconv.Itoa(startIndex+len(params)+1)+"::jsonb)")
+		params = append(params, condition.path, string(vars))
+	}
+
+	return strings.Join(clauses, " AND "), params
+}
+
+// composeExtractedCondition composes a comparison of the column extracted from the condition field with the value.
+// Values are passed as text and cast into the column type, so they are parsed the same way as by the generated column.
+func (c *PostgresJsonFilterBuilder) composeExtractedCondition(condition jsonFilterCondition,
+	value string, placeholder string) (string, any, bool) {
+
+	extracted, ok := c.extracted[condition.field]
+	if !ok || condition.field == "" {
+		return "", nil, false
+	}
+	column := Column(extracted.column)
+	switch {
+	case condition.operator == "@>":
+		return column + "=" + placeholder + "::text::" + extracted.pgType, value, true
+	case condition.operator == "IN":
+		values := strings.Split(value, ",")
+		for index := range values {
+			values[index] = strings.TrimSpace(values[index])
+		}
+		return column + "=ANY(" + placeholder + "::text[]::" + extracted.pgType + "[])", values, true
+	case condition.compare != "":
+		operator := condition.compare
+		switch operator {
+		case "==":
+			operator = "="
+		case "!=":
+			operator = "<>"
+		}
+		return column + operator + placeholder + "::text::" + extracted.pgType, value, true
+	}
+	return "", nil, false
+}
+
+// JsonFilterBuilder creates a filter builder for the JSON data column of the persistence,
+// which uses columns declared by EnsureExtractedColumn instead of their JSON fields
+// and casts JSON data columns into JSONB.
+//
+//	Returns: *PostgresJsonFilterBuilder
+func (c *IdentifiableJsonPostgresPersistence[T, K]) JsonFilterBuilder() *PostgresJsonFilterBuilder {
+	builder := NewPostgresJsonFilterBuilder(c.jsonColumn)
+	if c.plainJson {
+		builder.WithColumnType("JSON")
+	}
+	for field, extracted := range c.extractedColumns {
+		builder.WithExtractedColumn(field, extracted.column, extracted.pgType)
+	}
+	return builder
+}
+
+// convertJsonFilterValue converts the filter value into the JSON type.
+// Values which can't be converted are kept as strings, so they don't match fields of other types.
+func convertJsonFilterValue(value string, jsonType string) any {
+	switch jsonType {
+	case JsonTypeNumber:
+		if number, ok := cconv.DoubleConverter.ToNullableDouble(value); ok {
+			return number
+		}
+	case JsonTypeBoolean:
+		if flag, ok := cconv.BooleanConverter.ToNullableBoolean(value); ok {
+			return flag
+		}
+	}
+	return value
+}
+
+// composeJsonDocument returns a JSON document with the value at the dot-separated path of the field.
+func composeJsonDocument(field string, value any) string {
+	path := strings.Split(field, ".")
+	for index := len(path) - 1; index >= 0; index-- {
+		value = map[string]any{path[index]: value}
+	}
+	document, _ := json.Marshal(value)
+	return string(document)
+}
+
+// composeJsonValue returns an expression which extracts the JSONB value of the dot-separated field
+// from the column expression, e.g. "data"->'tags'.
+func composeJsonValue(expr string, field string) string {
+	for _, key := range strings.Split(field, ".") {
+		expr += "->" + QuoteLiteral(key)
+	}
+	return expr
+}
+
+// composeJsonPath returns a JSON path of the dot-separated field, e.g. $."stats"."count".
+func composeJsonPath(field string) string {
+	path := "$"
+	for _, key := range strings.Split(field, ".") {
+		key = strings.ReplaceAll(key, "\\", "\\\\")
+		path += ".\"" + strings.ReplaceAll(key, "\"", "\\\"") + "\""
+	}
+	return path
+}
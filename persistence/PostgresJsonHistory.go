@@ -0,0 +1,118 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+)
+
+// historyCorrelationSetting is a run-time setting which passes the correlationId to the history trigger.
+const historyCorrelationSetting = "pip.correlation_id"
+
+// Operations which replaced versions of documents kept in the history table.
+const (
+	// HistoryOperationUpdate marks versions replaced by updates, including partial updates and upserts.
+	HistoryOperationUpdate = "update"
+	// HistoryOperationDelete marks versions of deleted documents.
+	HistoryOperationDelete = "delete"
+)
+
+// PostgresHistoryRecord is a previous version of a document kept in the history table.
+type PostgresHistoryRecord[T any] struct {
+	// The previous version of the data item.
+	Item T
+	// The operation which replaced the version: update or delete.
+	Operation string
+	// The correlationId of the operation, empty if it wasn't set.
+	CorrelationId string
+	// The time when the version was replaced.
+	ChangedAt time.Time
+}
+
+// historyTableName returns a name of the history table of the persistence.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) historyTableName() string {
+	return c.TableName + "_history"
+}
+
+// ensureHistory adds statements which create the history table and a trigger copying previous versions
+// of updated and deleted documents into it. A trigger catches every change, including upserts,
+// batches and custom SQL, while the correlationId is passed by a run-time setting of the operations.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) ensureHistory(idType string, dataType string) {
+	name := c.historyTableName()
+	table := c.quotedSchemaObjectName(name)
+	function := c.quotedSchemaObjectName(name + "_record")
+	id, data := c.quotedIdColumn(), c.QuoteIdentifier(c.jsonColumn)
+
+	definitions := "\"history_id\" BIGSERIAL PRIMARY KEY, " + id + " " + idType + " NOT NULL, " + data + " " + dataType +
+		", \"operation\" TEXT NOT NULL, \"correlation_id\" TEXT, \"changed_at\" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()"
+	columns, values := id+", "+data+", \"operation\", \"correlation_id\"",
+		"OLD."+id+", OLD."+data+", lower(TG_OP), NULLIF(current_setting('"+historyCorrelationSetting+"', true), '')"
+	if definition := c.composeTenantColumn(); definition != "" {
+		definitions += ", " + definition
+		columns += ", " + c.quotedTenantColumn()
+		values += ", OLD." + c.quotedTenantColumn()
+	}
+
+	// History is kept for existing tables as well, so it's created by update statements
+	c.updateStatements = append(c.updateStatements,
+		"CREATE TABLE IF NOT EXISTS "+table+" ("+definitions+")",
+		"CREATE INDEX IF NOT EXISTS "+c.QuoteIdentifier(name+"_id")+" ON "+table+" ("+id+", \"history_id\")",
+		"CREATE OR REPLACE FUNCTION "+function+"() RETURNS trigger LANGUAGE plpgsql AS $$ BEGIN"+
+			" INSERT INTO "+table+" ("+columns+") VALUES ("+values+"); RETURN NULL; END $$",
+		"DROP TRIGGER IF EXISTS "+c.QuoteIdentifier(name)+" ON "+c.QuotedTableName(),
+		"CREATE TRIGGER "+c.QuoteIdentifier(name)+" AFTER UPDATE OR DELETE ON "+c.QuotedTableName()+
+			" FOR EACH ROW EXECUTE FUNCTION "+function+"()")
+}
+
+// GetHistoryById gets previous versions of the document with the id kept in the history table
+// when options.history is set, the latest first. Versions are decrypted and masked like data items.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- id                an id of the data item.
+//	Returns: previous versions of the data item or error.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) GetHistoryById(ctx context.Context, correlationId string,
+	id K) ([]PostgresHistoryRecord[T], error) {
+
+	if !c.history {
+		return nil, cerr.NewConfigError(correlationId, "NO_HISTORY", "History of "+c.TableName+" is not enabled by options.history").
+			WithDetails("table", c.TableName)
+	}
+
+	query, params, err := c.applyTenantCondition(ctx, correlationId,
+		"SELECT "+c.QuoteIdentifier(c.jsonColumn)+"::text, \"operation\", COALESCE(\"correlation_id\", ''), \"changed_at\""+
+			" FROM "+c.quotedSchemaObjectName(c.historyTableName())+" WHERE "+c.quotedIdColumn()+"=$1", []any{id})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.queryRead(ctx, correlationId, query+" ORDER BY \"history_id\" DESC", params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]PostgresHistoryRecord[T], 0)
+	for rows.Next() {
+		var record PostgresHistoryRecord[T]
+		var data string
+		if err := rows.Scan(&data, &record.Operation, &record.CorrelationId, &record.ChangedAt); err != nil {
+			return nil, err
+		}
+		if data, err = c.publicDocument(data); err != nil {
+			return nil, err
+		}
+		if record.Item, err = c.JsonConvertor.FromJson(data); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(ctx, correlationId, "Retrieved %d versions from history of %s with id = %s", len(records), c.TableName, id)
+	return records, nil
+}
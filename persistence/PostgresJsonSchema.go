@@ -0,0 +1,85 @@
+package persistence
+
+import (
+	"sort"
+	"strings"
+)
+
+// Types of JSON values checked by EnsureDataTypes in addition to JsonTypeString, JsonTypeNumber and JsonTypeBoolean.
+const (
+	// JsonTypeObject is a type of nested JSON objects.
+	JsonTypeObject = "object"
+	// JsonTypeArray is a type of JSON arrays.
+	JsonTypeArray = "array"
+)
+
+// EnsureDataTypes adds a CHECK constraint to the table on opening, so the database rejects documents
+// with fields of unexpected types or without required fields. Null values match any type, but not required fields.
+// The constraint is recreated every time the persistence is opened and isn't validated for existing rows,
+// so changed types apply to new and updated documents only.
+//
+//	Example:
+//		c.EnsureDataTypes(map[string]string{
+//			"key":         persist.JsonTypeString,
+//			"address.zip": persist.JsonTypeString,
+//			"tags":        persist.JsonTypeArray,
+//		}, []string{"key"})
+//
+//	Parameters:
+//		- types field names or dot-separated paths of nested fields mapped to their types:
+//		  string, number, boolean, object or array. Fields of other types are ignored.
+//		- required field names or dot-separated paths of fields which must be set.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) EnsureDataTypes(types map[string]string, required []string) {
+	fields := make([]string, 0, len(types))
+	for field := range types {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	conditions := make([]string, 0, len(fields)+len(required))
+	for _, field := range fields {
+		switch jsonType := strings.ToLower(types[field]); jsonType {
+		case JsonTypeString, JsonTypeNumber, JsonTypeBoolean, JsonTypeObject, JsonTypeArray:
+			conditions = append(conditions, "COALESCE(jsonb_typeof("+c.composeJsonPathValue(field)+"), 'null')"+
+				" IN ("+QuoteLiteral(jsonType)+", 'null')")
+		}
+	}
+	for _, field := range required {
+		conditions = append(conditions, "COALESCE(jsonb_typeof("+c.composeJsonPathValue(field)+"), 'null') <> 'null'")
+	}
+	if len(conditions) == 0 {
+		return
+	}
+	c.ensureDataConstraint(c.TableName+"_"+c.jsonColumn+"_types", strings.Join(conditions, " AND "))
+}
+
+// EnsureJsonSchema adds a CHECK constraint which validates documents against the JSON schema on opening.
+// It requires the pg_jsonschema extension, which is created when it's available but not enabled yet.
+// Like EnsureDataTypes the constraint is recreated every time the persistence is opened
+// and isn't validated for existing rows.
+//
+//	Parameters:
+//		- schema a JSON schema of the documents.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) EnsureJsonSchema(schema string) {
+	c.updateStatements = append(c.updateStatements, "CREATE EXTENSION IF NOT EXISTS pg_jsonschema")
+	c.ensureDataConstraint(c.TableName+"_"+c.jsonColumn+"_schema",
+		"jsonb_matches_schema("+QuoteLiteral(schema)+"::json, "+c.jsonbData()+")")
+}
+
+// ensureDataConstraint recreates the CHECK constraint of the data column on opening.
+// The constraint is added as NOT VALID, so opening doesn't fail or scan the table because of existing rows.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) ensureDataConstraint(name string, check string) {
+	c.updateStatements = append(c.updateStatements,
+		"ALTER TABLE "+c.QuotedTableName()+" DROP CONSTRAINT IF EXISTS "+c.QuoteIdentifier(name),
+		"ALTER TABLE "+c.QuotedTableName()+" ADD CONSTRAINT "+c.QuoteIdentifier(name)+
+			" CHECK ("+check+") NOT VALID")
+}
+
+// composeJsonPathValue returns an expression which extracts the JSONB value of the dot-separated field.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) composeJsonPathValue(field string) string {
+	keys := strings.Split(field, ".")
+	for index, key := range keys {
+		keys[index] = QuoteLiteral(key)
+	}
+	return c.jsonbData() + "#>ARRAY[" + strings.Join(keys, ",") + "]::text[]"
+}
@@ -0,0 +1,80 @@
+package persistence
+
+import (
+	"context"
+	"strconv"
+)
+
+// PostgresJsonStats reports sizes of JSON documents and the table storage.
+// Document sizes are stored sizes in bytes, after compression of large documents.
+type PostgresJsonStats struct {
+	// Number of rows in the table.
+	Count int64
+	// Number of dead rows waiting to be vacuumed, an indicator of table bloat.
+	DeadCount int64
+	// Average size of documents.
+	AvgSize float64
+	// Median size of documents.
+	MedianSize int64
+	// Size which 95% of documents don't exceed.
+	P95Size int64
+	// Size which 99% of documents don't exceed.
+	P99Size int64
+	// Size of the largest document.
+	MaxSize int64
+	// Size of the table including its TOAST table, but without indexes.
+	TableSize int64
+	// Size of the TOAST table where large documents are stored out of line.
+	ToastSize int64
+	// Size of all indexes of the table.
+	IndexesSize int64
+	// Total size of the table with its TOAST table and indexes.
+	TotalSize int64
+}
+
+// Stats reports row counts, sizes of JSON documents and sizes of the table and its indexes,
+// so oversized documents and bloated tables can be spotted. Document sizes are calculated
+// over all rows of the table, ignoring tenants, so it reads the whole table and shall not be called often.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//	Returns: statistics of the table or error.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) Stats(ctx context.Context, correlationId string) (stats PostgresJsonStats, err error) {
+	size := "pg_column_size(" + c.QuoteIdentifier(c.jsonColumn) + ")"
+	percentile := func(fraction float64) string {
+		return "COALESCE(percentile_cont(" + strconv.FormatFloat(fraction, 'f', -1, 64) +
+			") WITHIN GROUP (ORDER BY " + size + "), 0)::bigint"
+	}
+	// Sizes of partitioned tables are sums of sizes of their partitions
+	relations := c.composeTableRelations("$1")
+	sum := func(expr string) string {
+		return "(SELECT COALESCE(sum(" + expr + "), 0)::bigint FROM (" + relations + ") AS \"relations\"(\"oid\"))"
+	}
+	query := "SELECT count(*), " + sum("pg_stat_get_dead_tuples(\"oid\")") + "," +
+		" COALESCE(avg(" + size + "), 0)::float8, " + percentile(0.5) + ", " + percentile(0.95) + ", " + percentile(0.99) + "," +
+		" COALESCE(max(" + size + "), 0)::bigint, " + sum("pg_table_size(\"oid\")") + "," +
+		" COALESCE((SELECT sum(pg_total_relation_size(reltoastrelid)) FROM pg_class WHERE oid IN (" + relations + ") AND reltoastrelid<>0), 0)::bigint," +
+		" " + sum("pg_indexes_size(\"oid\")") + ", " + sum("pg_total_relation_size(\"oid\")") +
+		" FROM " + c.QuotedTableName()
+
+	rows, err := c.queryRead(ctx, correlationId, query, c.qualifiedTableName())
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		err = rows.Scan(&stats.Count, &stats.DeadCount, &stats.AvgSize, &stats.MedianSize, &stats.P95Size, &stats.P99Size,
+			&stats.MaxSize, &stats.TableSize, &stats.ToastSize, &stats.IndexesSize, &stats.TotalSize)
+		if err != nil {
+			return stats, err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return stats, err
+	}
+
+	c.Logger.Trace(ctx, correlationId, "Retrieved stats of %d documents in %s", stats.Count, c.TableName)
+	return stats, nil
+}
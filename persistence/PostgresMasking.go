@@ -0,0 +1,65 @@
+package persistence
+
+import (
+	"strings"
+)
+
+// DefaultMask is a default value which replaces masked string fields.
+const DefaultMask = "***"
+
+// SetMaskedFields declares fields which are masked in data items returned by the persistence, so sensitive
+// values, e.g. SSNs or tokens, are hidden from consumers without changing the stored data. Masked string
+// values are replaced by the mask set by options.mask, and values of other types by nulls, so they still
+// fit fields of the data items. Fields can be masked by options.masked_fields as well.
+// Masked items shall not be written back, because the masks would replace the stored values.
+//
+//	Example:
+//		c.SetMaskedFields([]string{"ssn", "cards.*.number"})
+//
+//	Parameters:
+//		- fields field names or dot-separated paths of nested fields, where * matches all items
+//		  of arrays and all fields of objects. Paths of JSON persistences start from fields of the documents.
+func (c *PostgresPersistence[T]) SetMaskedFields(fields []string) {
+	c.maskedFields = make([][]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			c.maskedFields = append(c.maskedFields, strings.Split(field, "."))
+		}
+	}
+}
+
+// maskFields replaces values of the masked fields in the item map converted from a row.
+func (c *PostgresPersistence[T]) maskFields(item map[string]any) {
+	for _, path := range c.maskedFields {
+		maskValue(item, path, c.mask)
+	}
+}
+
+// maskValue replaces values at the path of the value. Missing fields are ignored.
+func maskValue(value any, path []string, mask string) any {
+	if len(path) == 0 {
+		if _, ok := value.(string); ok {
+			return mask
+		}
+		return nil
+	}
+
+	key, rest := path[0], path[1:]
+	switch nested := value.(type) {
+	case map[string]any:
+		if key == "*" {
+			for name, field := range nested {
+				nested[name] = maskValue(field, rest, mask)
+			}
+		} else if field, ok := nested[key]; ok && field != nil {
+			nested[key] = maskValue(field, rest, mask)
+		}
+	case []any:
+		if key == "*" {
+			for index, element := range nested {
+				nested[index] = maskValue(element, rest, mask)
+			}
+		}
+	}
+	return value
+}
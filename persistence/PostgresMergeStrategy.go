@@ -0,0 +1,55 @@
+package persistence
+
+import (
+	"reflect"
+)
+
+// Strategies to merge items with stored rows used by Set and Update operations.
+const (
+	// MergeStrategyReplace overwrites all columns with values of the item.
+	MergeStrategyReplace = "replace"
+	// MergeStrategyIgnoreNulls keeps stored values of columns which are null in the item.
+	MergeStrategyIgnoreNulls = "ignore_nulls"
+	// MergeStrategyIgnoreEmpty keeps stored values of columns which are null or have zero values in the item,
+	// e.g. empty strings, zero numbers, false or empty lists.
+	MergeStrategyIgnoreEmpty = "ignore_empty"
+)
+
+// applyMergeStrategy removes columns ignored by options.merge_strategy from the object map,
+// so they are left out of the SET lists of updates and get column defaults in inserted rows.
+// The id and columns of the conflict target are always kept.
+func (c *PostgresPersistence[T]) applyMergeStrategy(objMap map[string]any) map[string]any {
+	if c.mergeStrategy != MergeStrategyIgnoreNulls && c.mergeStrategy != MergeStrategyIgnoreEmpty {
+		return objMap
+	}
+
+	kept := make(map[string]bool, len(c.conflictTarget)+1)
+	kept[c.NamingStrategy.ColumnName(c.idColumn)] = true
+	for _, column := range c.conflictTarget {
+		kept[c.NamingStrategy.ColumnName(column)] = true
+	}
+
+	for column, value := range objMap {
+		if kept[column] {
+			continue
+		}
+		if value == nil || (c.mergeStrategy == MergeStrategyIgnoreEmpty && isEmptyValue(value)) {
+			delete(objMap, column)
+		}
+	}
+	return objMap
+}
+
+// isEmptyValue checks if the value is a zero value or an empty string, slice or map.
+func isEmptyValue(value any) bool {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
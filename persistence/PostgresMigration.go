@@ -0,0 +1,85 @@
+package persistence
+
+import (
+	"context"
+
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+)
+
+// DefaultMigrationBatchSize is a default number of data items copied by MigrateItems at once.
+const DefaultMigrationBatchSize = 100
+
+// PostgresMigrationProgress reports progress of MigrateItems after every copied batch.
+type PostgresMigrationProgress struct {
+	// Number of data items copied so far.
+	Migrated int64
+	// Number of data items in the source table when the migration started.
+	Total int64
+}
+
+// MigrateItems copies data items from the source persistence into the target one by batches, e.g. from
+// a relational table into a JSON one when the storage model changes, or back. Items are read in the order
+// of their ids, so each batch is found by an index without skipping previous rows, and saved by SetMany,
+// so the migration can be repeated after a failure. Interceptors of the target are called for saved items.
+// The embedded persistences are passed, e.g. &c.IdentifiablePostgresPersistence of a relational persistence
+// or c.IdentifiablePostgresPersistence of a JSON one. Both must be opened.
+//
+//	Example:
+//		count, err := persist.MigrateItems(ctx, correlationId, &relational.IdentifiablePostgresPersistence,
+//			json.IdentifiablePostgresPersistence, 500, func(progress persist.PostgresMigrationProgress) {
+//				logger.Info(ctx, correlationId, "Migrated %d of %d items", progress.Migrated, progress.Total)
+//			})
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- source            a persistence to read data items from.
+//		- target            a persistence to write data items into.
+//		- batchSize         (optional) a number of items copied at once, limited by the max page size of the source (default: 100)
+//		- progress          (optional) a function called after every copied batch.
+//	Returns: number of copied data items or error.
+func MigrateItems[T any, K any](ctx context.Context, correlationId string,
+	source *IdentifiablePostgresPersistence[T, K], target *IdentifiablePostgresPersistence[T, K],
+	batchSize int, progress func(progress PostgresMigrationProgress)) (int64, error) {
+
+	if batchSize <= 0 {
+		batchSize = DefaultMigrationBatchSize
+	}
+	total, err := source.GetCountByFilter(ctx, correlationId, "")
+	if err != nil {
+		return 0, err
+	}
+
+	var migrated int64
+	var lastId any
+	paging := *cdata.NewPagingParams(0, int64(batchSize), false)
+	for {
+		if err := source.checkInterrupted(ctx, correlationId); err != nil {
+			return migrated, err
+		}
+
+		filter, params := "", []any{}
+		if lastId != nil {
+			filter, params = source.quotedIdColumn()+">$1", []any{lastId}
+		}
+		page, err := source.GetPageByFilterWithParams(ctx, correlationId, filter, params, paging, source.quotedIdColumn(), "")
+		if err != nil {
+			return migrated, err
+		}
+		if len(page.Data) == 0 {
+			break
+		}
+
+		if _, err = target.SetMany(ctx, correlationId, page.Data); err != nil {
+			return migrated, err
+		}
+		migrated += int64(len(page.Data))
+		lastId = GetObjectId[K](page.Data[len(page.Data)-1])
+		if progress != nil {
+			progress(PostgresMigrationProgress{Migrated: migrated, Total: total})
+		}
+	}
+
+	target.Logger.Info(ctx, correlationId, "Migrated %d items from %s into %s", migrated, source.TableName, target.TableName)
+	return migrated, nil
+}
@@ -0,0 +1,106 @@
+package persistence
+
+import (
+	"strings"
+	"unicode"
+)
+
+// INamingStrategy converts names of data item fields into table column names and back.
+// Conversions must be idempotent: converting a column name once more must not change it.
+type INamingStrategy interface {
+	// ColumnName converts a field name into a column name.
+	ColumnName(field string) string
+	// FieldName converts a column name into a field name.
+	FieldName(column string) string
+}
+
+// NewNamingStrategy creates a naming strategy by its name.
+//
+//	Parameters:
+//		- name a strategy name: as_is, snake_case or camel_case. Unknown names create as_is strategy.
+//	Returns: INamingStrategy
+func NewNamingStrategy(name string) INamingStrategy {
+	switch strings.ToLower(strings.ReplaceAll(name, "-", "_")) {
+	case "snake_case", "snake":
+		return &SnakeCaseNamingStrategy{}
+	case "camel_case", "camel":
+		return &CamelCaseNamingStrategy{}
+	}
+	return &AsIsNamingStrategy{}
+}
+
+// AsIsNamingStrategy keeps field names as column names without changes.
+type AsIsNamingStrategy struct{}
+
+// ColumnName returns the field name without changes.
+func (c *AsIsNamingStrategy) ColumnName(field string) string {
+	return field
+}
+
+// FieldName returns the column name without changes.
+func (c *AsIsNamingStrategy) FieldName(column string) string {
+	return column
+}
+
+// SnakeCaseNamingStrategy stores fields in lower snake_case columns, e.g. createdAt in created_at.
+// Column names are converted back into lower camelCase field names.
+type SnakeCaseNamingStrategy struct{}
+
+// ColumnName converts the field name into lower snake_case.
+func (c *SnakeCaseNamingStrategy) ColumnName(field string) string {
+	return toSnakeCase(field)
+}
+
+// FieldName converts the column name into lower camelCase.
+func (c *SnakeCaseNamingStrategy) FieldName(column string) string {
+	return toCamelCase(column)
+}
+
+// CamelCaseNamingStrategy stores fields in lower camelCase columns, e.g. created_at in createdAt.
+type CamelCaseNamingStrategy struct{}
+
+// ColumnName converts the field name into lower camelCase.
+func (c *CamelCaseNamingStrategy) ColumnName(field string) string {
+	return toCamelCase(field)
+}
+
+// FieldName returns the column name without changes.
+func (c *CamelCaseNamingStrategy) FieldName(column string) string {
+	return column
+}
+
+// toSnakeCase converts a name into lower snake_case, e.g. userID into user_id.
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	builder := strings.Builder{}
+	for index, r := range runes {
+		if unicode.IsUpper(r) && index > 0 {
+			prev := runes[index-1]
+			nextIsLower := index+1 < len(runes) && unicode.IsLower(runes[index+1])
+			if prev != '_' && (unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower)) {
+				builder.WriteRune('_')
+			}
+		}
+		builder.WriteRune(unicode.ToLower(r))
+	}
+	return builder.String()
+}
+
+// toCamelCase converts a name into lower camelCase, e.g. created_at into createdAt.
+func toCamelCase(name string) string {
+	parts := strings.Split(name, "_")
+	builder := strings.Builder{}
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		runes := []rune(part)
+		if builder.Len() == 0 {
+			runes[0] = unicode.ToLower(runes[0])
+		} else {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		builder.WriteString(string(runes))
+	}
+	return builder.String()
+}
@@ -0,0 +1,159 @@
+package persistence
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+)
+
+// nestedField is a value of a nested field set in a JSONB column.
+type nestedField struct {
+	path  []string
+	value any
+}
+
+// splitNestedFields separates fields with dotted paths, e.g. address.city, from plain fields.
+//
+//	Returns: plain fields and fields with nested paths.
+func splitNestedFields(fields map[string]any) (map[string]any, map[string]any) {
+	plain := make(map[string]any, len(fields))
+	nested := make(map[string]any)
+	for field, value := range fields {
+		if strings.Contains(field, ".") {
+			nested[field] = value
+		} else {
+			plain[field] = value
+		}
+	}
+	return plain, nested
+}
+
+// composeNestedUpdate builds an UPDATE statement which sets plain columns as composeUpdate does
+// and nested fields of JSONB columns with jsonb_set. The first segment of a dotted path is a field
+// converted into a column name, the rest are names of nested JSON fields. Missing intermediate
+// objects are created. The statement is not cached, because it depends on the updated paths.
+//
+//	Returns: the statement, its parameters, true if the version is checked or an error
+//	when a column is updated both as a whole and by nested paths.
+func (c *IdentifiablePostgresPersistence[T, K]) composeNestedUpdate(correlationId string, objMap map[string]any,
+	nested map[string]any, id any) (string, []any, bool, error) {
+
+	var version any
+	checked := false
+	if c.versionColumn != "" {
+		version, checked = objMap[c.versionColumn]
+		checked = checked && version != nil
+		delete(objMap, c.versionColumn)
+	}
+
+	columns, values := c.GenerateColumnsAndValues(objMap)
+	sets := make([]string, 0, len(columns)+1)
+	if len(columns) > 0 {
+		sets = append(sets, c.GenerateSetParameters(columns))
+	}
+
+	placeholder := func(value any, cast string) string {
+		values = append(values, value)
+		return "$" + strconv.Itoa(len(values)) + "::" + cast
+	}
+
+	fields := make([]string, 0, len(nested))
+	for field := range nested {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	// Group paths by columns keeping shorter paths first
+	paths := make(map[string][]nestedField)
+	pathColumns := make([]string, 0)
+	for _, field := range fields {
+		segments := strings.Split(field, ".")
+		column := c.NamingStrategy.ColumnName(segments[0])
+		if _, ok := objMap[column]; ok {
+			return "", nil, false, cerr.NewBadRequestError(correlationId, "CONFLICTING_FIELDS",
+				"Column "+column+" is updated both as a whole and by nested paths").
+				WithDetails("field", field)
+		}
+		if _, ok := paths[column]; !ok {
+			pathColumns = append(pathColumns, column)
+		}
+		paths[column] = append(paths[column], nestedField{path: segments[1:], value: nested[field]})
+	}
+
+	for _, column := range pathColumns {
+		quoted := c.quoteColumn(column)
+		expr, err := composeJsonbSet(quoted, paths[column], placeholder)
+		if err != nil {
+			return "", nil, false, err
+		}
+		sets = append(sets, quoted+"="+expr)
+	}
+
+	if c.versionColumn != "" {
+		quotedVersion := c.QuoteIdentifier(c.versionColumn)
+		sets = append(sets, quotedVersion+"="+quotedVersion+"+1")
+	}
+
+	values = append(values, id)
+	query := "UPDATE " + c.QuotedTableName() + " SET " + strings.Join(sets, ",") +
+		" WHERE " + c.quotedIdColumn() + "=$" + strconv.Itoa(len(values))
+	if checked {
+		values = append(values, version)
+		query += " AND " + c.QuoteIdentifier(c.versionColumn) + "=$" + strconv.Itoa(len(values))
+	}
+	return query + " RETURNING *", values, checked, nil
+}
+
+// composeJsonbSet returns an expression which sets the nested fields in the JSONB column with jsonb_set.
+// Missing intermediate objects are created. Values are added as parameters by the placeholder function.
+func composeJsonbSet(quoted string, fields []nestedField, placeholder func(value any, cast string) string) (string, error) {
+	expr := "COALESCE(" + quoted + ",'{}'::jsonb)"
+
+	// Create missing intermediate objects before nested fields are set
+	ensured := make(map[string]bool)
+	for _, field := range fields {
+		path := field.path
+		for depth := 1; depth < len(path); depth++ {
+			key := strings.Join(path[:depth], ".")
+			if ensured[key] {
+				continue
+			}
+			ensured[key] = true
+			prefix := placeholder(path[:depth], "text[]")
+			expr = "jsonb_set(" + expr + "," + prefix + ",COALESCE(" + quoted + "#>" + prefix + ",'{}'::jsonb))"
+		}
+	}
+
+	for _, field := range fields {
+		buf, toJsonErr := cconv.JsonConverter.ToJson(field.value)
+		if toJsonErr != nil {
+			return "", toJsonErr
+		}
+		expr = "jsonb_set(" + expr + "," + placeholder(field.path, "text[]") + "," + placeholder(buf, "jsonb") + ",true)"
+	}
+	return expr, nil
+}
+
+// flattenNestedFields converts nested objects of the map into fields with paths of their leaf values,
+// so they can be merged into JSONB documents without replacing sibling fields.
+// Arrays and other values are leaves, empty objects don't change anything.
+func flattenNestedFields(prefix []string, fields map[string]any, result []nestedField) []nestedField {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path := append(append(make([]string, 0, len(prefix)+1), prefix...), key)
+		if nested, ok := fields[key].(map[string]any); ok {
+			result = flattenNestedFields(path, nested, result)
+			continue
+		}
+		result = append(result, nestedField{path: path, value: fields[key]})
+	}
+	return result
+}
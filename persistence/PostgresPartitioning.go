@@ -0,0 +1,81 @@
+package persistence
+
+import (
+	"strconv"
+)
+
+// partitionName returns a name of the hash partition of the table with the remainder.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) partitionName(remainder int) string {
+	return c.TableName + "_p" + strconv.Itoa(remainder)
+}
+
+// quotedPartitionName returns the quoted name of the hash partition qualified with the schema like the table.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) quotedPartitionName(remainder int) string {
+	// Partitions of temporary tables are temporary as well and can't be qualified
+	if c.tableType == TableTypeTemporary {
+		return c.QuoteIdentifier(c.partitionName(remainder))
+	}
+	return c.quotedSchemaObjectName(c.partitionName(remainder))
+}
+
+// composePartitionedTable returns a CREATE TABLE statement of the table with the column definitions,
+// which is hash-partitioned by the id column when options.partitions is set. Partitioned tables
+// can't be unlogged and have no storage of their own, so the table type and storage parameters
+// apply to partitions, which are created by partitionStatements.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) composePartitionedTable(definitions string) string {
+	if c.partitions <= 0 {
+		return c.CreateTableClause() + " (" + definitions + ")" + c.TableStorageClause()
+	}
+
+	clause := "CREATE TABLE IF NOT EXISTS " + c.QuotedTableName()
+	if c.tableType == TableTypeTemporary {
+		clause = c.CreateTableClause()
+	}
+	clause += " (" + definitions + ") PARTITION BY HASH (" + c.quotedIdColumn() + ")"
+	if c.tablespace != "" {
+		clause += " TABLESPACE " + c.QuoteIdentifier(c.tablespace)
+	}
+	return clause
+}
+
+// partitionStatements returns statements which create hash partitions of the table set by options.partitions.
+// The number of partitions can't be changed for existing tables, as rows are distributed by the modulus.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) partitionStatements() []string {
+	statements := make([]string, 0, c.partitions)
+	clause := "CREATE TABLE IF NOT EXISTS "
+	switch c.tableType {
+	case TableTypeUnlogged:
+		clause = "CREATE UNLOGGED TABLE IF NOT EXISTS "
+	case TableTypeTemporary:
+		clause = "CREATE TEMPORARY TABLE IF NOT EXISTS "
+	}
+	for remainder := 0; remainder < c.partitions; remainder++ {
+		statements = append(statements, clause+c.quotedPartitionName(remainder)+
+			" PARTITION OF "+c.QuotedTableName()+
+			" FOR VALUES WITH (MODULUS "+strconv.Itoa(c.partitions)+", REMAINDER "+strconv.Itoa(remainder)+")"+
+			c.TableStorageClause())
+	}
+	return statements
+}
+
+// toastTables returns quoted names of tables which keep data of the table and receive its TOAST tuple target.
+// Partitioned tables have no storage, so the target is set for their partitions.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) toastTables() []string {
+	if c.partitions <= 0 {
+		return []string{c.QuotedTableName()}
+	}
+	tables := make([]string, 0, c.partitions)
+	for remainder := 0; remainder < c.partitions; remainder++ {
+		tables = append(tables, c.quotedPartitionName(remainder))
+	}
+	return tables
+}
+
+// composeTableRelations returns a subquery of oids of the table, or of partitions of a partitioned table,
+// for the table name in the given parameter, so statistics of partitioned tables are collected from their partitions.
+func (c *PostgresPersistence[T]) composeTableRelations(placeholder string) string {
+	table := "to_regclass(" + placeholder + ")"
+	return "SELECT \"oid\" FROM pg_class WHERE \"oid\"=" + table + " AND relkind<>'p'" +
+		" UNION ALL SELECT i.inhrelid FROM pg_inherits i JOIN pg_class p ON p.\"oid\"=i.inhparent" +
+		" WHERE p.\"oid\"=" + table + " AND p.relkind='p'"
+}
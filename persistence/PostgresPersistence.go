@@ -3,21 +3,27 @@ package persistence
 import (
 	"context"
 	"errors"
+	"hash/fnv"
 	"math/rand"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 
-	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/jackc/pgx/v5/pgxpool"
 	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
 	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
 	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
 	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
 	cref "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	ccount "github.com/pip-services3-gox/pip-services3-components-gox/count"
 	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
-	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/connect"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
 )
 
 type IPostgresPersistenceOverrides[T any] interface {
@@ -50,9 +56,41 @@ type IPostgresPersistenceOverrides[T any] interface {
 //			- connect_timeout:      (optional) number of milliseconds to wait before timing out when connecting a new client (default: 0)
 //			- idle_timeout:         (optional) number of milliseconds a client must sit idle in the pool and not be checked out (default: 10000)
 //			- max_pool_size:        (optional) maximum number of clients the pool should contain (default: 10)
+//			- auto_reconnect:       (optional) enables automatic reconnection when connection is lost (default: true)
+//			- lazy_open:            (optional) defers connection and schema creation until the first operation (default: false)
+//			- auto_create_table:    (optional) creates the table from struct tags of the data type when DefineSchema is not overridden, see EnsureTableFromStruct (default: false)
+//			- infer_schema:         (optional) creates the table of map persistences on the first write and adds columns of new fields
+//			                        with types derived from their values on writes except batches, see EnsureTableFromTypes (default: false)
+//			- naming_strategy:      (optional) converts field names into column names: as_is, snake_case or camel_case (default: as_is)
+//			- use_search_path:      (optional) sets search_path to the schema for every operation instead of qualifying names with it,
+//			                        so custom SQL in child classes can use unqualified names (default: false)
+//			- table_type:           (optional) type of tables created by EnsureTable and EnsureTableFromStruct: logged, unlogged or temporary, see CreateTableClause (default: logged)
+//			- storage:              (optional) storage parameters of tables created by EnsureTable and EnsureTableFromStruct, e.g. storage.fillfactor=70, see TableStorageClause
+//			- tablespace:           (optional) tablespace of tables created by EnsureTable and EnsureTableFromStruct
+//			- collation:            (optional) collation of text columns created by EnsureTableFromStruct, e.g. C or und-x-icu
+//			- deleted_column:       (optional) a nullable column which marks soft-deleted items, cleared by RestoreByFilter
+//			- tenant_column:        (optional) a column which assigns items to tenants. Created items get the tenant set by
+//			                        NewContextWithTenant and other operations see only items of the tenant, while operations
+//			                        without a tenant in the context fail. Custom SQL of ExecuteQuery and ExecuteNonQuery
+//			                        is not restricted and batches are not supported
+//			- masked_fields:        (optional) a comma-separated list of fields masked in returned items, see SetMaskedFields
+//			- mask:                 (optional) a value which replaces masked string fields (default: ***)
+//			- schema_validation:    (optional) compares the existing table with the declared schema: none, warn to log differences or strict to fail opening, see ValidateSchema (default: none)
+//			- debug:                (optional) writes driver-level query logs into the logger (default: false)
+//			- approximate_total:    (optional) estimates totals of data pages from table statistics instead of counting all rows (default: false)
+//			- approximate_total_threshold: (optional) estimated totals below this number are counted exactly (default: 10000)
+//			- tag_sessions:         (optional) sets application_name to the correlationId of every operation to trace queries in pg_stat_activity (default: false)
+//			- random_method:        (optional) selects random items by GetOneRandom with offset, order or sample method, see RandomMethodOffset (default: offset)
+//			- random_sample_percent: (optional) percentage of table pages sampled by the sample random method (default: 1)
+//			- max_page_size:        (optional) maximum number of items returned in a page, can be overridden per call with NewContextWithMaxPageSize (default: 100)
+//			- query_timeout:        (optional) number of milliseconds after which a query is cancelled, 0 to wait indefinitely (default: 0)
+//			- max_retries:          (optional) number of retries of idempotent operations failed with serialization failures, deadlocks or lost connections (default: 0)
+//			- retry_timeout:        (optional) number of milliseconds before the first retry, doubled for each next one (default: 100)
+//			- max_batch_size:       (optional) maximum number of items inserted by a single statement in bulk operations (default: 1000)
 //
 //	References:
 //		- *:logger:*:*:1.0           (optional) ILogger components to pass log messages
+//		- *:counters:*:*:1.0         (optional) ICounters components to pass collected measurements
 //		- *:discovery:*:*:1.0        (optional) IDiscovery services
 //		- *:credential-store:*:*:1.0 (optional) Credential stores to resolve credentials
 type PostgresPersistence[T any] struct {
@@ -68,11 +106,72 @@ type PostgresPersistence[T any] struct {
 	opened           bool
 	localConnection  bool
 	schemaStatements []string
+	updateStatements []string
+	declaredColumns  []declaredColumn
+	declaredIndexes  []string
+	declaredTable    bool
+	schemaValidation string
+	tableType        string
+	useSearchPath    bool
+	tableStorage     map[string]string
+	tablespace       string
+	dataStorage      string
+	dataCompression  string
+	toastTupleTarget int
+	idSequence       string
+	serverIds        bool
+	integerIds       bool
+	versionColumn    string
+	deletedColumn    string
+	conflictTarget   []string
+	conflictAction   string
+	conflictUpdate   []string
+	mergeStrategy    string
+	skipReturning    bool
+	deepMerge        bool
+	maskedFields     [][]string
+	uniqueKeys       map[string][]string
+	mask             string
+	tenantColumn     string
+	randomMethod     string
+	samplePercent    float64
+	collation        string
+	joins            []PostgresJoin
+	interceptors     []IPostgresInterceptor[T]
+	lazyOpen         bool
+	autoCreateTable  bool
+	inferSchema      bool
+	tagSessions      bool
+	queryTimeout     time.Duration
+	maxRetries       int
+	retryTimeout     time.Duration
+	approximateTotal bool
+	idColumn         string
+	idType           string
+	jsonColumn       string
+	plainJson        bool
+	searchColumn     string
+	searchConfig     string
+	searchDocument   string
+	sortTypes        map[string]string
+	extractedColumns map[string]extractedColumn
+	columnsLock      sync.Mutex
+	tableColumns     map[string]bool
+	statements       sync.Map
+	openPending      int32
+	openLock         sync.Mutex
+
+	approximateTotalThreshold int
+	correlationSetting        string
 
 	//The dependency resolver.
 	DependencyResolver *cref.DependencyResolver
 	//The logger.
 	Logger *clog.CompositeLogger
+	//The strategy to convert field names into column names.
+	NamingStrategy INamingStrategy
+	//The performance counters.
+	Counters *ccount.CompositeCounters
 	//The PostgreSQL connection component.
 	Connection *conn.PostgresConnection
 	//The PostgreSQL connection pool object.
@@ -82,16 +181,25 @@ type PostgresPersistence[T any] struct {
 	//The PostgreSQL database schema name. If not set use "public" by default
 	SchemaName string
 	//The PostgreSQL table object.
-	TableName   string
-	MaxPageSize int
+	TableName    string
+	MaxPageSize  int
+	MaxBatchSize int
 
-	// Defines channel which closed before closing persistence and signals about terminating
+	// Defines context which is cancelled before closing persistence and signals about terminating
 	// all going processes
 	//	!IMPORTANT if you do not Close existing query response the persistence can not be closed
 	//	see IsTerminated method
-	isTerminated chan struct{}
+	isTerminated  context.Context
+	terminate     context.CancelFunc
+	terminateLock sync.Mutex
 }
 
+// DefaultApproximateTotalThreshold is a number of items below which estimated totals are counted exactly.
+const DefaultApproximateTotalThreshold = 10000
+
+// maxStatementParameters is a maximum number of bound parameters PostgreSQL accepts in a single statement.
+const maxStatementParameters = 65535
+
 // InheritPostgresPersistence creates a new instance of the persistence component.
 //
 //	Parameters:
@@ -109,16 +217,27 @@ func InheritPostgresPersistence[T any](overrides IPostgresPersistenceOverrides[T
 			"options.connect_timeout", 5000,
 			"options.auto_reconnect", true,
 			"options.max_page_size", 100,
-			"options.debug", true,
+			"options.max_batch_size", 1000,
+			"options.debug", false,
 		),
 		schemaStatements: make([]string, 0),
+		updateStatements: make([]string, 0),
 		Logger:           clog.NewCompositeLogger(),
+		Counters:         ccount.NewCompositeCounters(),
+		NamingStrategy:   &AsIsNamingStrategy{},
+		maxRetries:       DefaultMaxRetries,
+		retryTimeout:     DefaultRetryTimeout,
 		MaxPageSize:      100,
+		MaxBatchSize:     1000,
 		TableName:        tableName,
+		idColumn:         "id",
+		mask:             DefaultMask,
 		JsonConvertor:    cconv.NewDefaultCustomTypeJsonConvertor[T](),
 		JsonMapConvertor: cconv.NewDefaultCustomTypeJsonConvertor[map[string]any](),
-		isTerminated:     make(chan struct{}),
+
+		approximateTotalThreshold: DefaultApproximateTotalThreshold,
 	}
+	c.isTerminated, c.terminate = context.WithCancel(context.Background())
 
 	c.DependencyResolver = cref.NewDependencyResolver()
 	c.DependencyResolver.Configure(context.Background(), c.defaultConfig)
@@ -140,7 +259,53 @@ func (c *PostgresPersistence[T]) Configure(ctx context.Context, config *cconf.Co
 	c.TableName = config.GetAsStringWithDefault("collection", c.TableName)
 	c.TableName = config.GetAsStringWithDefault("table", c.TableName)
 	c.MaxPageSize = config.GetAsIntegerWithDefault("options.max_page_size", c.MaxPageSize)
+	c.MaxBatchSize = config.GetAsIntegerWithDefault("options.max_batch_size", c.MaxBatchSize)
 	c.SchemaName = config.GetAsStringWithDefault("schema", c.SchemaName)
+	c.lazyOpen = config.GetAsBooleanWithDefault("options.lazy_open", c.lazyOpen)
+	c.autoCreateTable = config.GetAsBooleanWithDefault("options.auto_create_table", c.autoCreateTable)
+	c.inferSchema = config.GetAsBooleanWithDefault("options.infer_schema", c.inferSchema)
+	c.tableType = strings.ToLower(config.GetAsStringWithDefault("options.table_type", c.tableType))
+	c.useSearchPath = config.GetAsBooleanWithDefault("options.use_search_path", c.useSearchPath)
+	if storage := config.GetSection("options.storage"); storage.Len() > 0 {
+		c.tableStorage = storage.Value()
+	}
+	c.tablespace = config.GetAsStringWithDefault("options.tablespace", c.tablespace)
+	c.dataStorage = strings.ToLower(config.GetAsStringWithDefault("options.data_storage", c.dataStorage))
+	c.dataCompression = strings.ToLower(config.GetAsStringWithDefault("options.data_compression", c.dataCompression))
+	c.toastTupleTarget = config.GetAsIntegerWithDefault("options.toast_tuple_target", c.toastTupleTarget)
+	c.idSequence = config.GetAsStringWithDefault("options.id_sequence", c.idSequence)
+	c.serverIds = config.GetAsBooleanWithDefault("options.server_ids", c.serverIds)
+	c.versionColumn = config.GetAsStringWithDefault("options.version_column", c.versionColumn)
+	c.deletedColumn = config.GetAsStringWithDefault("options.deleted_column", c.deletedColumn)
+	if target, ok := config.GetAsNullableString("options.conflict_target"); ok {
+		c.conflictTarget = splitConflictList(target)
+	}
+	c.conflictAction = strings.ToLower(config.GetAsStringWithDefault("options.conflict_action", c.conflictAction))
+	if columns, ok := config.GetAsNullableString("options.conflict_update_columns"); ok {
+		c.conflictUpdate = splitConflictList(columns)
+	}
+	c.mergeStrategy = strings.ToLower(config.GetAsStringWithDefault("options.merge_strategy", c.mergeStrategy))
+	c.skipReturning = config.GetAsBooleanWithDefault("options.skip_returning", c.skipReturning)
+	c.deepMerge = config.GetAsBooleanWithDefault("options.deep_merge", c.deepMerge)
+	c.tenantColumn = config.GetAsStringWithDefault("options.tenant_column", c.tenantColumn)
+	if fields, ok := config.GetAsNullableString("options.masked_fields"); ok {
+		c.SetMaskedFields(strings.Split(fields, ","))
+	}
+	c.mask = config.GetAsStringWithDefault("options.mask", c.mask)
+	c.collation = config.GetAsStringWithDefault("options.collation", c.collation)
+	c.schemaValidation = strings.ToLower(config.GetAsStringWithDefault("options.schema_validation", c.schemaValidation))
+	if strategy, ok := config.GetAsNullableString("options.naming_strategy"); ok {
+		c.NamingStrategy = NewNamingStrategy(strategy)
+	}
+	c.resetStatements()
+	c.tagSessions = config.GetAsBooleanWithDefault("options.tag_sessions", c.tagSessions)
+	c.maxRetries = config.GetAsIntegerWithDefault("options.max_retries", c.maxRetries)
+	c.retryTimeout = time.Duration(config.GetAsIntegerWithDefault("options.retry_timeout", int(c.retryTimeout.Milliseconds()))) * time.Millisecond
+	c.queryTimeout = time.Duration(config.GetAsIntegerWithDefault("options.query_timeout", int(c.queryTimeout.Milliseconds()))) * time.Millisecond
+	c.approximateTotal = config.GetAsBooleanWithDefault("options.approximate_total", c.approximateTotal)
+	c.randomMethod = strings.ToLower(config.GetAsStringWithDefault("options.random_method", c.randomMethod))
+	c.samplePercent = config.GetAsDoubleWithDefault("options.random_sample_percent", c.samplePercent)
+	c.approximateTotalThreshold = config.GetAsIntegerWithDefault("options.approximate_total_threshold", c.approximateTotalThreshold)
 }
 
 // SetReferences to dependent components.
@@ -152,6 +317,7 @@ func (c *PostgresPersistence[T]) SetReferences(ctx context.Context, references c
 
 	c.references = references
 	c.Logger.SetReferences(ctx, references)
+	c.Counters.SetReferences(ctx, references)
 
 	// Get connection
 	c.DependencyResolver.SetReferences(ctx, references)
@@ -185,46 +351,98 @@ func (c *PostgresPersistence[T]) createConnection(ctx context.Context) *conn.Pos
 	return connection
 }
 
-// EnsureIndex adds index definition to create it on opening
+// EnsureIndex adds index definition to create it on opening.
+// Keys are sorted by name, use EnsureIndexWithKeys when the order of keys matters.
 //
 //	Parameters:
-//		- keys index keys (fields)
-//		- options index options
+//		- keys index keys (fields) mapped to "1" for ascending or any other value for descending order.
+//		  A key is a column name or an expression in parentheses.
+//		- options index options:
+//			- unique: true to create a unique index
+//			- type: (optional) index method: btree, hash, gist, spgist, gin or brin
+//			- include: (optional) comma-separated columns to include into the index
+//			- where: (optional) a predicate to create a partial index
+//			- with: (optional) comma-separated storage parameters, e.g. fillfactor=70
+//			- tablespace: (optional) a tablespace to create the index in
 func (c *PostgresPersistence[T]) EnsureIndex(name string, keys map[string]string, options map[string]string) {
+	names := make([]string, 0, len(keys))
+	for key := range keys {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	indexKeys := make([]PostgresIndexKey, 0, len(names))
+	for _, key := range names {
+		indexKeys = append(indexKeys, PostgresIndexKey{Name: key, Descending: keys[key] != "1"})
+	}
+	c.EnsureIndexWithKeys(name, indexKeys, options)
+}
+
+// EnsureIndexWithKeys adds index definition with ordered keys to create it on opening.
+//
+//	Parameters:
+//		- name an index name.
+//		- keys index keys in the order they are indexed.
+//		- options index options, see EnsureIndex.
+func (c *PostgresPersistence[T]) EnsureIndexWithKeys(name string, keys []PostgresIndexKey, options map[string]string) {
 	builder := "CREATE"
 	if options == nil {
 		options = make(map[string]string, 0)
 	}
 
-	if options["unique"] != "" {
+	if cconv.BooleanConverter.ToBoolean(options["unique"]) {
 		builder += " UNIQUE"
 	}
 
-	indexName := c.QuoteIdentifier(name)
+	builder += " INDEX IF NOT EXISTS " + c.QuoteIdentifier(name) + " ON " + c.QuotedTableName()
 
-	builder += " INDEX IF NOT EXISTS " + indexName + " ON " + c.QuotedTableName()
-
-	if options["type"] != "" {
-		builder += " " + options["type"]
+	if method := composeIndexMethod(options["type"]); method != "" {
+		builder += " USING " + method
 	}
 
-	fields := ""
-	for key, _ := range keys {
-		if fields != "" {
-			fields += ", "
+	fields := make([]string, 0, len(keys))
+	for _, key := range keys {
+		field := c.composeIndexKey(key.Name)
+		if key.Descending {
+			field += " DESC"
 		}
-		fields += key
-		asc := keys[key]
-		if asc != "1" {
-			fields += " DESC"
+		fields = append(fields, field)
+	}
+	builder += " (" + strings.Join(fields, ", ") + ")"
+
+	if include := options["include"]; include != "" {
+		columns := make([]string, 0)
+		for _, column := range strings.Split(include, ",") {
+			if column = strings.TrimSpace(column); column != "" {
+				columns = append(columns, c.quoteColumn(column))
+			}
 		}
+		builder += " INCLUDE (" + strings.Join(columns, ", ") + ")"
+	}
+
+	if with := options["with"]; with != "" {
+		builder += " WITH (" + with + ")"
+	}
+	if tablespace := options["tablespace"]; tablespace != "" {
+		builder += " TABLESPACE " + c.QuoteIdentifier(tablespace)
 	}
 
-	builder += "(" + fields + ")"
+	if where := options["where"]; where != "" {
+		builder += " WHERE " + where
+	}
 
+	c.declaredIndexes = append(c.declaredIndexes, name)
 	c.EnsureSchema(builder)
 }
 
+// composeIndexKey quotes a column name of the index key. Expressions are used as is.
+func (c *PostgresPersistence[T]) composeIndexKey(key string) string {
+	if indexIdentifierPattern.MatchString(key) {
+		return c.quoteColumn(key)
+	}
+	return key
+}
+
 // DefineSchema a database schema for this persistence, have to call in child class
 func (c *PostgresPersistence[T]) DefineSchema() {
 	// Override in child classes
@@ -232,6 +450,9 @@ func (c *PostgresPersistence[T]) DefineSchema() {
 	if len(c.SchemaName) > 0 {
 		c.EnsureSchema("CREATE SCHEMA IF NOT EXISTS " + c.QuoteIdentifier(c.SchemaName))
 	}
+	if c.autoCreateTable && c.jsonColumn == "" {
+		c.EnsureTableFromStruct()
+	}
 }
 
 // EnsureSchema adds a statement to schema definition
@@ -245,6 +466,167 @@ func (c *PostgresPersistence[T]) EnsureSchema(schemaStatement string) {
 // ClearSchema clears all auto-created objects
 func (c *PostgresPersistence[T]) ClearSchema() {
 	c.schemaStatements = []string{}
+	c.updateStatements = []string{}
+	c.declaredColumns = nil
+	c.declaredIndexes = nil
+	c.declaredTable = false
+	c.extractedColumns = nil
+	c.uniqueKeys = nil
+}
+
+// EnsureColumn adds a column definition to add it to the existing table on opening.
+// Unlike other schema statements it is executed every time the persistence is opened,
+// so new columns appear in all environments without manual migrations.
+//
+//	Parameters:
+//		- name a column name.
+//		- pgType a PostgreSQL type of the column, e.g. TEXT or TIMESTAMP WITH TIME ZONE.
+//		- defaultValue (optional) an SQL expression of the default value, e.g. 'text', 0 or now().
+func (c *PostgresPersistence[T]) EnsureColumn(name string, pgType string, defaultValue string) {
+	statement := "ALTER TABLE " + c.QuotedTableName() + " ADD COLUMN IF NOT EXISTS " + c.QuoteIdentifier(name) + " " + pgType
+	if defaultValue != "" {
+		statement += " DEFAULT " + defaultValue
+	}
+	c.declareColumn(name, pgType)
+	c.updateStatements = append(c.updateStatements, statement)
+}
+
+// EnsureFunction adds a PL/pgSQL function definition to create or replace it on opening.
+// The function is created in the persistence schema and has no arguments, what is enough for trigger functions.
+//
+//	Parameters:
+//		- name a function name.
+//		- returns a return type, e.g. trigger.
+//		- body a function body between BEGIN and END, including these keywords.
+func (c *PostgresPersistence[T]) EnsureFunction(name string, returns string, body string) {
+	c.updateStatements = append(c.updateStatements, "CREATE OR REPLACE FUNCTION "+c.quotedSchemaObjectName(name)+"()"+
+		" RETURNS "+returns+" LANGUAGE plpgsql AS $function$ "+body+" $function$")
+}
+
+// EnsureTrigger adds a row-level trigger definition on the persistence table to recreate it on opening.
+//
+//	Parameters:
+//		- name a trigger name.
+//		- timing when the trigger fires: BEFORE, AFTER or INSTEAD OF.
+//		- events events which fire the trigger, e.g. INSERT OR UPDATE.
+//		- function a name of the function defined with EnsureFunction.
+func (c *PostgresPersistence[T]) EnsureTrigger(name string, timing string, events string, function string) {
+	c.updateStatements = append(c.updateStatements,
+		"DROP TRIGGER IF EXISTS "+c.QuoteIdentifier(name)+" ON "+c.QuotedTableName(),
+		"CREATE TRIGGER "+c.QuoteIdentifier(name)+" "+timing+" "+events+" ON "+c.QuotedTableName()+
+			" FOR EACH ROW EXECUTE FUNCTION "+c.quotedSchemaObjectName(function)+"()")
+}
+
+// EnsureUpdatedAt adds a function and a trigger which set the column to the current time on every update.
+//
+//	Parameters:
+//		- column a name of the timestamp column, e.g. updated_at.
+func (c *PostgresPersistence[T]) EnsureUpdatedAt(column string) {
+	name := c.TableName + "_set_" + column
+	c.EnsureFunction(name, "trigger", "BEGIN NEW."+c.QuoteIdentifier(column)+" = now(); RETURN NEW; END;")
+	c.EnsureTrigger(name, "BEFORE", "UPDATE", name)
+}
+
+// EnsureRowLevelSecurity adds a statement to enable row-level security on the persistence table on opening.
+// Table owners bypass policies unless the security is forced, what matters when the service connects as the owner.
+//
+//	Parameters:
+//		- force true to apply policies to the table owner as well.
+func (c *PostgresPersistence[T]) EnsureRowLevelSecurity(force bool) {
+	statement := "ALTER TABLE " + c.QuotedTableName() + " ENABLE ROW LEVEL SECURITY"
+	if force {
+		statement += ", FORCE ROW LEVEL SECURITY"
+	}
+	c.updateStatements = append(c.updateStatements, statement)
+}
+
+// EnsurePolicy adds a row-level security policy definition on the persistence table to recreate it on opening.
+// Row-level security is enabled on the table if it wasn't enabled by EnsureRowLevelSecurity.
+// Policies usually compare columns with run-time settings passed with NewContextWithSessionSettings.
+//
+//	Example:
+//		c.EnsurePolicy(c.TableName+"_tenant", "ALL",
+//			"\"tenant_id\" = current_setting('app.tenant_id')", "")
+//
+//	Parameters:
+//		- name a policy name.
+//		- command a command the policy applies to: ALL, SELECT, INSERT, UPDATE or DELETE.
+//		- using (optional) an expression which existing rows must satisfy to be visible.
+//		- check (optional) an expression which new and updated rows must satisfy.
+func (c *PostgresPersistence[T]) EnsurePolicy(name string, command string, using string, check string) {
+	enabled := false
+	for _, statement := range c.updateStatements {
+		if strings.HasPrefix(statement, "ALTER TABLE "+c.QuotedTableName()+" ENABLE ROW LEVEL SECURITY") {
+			enabled = true
+		}
+	}
+	if !enabled {
+		c.EnsureRowLevelSecurity(false)
+	}
+
+	statement := "CREATE POLICY " + c.QuoteIdentifier(name) + " ON " + c.QuotedTableName()
+	if command != "" {
+		statement += " FOR " + command
+	}
+	if using != "" {
+		statement += " USING (" + using + ")"
+	}
+	if check != "" {
+		statement += " WITH CHECK (" + check + ")"
+	}
+	c.updateStatements = append(c.updateStatements,
+		"DROP POLICY IF EXISTS "+c.QuoteIdentifier(name)+" ON "+c.QuotedTableName(),
+		statement)
+}
+
+// quotedSchemaObjectName returns a quoted name of the database object in the persistence schema.
+func (c *PostgresPersistence[T]) quotedSchemaObjectName(name string) string {
+	if len(c.SchemaName) > 0 && !c.useSearchPath {
+		return c.QuoteIdentifier(c.SchemaName) + "." + c.QuoteIdentifier(name)
+	}
+	return c.QuoteIdentifier(name)
+}
+
+// DefineJoin adds a related table joined in read queries by filter.
+// Joins with unsupported types or without a table or a condition are ignored.
+//
+//	Parameters:
+//		- join a definition of the joined table.
+func (c *PostgresPersistence[T]) DefineJoin(join PostgresJoin) {
+	if join.isValid() {
+		c.joins = append(c.joins, join)
+	}
+}
+
+// ClearJoins removes all defined joins.
+func (c *PostgresPersistence[T]) ClearJoins() {
+	c.joins = nil
+}
+
+// composeFrom returns the table name with JOIN clauses of the related tables.
+func (c *PostgresPersistence[T]) composeFrom() string {
+	from := c.QuotedTableName()
+	for _, join := range c.joins {
+		from += join.composeClause()
+	}
+	return from
+}
+
+// composeSelection returns the SELECT list. By default it selects all columns
+// of the persistence table and the selected columns of the joined tables.
+func (c *PostgresPersistence[T]) composeSelection(selection string) string {
+	if len(selection) > 0 {
+		return selection
+	}
+	if len(c.joins) == 0 {
+		return "*"
+	}
+
+	columns := []string{c.QuotedTableName() + ".*"}
+	for _, join := range c.joins {
+		columns = append(columns, join.composeColumns()...)
+	}
+	return strings.Join(columns, ",")
 }
 
 // ConvertToPublic converts object value from internal to func (c * PostgresPersistence) format.
@@ -264,8 +646,9 @@ func (c *PostgresPersistence[T]) ConvertToPublic(rows pgx.Rows) (T, error) {
 	buf := make(map[string]any, 0)
 
 	for index, column := range columns {
-		buf[(string)(column.Name)] = values[index]
+		buf[c.NamingStrategy.FieldName((string)(column.Name))] = values[index]
 	}
+	c.maskFields(buf)
 
 	jsonBuf, toJsonErr := cconv.JsonConverter.ToJson(buf)
 	if toJsonErr != nil {
@@ -290,8 +673,10 @@ func (c *PostgresPersistence[T]) ConvertFromPublic(value T) (map[string]any, err
 	}
 
 	item, fromJsonErr := c.JsonMapConvertor.FromJson(buf)
-
-	return item, fromJsonErr
+	if fromJsonErr != nil {
+		return nil, fromJsonErr
+	}
+	return c.toColumnNames(item), nil
 }
 
 // ConvertFromPublicPartial converts the given object from the public partial format.
@@ -306,7 +691,32 @@ func (c *PostgresPersistence[T]) ConvertFromPublicPartial(value map[string]any)
 	}
 
 	item, fromJsonErr := c.JsonMapConvertor.FromJson(buf)
-	return item, fromJsonErr
+	if fromJsonErr != nil {
+		return nil, fromJsonErr
+	}
+	return c.toColumnNames(item), nil
+}
+
+// toColumnNames renames fields of the object map into column names using the naming strategy.
+func (c *PostgresPersistence[T]) toColumnNames(objMap map[string]any) map[string]any {
+	if _, ok := c.NamingStrategy.(*AsIsNamingStrategy); ok || objMap == nil {
+		return objMap
+	}
+	result := make(map[string]any, len(objMap))
+	for field, value := range objMap {
+		result[c.NamingStrategy.ColumnName(field)] = value
+	}
+	return result
+}
+
+// quoteColumn converts the field name into a column name using the naming strategy and quotes it.
+func (c *PostgresPersistence[T]) quoteColumn(field string) string {
+	return c.QuoteIdentifier(c.NamingStrategy.ColumnName(field))
+}
+
+// quotedIdColumn returns the quoted name of the id column.
+func (c *PostgresPersistence[T]) quotedIdColumn() string {
+	return c.QuoteIdentifier(c.idColumn)
 }
 
 func (c *PostgresPersistence[T]) QuoteIdentifier(value string) string {
@@ -319,14 +729,241 @@ func (c *PostgresPersistence[T]) QuoteIdentifier(value string) string {
 	return "\"" + value + "\""
 }
 
-// QuotedTableName return quoted SchemaName with TableName ("schema"."table")
+// ComposeSort translates sort parameters into a safely quoted ORDER BY clause
+// to be passed into GetPageByFilter or GetListByFilter methods.
+// In JSON persistences sort fields refer to fields of the data column.
+//
+//	Parameters:
+//		- sort sort parameters.
+//	Returns: an ORDER BY clause without the ORDER BY keywords.
+func (c *PostgresPersistence[T]) ComposeSort(sort cdata.SortParams) string {
+	return c.newSortBuilder().Build(sort)
+}
+
+// ComposeField converts a field name into a safely quoted column or JSON field expression.
+// In JSON persistences fields refer to fields of the data column.
+//
+//	Parameters:
+//		- field a field name, nested fields can be separated by dots.
+//	Returns: an SQL expression of the field.
+func (c *PostgresPersistence[T]) ComposeField(field string) string {
+	return c.newSortBuilder().composeField(field)
+}
+
+// SetSortTypes declares types of JSON fields, so ComposeSort orders them by their numeric
+// or date values instead of text, see PostgresSortBuilder.WithTypes.
+//
+//	Parameters:
+//		- types sort field names mapped to their types: JsonTypeString, JsonTypeNumber,
+//		  JsonTypeBoolean, JsonTypeDate or JsonTypeDateTime.
+func (c *PostgresPersistence[T]) SetSortTypes(types map[string]string) {
+	c.sortTypes = types
+}
+
+// newSortBuilder creates a sort builder which is aware of the JSON column of the persistence.
+func (c *PostgresPersistence[T]) newSortBuilder() *PostgresSortBuilder {
+	builder := NewPostgresSortBuilder()
+	if c.jsonColumn != "" {
+		builder.WithJsonColumn(c.jsonColumn, c.idColumn).WithTypes(c.sortTypes)
+		for field, extracted := range c.extractedColumns {
+			builder.WithFieldColumn(field, extracted.column)
+		}
+	} else {
+		builder.WithNamingStrategy(c.NamingStrategy)
+	}
+	return builder
+}
+
+// ComposeSelect validates projection fields and composes a safely quoted SELECT list
+// to be passed into GetPageByFilter or GetListByFilter methods.
+// Fields are validated against columns of the table. In JSON persistences fields refer
+// to fields of the data column, nested ones separated by dots, and the column is rebuilt
+// with the selected fields only, so other fields of converted items get zero values.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- projection projection parameters.
+//	Returns: a SELECT list or empty string to select all columns, and BadRequestError if fields are unknown.
+func (c *PostgresPersistence[T]) ComposeSelect(ctx context.Context, correlationId string,
+	projection cdata.ProjectionParams) (string, error) {
+
+	fields := projection.Value()
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	if c.jsonColumn != "" {
+		return c.composeJsonSelect(fields), nil
+	}
+
+	columns, err := c.getTableColumns(ctx, correlationId)
+	if err != nil {
+		return "", err
+	}
+
+	selection := make([]string, 0, len(fields))
+	unknown := make([]string, 0)
+	for _, field := range fields {
+		column := c.NamingStrategy.ColumnName(field)
+		if !columns[column] {
+			unknown = append(unknown, field)
+			continue
+		}
+		selection = append(selection, Column(column))
+	}
+	if len(unknown) > 0 {
+		return "", cerr.NewBadRequestError(correlationId, "INVALID_PROJECTION",
+			"Projection contains unknown columns of "+c.TableName+": "+strings.Join(unknown, ", ")).
+			WithDetails("fields", unknown)
+	}
+	return strings.Join(selection, ","), nil
+}
+
+// composeJsonSelect composes a SELECT list which keeps only the given fields in the JSON column.
+// The id is always kept in the document, as data items are converted from the JSON column only.
+func (c *PostgresPersistence[T]) composeJsonSelect(fields []string) string {
+	selection := []string{Column(c.idColumn)}
+	paths := make([][]string, 0, len(fields))
+	for _, field := range fields {
+		if field == "id" {
+			continue
+		}
+		paths = append(paths, strings.Split(field, "."))
+	}
+
+	if len(paths) > 0 {
+		// Missing fields are removed to avoid null values in the result
+		selection = append(selection, "jsonb_strip_nulls(jsonb_build_object('id',"+Column(c.idColumn)+","+
+			composeJsonObjectFields(Column(c.jsonColumn), paths)+")) AS "+Column(c.jsonColumn))
+	}
+	return strings.Join(selection, ",")
+}
+
+// composeJsonObjectFields composes jsonb_build_object arguments which copy the given paths from the source.
+// Nested fields are copied into objects with only the selected fields, which are null when none of them is set.
+func composeJsonObjectFields(source string, paths [][]string) string {
+	keys := make([]string, 0, len(paths))
+	nested := make(map[string][][]string)
+	whole := make(map[string]bool)
+	for _, path := range paths {
+		key := path[0]
+		if _, ok := nested[key]; !ok {
+			keys = append(keys, key)
+			nested[key] = make([][]string, 0)
+		}
+		if len(path) == 1 {
+			whole[key] = true
+		} else {
+			nested[key] = append(nested[key], path[1:])
+		}
+	}
+
+	arguments := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := source + "->" + QuoteLiteral(key)
+		// Parent fields are selected as a whole together with their nested fields
+		if !whole[key] {
+			value = "NULLIF(jsonb_strip_nulls(jsonb_build_object(" + composeJsonObjectFields(value, nested[key]) + ")), '{}'::jsonb)"
+		}
+		arguments = append(arguments, QuoteLiteral(key)+","+value)
+	}
+	return strings.Join(arguments, ",")
+}
+
+// getTableColumns reads and caches names of the table columns.
+func (c *PostgresPersistence[T]) getTableColumns(ctx context.Context, correlationId string) (map[string]bool, error) {
+	c.columnsLock.Lock()
+	defer c.columnsLock.Unlock()
+
+	if c.tableColumns != nil {
+		return c.tableColumns, nil
+	}
+
+	query := "SELECT attname FROM pg_attribute WHERE attrelid=to_regclass($1) AND attnum>0 AND NOT attisdropped"
+	rows, err := c.queryRead(ctx, correlationId, query, c.qualifiedTableName())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	c.tableColumns = columns
+	return columns, nil
+}
+
+// resetTableColumns clears cached names of the table columns.
+func (c *PostgresPersistence[T]) resetTableColumns() {
+	c.columnsLock.Lock()
+	c.tableColumns = nil
+	c.columnsLock.Unlock()
+}
+
+// QuotedTableName return quoted SchemaName with TableName ("schema"."table").
+// In the search_path mode the table name is not qualified with the schema.
 func (c *PostgresPersistence[T]) QuotedTableName() string {
-	if len(c.SchemaName) > 0 {
+	if c.useSearchPath {
+		return c.QuoteIdentifier(c.TableName)
+	}
+	return c.qualifiedTableName()
+}
+
+// qualifiedTableName returns the table name qualified with the schema regardless of the search_path mode.
+func (c *PostgresPersistence[T]) qualifiedTableName() string {
+	// Temporary tables live in a special session schema and can't be qualified
+	if len(c.SchemaName) > 0 && c.tableType != TableTypeTemporary {
 		return c.QuoteIdentifier(c.SchemaName) + "." + c.QuoteIdentifier(c.TableName)
 	}
 	return c.QuoteIdentifier(c.TableName)
 }
 
+// GetClient returns a client to execute queries.
+// If the context carries a transaction started by PostgresConnection
+// the transaction is returned, otherwise the current connection pool is used.
+//
+//	Parameters:
+//		- ctx context.Context
+//	Returns: a client to execute queries.
+func (c *PostgresPersistence[T]) GetClient(ctx context.Context) conn.IPostgresClient {
+	if tx, ok := conn.TransactionFromContext(ctx); ok {
+		return tx
+	}
+	// The connection pool can be replaced after automatic reconnect
+	if c.Connection != nil {
+		if pool := c.Connection.GetConnection(); pool != nil {
+			return pool
+		}
+	}
+	return c.Client
+}
+
+// GetReadClient returns a client to execute read-only queries.
+// If the context carries a transaction it is used to keep reads consistent with writes,
+// otherwise a read replica is selected when replicas are configured in the connection.
+//
+//	Parameters:
+//		- ctx context.Context
+//	Returns: a client to execute read-only queries.
+func (c *PostgresPersistence[T]) GetReadClient(ctx context.Context) conn.IPostgresClient {
+	if _, ok := conn.TransactionFromContext(ctx); !ok && c.Connection != nil {
+		if pool := c.Connection.GetReadConnection(); pool != nil {
+			return pool
+		}
+	}
+	return c.GetClient(ctx)
+}
+
 // IsOpen checks if the component is opened.
 //
 //	Returns: true if the component has been opened and false otherwise.
@@ -338,15 +975,71 @@ func (c *PostgresPersistence[T]) IsOpen() bool {
 //
 //	Returns: true if you need terminate your processes.
 func (c *PostgresPersistence[T]) IsTerminated() bool {
-	select {
-	case _, ok := <-c.isTerminated:
-		if !ok {
-			return true
-		}
-	default:
+	terminated := c.getTerminated()
+	return terminated != nil && terminated.Err() != nil
+}
+
+// getTerminated returns the context which is cancelled when the component is terminated, or nil when it's closed.
+func (c *PostgresPersistence[T]) getTerminated() context.Context {
+	c.terminateLock.Lock()
+	defer c.terminateLock.Unlock()
+	return c.isTerminated
+}
+
+// resetTerminated replaces the termination context with a new one, or clears it when the component is closed.
+func (c *PostgresPersistence[T]) resetTerminated(closed bool) {
+	c.terminateLock.Lock()
+	defer c.terminateLock.Unlock()
+
+	if c.terminate != nil {
+		c.terminate()
+	}
+	c.isTerminated, c.terminate = nil, nil
+	if !closed {
+		c.isTerminated, c.terminate = context.WithCancel(context.Background())
+	}
+}
+
+// Terminate signals all running operations to stop before the component is closed, e.g. during shutdown.
+// Running queries are cancelled, iterations over retrieved rows stop at the next row
+// and new operations fail until the component is closed and opened again.
+// Close terminates running operations automatically.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+func (c *PostgresPersistence[T]) Terminate(ctx context.Context, correlationId string) {
+	if c.terminateOnce() {
+		c.Logger.Debug(ctx, correlationId, "Terminated operations of postgres collection %s", c.TableName)
+	}
+}
+
+// terminateOnce cancels the termination context once.
+//
+//	Returns: true if the context was cancelled by this call.
+func (c *PostgresPersistence[T]) terminateOnce() bool {
+	c.terminateLock.Lock()
+	defer c.terminateLock.Unlock()
+
+	if c.isTerminated == nil || c.isTerminated.Err() != nil {
 		return false
 	}
-	return false
+	c.terminate()
+	return true
+}
+
+// terminatedError returns an error of operations stopped by Terminate or Close.
+func terminatedError(correlationId string) error {
+	return cerr.NewError("query terminated").WithCorrelationId(correlationId)
+}
+
+// checkInterrupted checks if the operation shall be stopped because the component is closing
+// or the caller's context is cancelled or timed out.
+func (c *PostgresPersistence[T]) checkInterrupted(ctx context.Context, correlationId string) error {
+	if c.IsTerminated() {
+		return terminatedError(correlationId)
+	}
+	return ctx.Err()
 }
 
 // Open the component.
@@ -360,14 +1053,28 @@ func (c *PostgresPersistence[T]) Open(ctx context.Context, correlationId string)
 		return nil
 	}
 
-	c.isTerminated = make(chan struct{})
+	c.resetTerminated(false)
+
+	if c.lazyOpen {
+		atomic.StoreInt32(&c.openPending, 1)
+		c.opened = true
+		c.Logger.Debug(ctx, correlationId, "Opening of postgres collection %s is deferred until the first operation", c.QuotedTableName())
+		return nil
+	}
+
+	err = c.connect(ctx, correlationId)
+	c.opened = err == nil
+	return err
+}
 
+// connect opens the connection and creates database objects.
+func (c *PostgresPersistence[T]) connect(ctx context.Context, correlationId string) (err error) {
 	if c.Connection == nil {
 		c.Connection = c.createConnection(ctx)
 		c.localConnection = true
 	}
 
-	if c.localConnection {
+	if c.localConnection && !c.Connection.IsOpen() {
 		err = c.Connection.Open(ctx, correlationId)
 	}
 
@@ -379,13 +1086,12 @@ func (c *PostgresPersistence[T]) Open(ctx context.Context, correlationId string)
 		err = cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "PostgreSQL connection is not opened")
 	}
 
-	c.opened = false
-
 	if err != nil {
 		return err
 	}
 	c.Client = c.Connection.GetConnection()
 	c.DatabaseName = c.Connection.GetDatabaseName()
+	c.resetTableColumns()
 
 	// Define database schema
 	c.Overrides.DefineSchema()
@@ -396,119 +1102,356 @@ func (c *PostgresPersistence[T]) Open(ctx context.Context, correlationId string)
 		c.Client = nil
 		err = cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to postgres failed").WithCause(err)
 	} else {
-		c.opened = true
 		c.Logger.Debug(ctx, correlationId, "Connected to postgres database %s, collection %s", c.DatabaseName, c.QuotedTableName())
 	}
 
 	return err
 }
 
-// Close component and frees used resources.
-//
-//	Parameters:
-//		- ctx context.Context
-//		- correlationId (optional) transaction id to trace execution through call chain.
-//	Returns: error or nil no errors occurred.
-func (c *PostgresPersistence[T]) Close(ctx context.Context, correlationId string) (err error) {
-	if !c.opened {
+// ensureOpen completes deferred opening of the component in lazy open mode.
+// Concurrent calls wait for a single opening attempt.
+func (c *PostgresPersistence[T]) ensureOpen(ctx context.Context, correlationId string) error {
+	if atomic.LoadInt32(&c.openPending) == 0 {
 		return nil
 	}
 
-	if c.Connection == nil {
-		return cerr.NewInvalidStateError(correlationId, "NO_CONNECTION", "Postgres connection is missing")
-	}
+	c.openLock.Lock()
+	defer c.openLock.Unlock()
 
-	close(c.isTerminated)
-	if c.localConnection {
-		err = c.Connection.Close(ctx, correlationId)
+	if atomic.LoadInt32(&c.openPending) == 0 {
+		return nil
 	}
-	if err != nil {
+	if err := c.connect(ctx, correlationId); err != nil {
 		return err
 	}
-	c.opened = false
-	c.Client = nil
-	c.Connection = nil
-	c.isTerminated = nil
+	atomic.StoreInt32(&c.openPending, 0)
 	return nil
 }
 
-// Clear component state.
-//
-//	Parameters:
-//		- ctx context.Context
-//		- correlationId 	(optional) transaction id to trace execution through call chain.
-//	Returns: error or nil no errors occured.
-func (c *PostgresPersistence[T]) Clear(ctx context.Context, correlationId string) error {
-	// Return error if collection is not set
-	if c.TableName == "" {
-		return errors.New("Table name is not defined")
+// query executes a query against the client selected for the context.
+func (c *PostgresPersistence[T]) query(ctx context.Context, correlationId string, query string, args ...any) (pgx.Rows, error) {
+	if err := c.ensureOpen(ctx, correlationId); err != nil {
+		return nil, err
 	}
+	rows, err := c.queryClient(ctx, correlationId, c.GetClient(ctx), query, args...)
+	if err != nil || len(c.uniqueKeys) == 0 {
+		return rows, c.wrapUniqueKeyError(correlationId, err)
+	}
+	return &uniqueKeyRows[T]{Rows: rows, persistence: c, correlationId: correlationId}, nil
+}
 
-	rows, err := c.Client.Query(ctx, "DELETE FROM "+c.QuotedTableName())
+// exec executes a statement which does not return rows and returns its command tag.
+func (c *PostgresPersistence[T]) exec(ctx context.Context, correlationId string, query string, args ...any) (pgconn.CommandTag, error) {
+	rows, err := c.query(ctx, correlationId, query, args...)
 	if err != nil {
-		return cerr.
-			NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to postgres failed").
-			WithCause(err)
+		return pgconn.CommandTag{}, err
 	}
 	rows.Close()
+	return rows.CommandTag(), rows.Err()
+}
 
-	if rows.Err() != nil {
-		return rows.Err()
+// queryRead executes a read-only query which can be served by a read replica.
+func (c *PostgresPersistence[T]) queryRead(ctx context.Context, correlationId string, query string, args ...any) (pgx.Rows, error) {
+	if err := c.ensureOpen(ctx, correlationId); err != nil {
+		return nil, err
 	}
-
-	return nil
+	return c.queryClient(ctx, correlationId, c.GetReadClient(ctx), query, args...)
 }
 
-func (c *PostgresPersistence[T]) CreateSchema(ctx context.Context, correlationId string) (err error) {
-	if len(c.schemaStatements) == 0 {
-		return nil
-	}
+// queryClient executes a query with the given client.
+// When the query timeout is configured the query is cancelled if it doesn't complete in time.
+// Queries are cancelled as well when the component is terminated.
+func (c *PostgresPersistence[T]) queryClient(ctx context.Context, correlationId string, client conn.IPostgresClient,
+	query string, args ...any) (pgx.Rows, error) {
 
-	exists, err := c.checkTableExists(ctx)
-	if err != nil {
-		return err
+	terminated := c.getTerminated()
+	if c.queryTimeout <= 0 && terminated == nil {
+		return c.queryTagged(ctx, correlationId, client, query, args...)
 	}
-	if exists {
-		return nil
+	if terminated != nil && terminated.Err() != nil {
+		return nil, terminatedError(correlationId)
 	}
-	c.Logger.Debug(ctx, correlationId, "Table "+c.QuotedTableName()+" does not exist. Creating database objects...")
 
-	for _, dml := range c.schemaStatements {
-		result, err := c.Client.Query(ctx, dml)
-		if err != nil {
-			c.Logger.Error(ctx, correlationId, err, "Failed to autocreate database object")
-			return err
+	var timeoutCtx context.Context
+	var cancelQuery context.CancelFunc
+	if c.queryTimeout > 0 {
+		timeoutCtx, cancelQuery = context.WithTimeout(ctx, c.queryTimeout)
+	} else {
+		timeoutCtx, cancelQuery = context.WithCancel(ctx)
+	}
+	cancel := cancelQuery
+	if terminated != nil {
+		stop := context.AfterFunc(terminated, cancelQuery)
+		cancel = func() {
+			stop()
+			cancelQuery()
 		}
-		result.Close()
+	}
 
-		if result.Err() != nil {
-			return result.Err()
+	rows, err := c.queryTagged(timeoutCtx, correlationId, client, query, args...)
+	if err != nil {
+		err = c.wrapTimeoutError(ctx, timeoutCtx, terminated, correlationId, err)
+		cancel()
+		return nil, err
+	}
+	return &timeoutRows[T]{Rows: rows, persistence: c, ctx: ctx, timeoutCtx: timeoutCtx,
+		terminated: terminated, cancel: cancel, correlationId: correlationId}, nil
+}
+
+// wrapTimeoutError converts an error caused by the expired query timeout into InvocationError
+// and an error caused by termination of the component into the termination error.
+// Errors caused by the deadline of the caller context are returned as is.
+func (c *PostgresPersistence[T]) wrapTimeoutError(ctx context.Context, timeoutCtx context.Context,
+	terminated context.Context, correlationId string, err error) error {
+
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	if terminated != nil && terminated.Err() != nil && errors.Is(timeoutCtx.Err(), context.Canceled) {
+		return terminatedError(correlationId)
+	}
+	if !errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return cerr.NewInvocationError(correlationId, "QUERY_TIMEOUT", "PostgreSQL query timed out").
+		WithDetails("timeout", c.queryTimeout.Milliseconds()).
+		WithCause(err)
+}
+
+// queryTagged executes a query with the given client.
+// When session tagging is enabled the session application_name is set to the correlationId for the time of the query.
+// Run-time settings bound to the context with NewContextWithSessionSettings are set the same way.
+func (c *PostgresPersistence[T]) queryTagged(ctx context.Context, correlationId string, client conn.IPostgresClient,
+	query string, args ...any) (pgx.Rows, error) {
+
+	ctx = conn.NewContextWithCorrelationId(ctx, correlationId)
+	settings := c.sessionSettings(ctx, correlationId)
+	if len(settings) == 0 {
+		rows, err := client.Query(ctx, query, args...)
+		return rows, c.wrapConnectionError(correlationId, err)
+	}
+
+	pool, ok := client.(*pgxpool.Pool)
+	if !ok {
+		// The settings are local to the transaction and are reset on commit or rollback
+		statement, params := composeSetConfig(settings, true)
+		if _, err := client.Exec(ctx, statement, params...); err != nil {
+			return nil, c.wrapConnectionError(correlationId, err)
 		}
+		rows, err := client.Query(ctx, query, args...)
+		return rows, c.wrapConnectionError(correlationId, err)
+	}
+
+	// The settings are set on a dedicated connection which is held until the rows are closed
+	poolConn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, c.wrapConnectionError(correlationId, err)
+	}
+	statement, params := composeSetConfig(settings, false)
+	if _, err = poolConn.Exec(ctx, statement, params...); err != nil {
+		resetSessionSettings(poolConn, settings)
+		return nil, c.wrapConnectionError(correlationId, err)
+	}
+	rows, err := poolConn.Query(ctx, query, args...)
+	if err != nil {
+		resetSessionSettings(poolConn, settings)
+		return nil, c.wrapConnectionError(correlationId, err)
+	}
+	return &taggedRows{Rows: rows, conn: poolConn, settings: settings}, nil
+}
+
+// sessionSettings collects run-time settings to set for the time of the query sorted by their names.
+func (c *PostgresPersistence[T]) sessionSettings(ctx context.Context, correlationId string) []sessionSetting {
+	values := SessionSettingsFromContext(ctx)
+	if c.tagSessions && correlationId != "" {
+		values["application_name"] = correlationId
+	}
+	if c.useSearchPath && c.SchemaName != "" {
+		values["search_path"] = c.searchPath()
+	}
+	if c.correlationSetting != "" && correlationId != "" {
+		values[c.correlationSetting] = correlationId
+	}
+
+	settings := make([]sessionSetting, 0, len(values))
+	for name, value := range values {
+		settings = append(settings, sessionSetting{name: name, value: value})
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].name < settings[j].name })
+	return settings
+}
+
+// wrapConnectionError converts driver errors caused by an unreachable database into ConnectionError.
+func (c *PostgresPersistence[T]) wrapConnectionError(correlationId string, err error) error {
+	if !conn.IsConnectionError(err) {
+		return err
+	}
+	return cerr.NewConnectionError(correlationId, "CONNECTION_FAILED", "PostgreSQL database is not reachable").
+		WithCause(conn.RedactError(err))
+}
+
+// Close component and frees used resources.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occurred.
+func (c *PostgresPersistence[T]) Close(ctx context.Context, correlationId string) (err error) {
+	if !c.opened {
+		return nil
+	}
+
+	if atomic.CompareAndSwapInt32(&c.openPending, 1, 0) {
+		// The component was never connected in lazy open mode
+		c.resetTerminated(true)
+		c.opened = false
+		return nil
+	}
+
+	if c.Connection == nil {
+		return cerr.NewInvalidStateError(correlationId, "NO_CONNECTION", "Postgres connection is missing")
+	}
+
+	c.terminateOnce()
+	if c.localConnection {
+		err = c.Connection.Close(ctx, correlationId)
+	}
+	if err != nil {
+		return err
 	}
+	c.opened = false
+	c.Client = nil
+	c.Connection = nil
+	c.resetTerminated(true)
 	return nil
 }
 
-func (c *PostgresPersistence[T]) checkTableExists(ctx context.Context) (bool, error) {
-	// Check if table exist to determine either to auto create objects
-	query := "SELECT to_regclass('" + c.QuotedTableName() + "')"
-	result, err := c.Client.Query(ctx, query)
+// Clear component state.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId 	(optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occured.
+func (c *PostgresPersistence[T]) Clear(ctx context.Context, correlationId string) error {
+	// Return error if collection is not set
+	if c.TableName == "" {
+		return errors.New("Table name is not defined")
+	}
+
+	rows, err := c.query(ctx, correlationId, "DELETE FROM "+c.QuotedTableName())
 	if err != nil {
-		return false, err
+		return cerr.
+			NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to postgres failed").
+			WithCause(err)
 	}
-	defer result.Close()
+	rows.Close()
 
-	// If table already exists then exit
-	if result.Next() {
-		val, err := result.Values()
-		if err != nil {
-			return false, err
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	return nil
+}
+
+// CreateSchema creates database objects defined in DefineSchema when the table doesn't exist
+// and applies update statements, like EnsureColumn, to existing tables.
+// All statements run in a single transaction: when any of them fails, all changes are rolled back.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occurred.
+func (c *PostgresPersistence[T]) CreateSchema(ctx context.Context, correlationId string) (err error) {
+	if len(c.schemaStatements) == 0 && len(c.updateStatements) == 0 {
+		return nil
+	}
+	if c.Client == nil {
+		return cerr.NewInvalidStateError(correlationId, "NO_CONNECTION", "PostgreSQL connection is missing")
+	}
+
+	// Schema objects are created on a dedicated connection which holds the advisory lock,
+	// so concurrently starting instances create them one after another
+	poolConn, err := c.Client.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer poolConn.Release()
+
+	lockKey := c.schemaLockKey()
+	if _, err = poolConn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return err
+	}
+	defer func() {
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, unlockErr := poolConn.Exec(unlockCtx, "SELECT pg_advisory_unlock($1)", lockKey); unlockErr != nil {
+			// The session lock must not stay with the connection returned to the pool
+			_ = poolConn.Conn().Close(unlockCtx)
+		}
+	}()
+
+	exists, err := c.checkTableExists(ctx, poolConn)
+	if err != nil {
+		return err
+	}
+
+	statements := make([]string, 0, len(c.schemaStatements)+len(c.updateStatements))
+	if !exists {
+		c.Logger.Debug(ctx, correlationId, "Table "+c.QuotedTableName()+" does not exist. Creating database objects...")
+		statements = append(statements, c.schemaStatements...)
+	} else if err = c.validateExistingSchema(ctx, correlationId, poolConn); err != nil {
+		return err
+	}
+	// Update statements are idempotent and applied to existing tables as well
+	statements = append(statements, c.updateStatements...)
+	if len(statements) == 0 {
+		return nil
+	}
+
+	// All statements run in a single transaction, so a failed statement doesn't leave half-created objects
+	err = pgx.BeginFunc(ctx, poolConn, func(tx pgx.Tx) error {
+		if c.useSearchPath && c.SchemaName != "" {
+			if _, err := tx.Exec(ctx, "SELECT set_config('search_path', $1, true)", c.searchPath()); err != nil {
+				return err
+			}
 		}
+		return c.executeSchemaStatements(ctx, correlationId, tx, statements)
+	})
+	if err != nil {
+		c.Logger.Error(ctx, correlationId, err, "Failed to create database objects for %s, all changes were rolled back",
+			c.QuotedTableName())
+		return err
+	}
+	return nil
+}
+
+// schemaLockKey returns a key of the advisory lock which protects creation of the table objects.
+func (c *PostgresPersistence[T]) schemaLockKey() int64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte("schema:" + c.DatabaseName + ":" + c.qualifiedTableName()))
+	return int64(hash.Sum64())
+}
+
+// executeSchemaStatements executes DDL statements one by one.
+// The error of a failed statement is returned with the statement in details.
+func (c *PostgresPersistence[T]) executeSchemaStatements(ctx context.Context, correlationId string,
+	client conn.IPostgresClient, statements []string) error {
 
-		if len(val) > 0 && val[0] == c.TableName {
-			return true, nil
+	for _, dml := range statements {
+		if _, err := client.Exec(ctx, dml); err != nil {
+			return cerr.NewInternalError(correlationId, "SCHEMA_STATEMENT_FAILED", "Failed to execute schema statement: "+dml).
+				WithDetails("statement", dml).
+				WithCause(err)
 		}
 	}
-	return false, nil
+	return nil
+}
+
+func (c *PostgresPersistence[T]) checkTableExists(ctx context.Context, client conn.IPostgresClient) (bool, error) {
+	// Check if table exist to determine either to auto create objects
+	var exists bool
+	err := client.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", c.qualifiedTableName()).Scan(&exists)
+	return exists, err
 }
 
 // GenerateColumns generates a list of column names to use in SQL statements like: "column1,column2,column3"
@@ -526,7 +1469,7 @@ func (c *PostgresPersistence[T]) GenerateColumns(columns []string) string {
 		if builder.String() != "" {
 			builder.WriteString(",")
 		}
-		builder.WriteString(c.QuoteIdentifier(item))
+		builder.WriteString(c.quoteColumn(item))
 	}
 	return builder.String()
 
@@ -570,7 +1513,7 @@ func (c *PostgresPersistence[T]) GenerateSetParameters(columns []string) string
 		if setParamsBuf.String() != "" {
 			setParamsBuf.WriteString(",")
 		}
-		setParamsBuf.WriteString(c.QuoteIdentifier(columns[i]) + "=$" + strconv.FormatInt((int64)(index), 10))
+		setParamsBuf.WriteString(c.quoteColumn(columns[i]) + "=$" + strconv.FormatInt((int64)(index), 10))
 		index++
 	}
 	return setParamsBuf.String()
@@ -588,14 +1531,44 @@ func (c *PostgresPersistence[T]) GenerateColumnsAndValues(objMap map[string]any)
 
 	ln := len(objMap)
 	columns := make([]string, 0, ln)
-	values := make([]any, 0, ln)
-	for _col, _val := range objMap {
+	for _col := range objMap {
 		columns = append(columns, _col)
-		values = append(values, _val)
+	}
+	// Stable order of columns produces the same statements which are prepared once per connection
+	sort.Strings(columns)
+
+	values := make([]any, 0, ln)
+	for _, _col := range columns {
+		values = append(values, objMap[_col])
 	}
 	return columns, values
 }
 
+// GetStatement returns a cached SQL statement or builds and caches a new one.
+// Statements generated for the same operation and set of columns are built only once
+// and have the same text, so the driver prepares them once per connection.
+//
+//	Parameters:
+//		- key a unique key of the statement, e.g. operation name with the list of columns.
+//		- build a function to build the statement when it is not cached.
+//	Returns: the SQL statement.
+func (c *PostgresPersistence[T]) GetStatement(key string, build func() string) string {
+	if statement, ok := c.statements.Load(key); ok {
+		return statement.(string)
+	}
+	statement := build()
+	c.statements.Store(key, statement)
+	return statement
+}
+
+// resetStatements clears cached statements when the table name can change.
+func (c *PostgresPersistence[T]) resetStatements() {
+	c.statements.Range(func(key, value any) bool {
+		c.statements.Delete(key)
+		return true
+	})
+}
+
 // GetPageByFilter gets a page of data items retrieved by a given filter and sorted according to sort parameters.
 // This method shall be called by a func (c * PostgresPersistence) getPageByFilter method from child class that
 // receives FilterParams and converts them into a filter function.
@@ -611,18 +1584,52 @@ func (c *PostgresPersistence[T]) GenerateColumnsAndValues(objMap map[string]any)
 func (c *PostgresPersistence[T]) GetPageByFilter(ctx context.Context, correlationId string,
 	filter string, paging cdata.PagingParams, sort string, selection string) (page cdata.DataPage[T], err error) {
 
-	query := "SELECT * FROM " + c.QuotedTableName()
-	if len(selection) > 0 {
-		query = "SELECT " + selection + " FROM " + c.QuotedTableName()
+	return c.GetPageByFilterWithParams(ctx, correlationId, filter, nil, paging, sort, selection)
+}
+
+// GetPageByFilterWithParams gets a page of data items retrieved by a parameterized filter
+// and sorted according to sort parameters. Filter values are passed as bound parameters ($1, $2, ...)
+// which can be composed with PostgresFilterBuilder.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- filter            (optional) a WHERE clause with parameter placeholders
+//		- params            (optional) values of the filter parameters
+//		- paging            (optional) paging parameters
+//		- sort              (optional) ORDER BY clause composed by ComposeSort
+//		- select            (optional) SELECT list composed by ComposeSelect
+//	Returns: receives a data page or error.
+func (c *PostgresPersistence[T]) GetPageByFilterWithParams(ctx context.Context, correlationId string,
+	filter string, params []any, paging cdata.PagingParams, sort string, selection string) (page cdata.DataPage[T], err error) {
+	return withRetries(ctx, c, correlationId, "GetPageByFilterWithParams", func() (cdata.DataPage[T], error) {
+		return c.getPageByFilterWithParams(ctx, correlationId, filter, params, paging, sort, selection)
+	})
+}
+
+// getPageByFilterWithParams is a single attempt of GetPageByFilterWithParams.
+func (c *PostgresPersistence[T]) getPageByFilterWithParams(ctx context.Context, correlationId string,
+	filter string, params []any, paging cdata.PagingParams, sort string, selection string) (page cdata.DataPage[T], err error) {
+
+	// The count query applies the tenant filter itself
+	pageFilter, pageParams, tenantErr := c.applyTenantFilter(ctx, correlationId, filter, params)
+	if tenantErr != nil {
+		return page, tenantErr
 	}
 
+	query := "SELECT " + c.composeSelection(selection) + " FROM " + c.composeFrom()
+
 	// Adjust max item count based on configuration paging
 	skip := paging.GetSkip(-1)
-	take := paging.GetTake((int64)(c.MaxPageSize))
+	maxPageSize := (int64)(c.MaxPageSize)
+	if size, ok := MaxPageSizeFromContext(ctx); ok {
+		maxPageSize = size
+	}
+	take := paging.GetTake(maxPageSize)
 	pagingEnabled := paging.Total
 
-	if len(filter) > 0 {
-		query += " WHERE " + filter
+	if len(pageFilter) > 0 {
+		query += " WHERE " + pageFilter
 	}
 	if len(sort) > 0 {
 		query += " ORDER BY " + sort
@@ -632,7 +1639,7 @@ func (c *PostgresPersistence[T]) GetPageByFilter(ctx context.Context, correlatio
 	}
 	query += " LIMIT " + strconv.FormatInt(take, 10)
 
-	rows, err := c.Client.Query(ctx, query)
+	rows, err := c.queryRead(ctx, correlationId, query, pageParams...)
 	if err != nil {
 		return *cdata.NewEmptyDataPage[T](), err
 	}
@@ -640,11 +1647,9 @@ func (c *PostgresPersistence[T]) GetPageByFilter(ctx context.Context, correlatio
 
 	items := make([]T, 0, 0)
 	for rows.Next() {
-		if c.IsTerminated() {
+		if err := c.checkInterrupted(ctx, correlationId); err != nil {
 			rows.Close()
-			return *cdata.NewEmptyDataPage[T](), cerr.
-				NewError("query terminated").
-				WithCorrelationId(correlationId)
+			return *cdata.NewEmptyDataPage[T](), err
 		}
 		item, convErr := c.Overrides.ConvertToPublic(rows)
 		if convErr != nil {
@@ -657,8 +1662,19 @@ func (c *PostgresPersistence[T]) GetPageByFilter(ctx context.Context, correlatio
 		c.Logger.Trace(ctx, correlationId, "Retrieved %d from %s", len(items), c.TableName)
 	}
 
+	// Rows must be released before the count query when running inside a transaction
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return *cdata.NewEmptyDataPage[T](), err
+	}
+
 	if pagingEnabled {
-		count, err := c.GetCountByFilter(ctx, correlationId, filter)
+		var count int64
+		if c.approximateTotal {
+			count, err = c.getApproximateCount(ctx, correlationId, pageFilter, pageParams)
+		} else {
+			count, err = c.getCountByFilterWithParams(ctx, correlationId, filter, params)
+		}
 		if err != nil {
 			return *cdata.NewEmptyDataPage[T](), err
 		}
@@ -666,7 +1682,60 @@ func (c *PostgresPersistence[T]) GetPageByFilter(ctx context.Context, correlatio
 		return *cdata.NewDataPage[T](items, int(count)), nil
 	}
 
-	return *cdata.NewDataPage[T](items, cdata.EmptyTotalValue), rows.Err()
+	return *cdata.NewDataPage[T](items, cdata.EmptyTotalValue), nil
+}
+
+// getApproximateCount estimates a number of items from table statistics or query plan.
+// An exact count is calculated when the estimate is not available or below the threshold.
+func (c *PostgresPersistence[T]) getApproximateCount(ctx context.Context, correlationId string,
+	filter string, params []any) (int64, error) {
+
+	var query string
+	var args []any
+	if len(filter) > 0 || len(c.joins) > 0 {
+		query = "EXPLAIN (FORMAT JSON) SELECT 1 FROM " + c.composeFrom()
+		if len(filter) > 0 {
+			query += " WHERE " + filter
+		}
+		args = params
+	} else {
+		// Tables which were never analyzed have negative estimates, including any of their partitions
+		query = "SELECT COALESCE(CASE WHEN min(reltuples) < 0 THEN -1 ELSE sum(reltuples) END, -1)::bigint FROM pg_class" +
+			" WHERE oid IN (" + c.composeTableRelations("$1") + ")"
+		args = []any{c.QuotedTableName()}
+	}
+
+	rows, err := c.queryRead(ctx, correlationId, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	var estimate int64 = -1
+	if rows.Next() {
+		if len(filter) > 0 {
+			var plan []map[string]map[string]any
+			if err = rows.Scan(&plan); err == nil && len(plan) > 0 {
+				estimate = cconv.LongConverter.ToLongWithDefault(plan[0]["Plan"]["Plan Rows"], -1)
+			}
+		} else {
+			err = rows.Scan(&estimate)
+		}
+	}
+	rows.Close()
+	if err == nil {
+		err = rows.Err()
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Tables which were never analyzed have no statistics
+	if estimate < 0 || estimate < int64(c.approximateTotalThreshold) {
+		return c.getCountByFilterWithParams(ctx, correlationId, filter, params)
+	}
+
+	c.Logger.Trace(ctx, correlationId, "Estimated %d items in %s", estimate, c.TableName)
+	return estimate, nil
 }
 
 // GetCountByFilter gets a number of data items retrieved by a given filter.
@@ -681,12 +1750,39 @@ func (c *PostgresPersistence[T]) GetPageByFilter(ctx context.Context, correlatio
 func (c *PostgresPersistence[T]) GetCountByFilter(ctx context.Context, correlationId string,
 	filter string) (int64, error) {
 
-	query := "SELECT COUNT(*) AS count FROM " + c.QuotedTableName()
+	return c.GetCountByFilterWithParams(ctx, correlationId, filter, nil)
+}
+
+// GetCountByFilterWithParams gets a number of data items retrieved by a parameterized filter.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- filter            (optional) a WHERE clause with parameter placeholders
+//		- params            (optional) values of the filter parameters
+//	Returns: number of items or error.
+func (c *PostgresPersistence[T]) GetCountByFilterWithParams(ctx context.Context, correlationId string,
+	filter string, params []any) (int64, error) {
+	return withRetries(ctx, c, correlationId, "GetCountByFilterWithParams", func() (int64, error) {
+		return c.getCountByFilterWithParams(ctx, correlationId, filter, params)
+	})
+}
+
+// getCountByFilterWithParams is a single attempt of GetCountByFilterWithParams.
+func (c *PostgresPersistence[T]) getCountByFilterWithParams(ctx context.Context, correlationId string,
+	filter string, params []any) (int64, error) {
+
+	filter, params, tenantErr := c.applyTenantFilter(ctx, correlationId, filter, params)
+	if tenantErr != nil {
+		return 0, tenantErr
+	}
+
+	query := "SELECT COUNT(*) AS count FROM " + c.composeFrom()
 	if len(filter) > 0 {
 		query += " WHERE " + filter
 	}
 
-	rows, err := c.Client.Query(ctx, query)
+	rows, err := c.queryRead(ctx, correlationId, query, params...)
 	if err != nil {
 		return 0, err
 	}
@@ -723,12 +1819,40 @@ func (c *PostgresPersistence[T]) GetCountByFilter(ctx context.Context, correlati
 func (c *PostgresPersistence[T]) GetListByFilter(ctx context.Context, correlationId string,
 	filter string, sort string, selection string) (items []T, err error) {
 
-	query := "SELECT * FROM " + c.QuotedTableName()
+	return c.GetListByFilterWithParams(ctx, correlationId, filter, nil, sort, selection)
+}
 
-	if len(selection) > 0 {
-		query = "SELECT " + selection + " FROM " + c.QuotedTableName()
+// GetListByFilterWithParams gets a list of data items retrieved by a parameterized filter
+// and sorted according to sort parameters.
+// When the context is created with NewContextWithRowLock the retrieved rows are locked
+// until the end of the transaction carried by the context.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId    (optional) transaction id to trace execution through call chain.
+//		- filter           (optional) a WHERE clause with parameter placeholders
+//		- params           (optional) values of the filter parameters
+//		- sort             (optional) ORDER BY clause composed by ComposeSort
+//		- select           (optional) SELECT list composed by ComposeSelect
+//	Returns: data list or error.
+func (c *PostgresPersistence[T]) GetListByFilterWithParams(ctx context.Context, correlationId string,
+	filter string, params []any, sort string, selection string) (items []T, err error) {
+	return withRetries(ctx, c, correlationId, "GetListByFilterWithParams", func() ([]T, error) {
+		return c.getListByFilterWithParams(ctx, correlationId, filter, params, sort, selection)
+	})
+}
+
+// getListByFilterWithParams is a single attempt of GetListByFilterWithParams.
+func (c *PostgresPersistence[T]) getListByFilterWithParams(ctx context.Context, correlationId string,
+	filter string, params []any, sort string, selection string) (items []T, err error) {
+
+	filter, params, tenantErr := c.applyTenantFilter(ctx, correlationId, filter, params)
+	if tenantErr != nil {
+		return nil, tenantErr
 	}
 
+	query := "SELECT " + c.composeSelection(selection) + " FROM " + c.composeFrom()
+
 	if len(filter) > 0 {
 		query += " WHERE " + filter
 	}
@@ -737,19 +1861,25 @@ func (c *PostgresPersistence[T]) GetListByFilter(ctx context.Context, correlatio
 		query += " ORDER BY " + sort
 	}
 
-	rows, err := c.Client.Query(ctx, query)
+	if lock, ok := RowLockFromContext(ctx); ok {
+		lockClause, lockErr := c.composeRowLock(ctx, correlationId, lock)
+		if lockErr != nil {
+			return nil, lockErr
+		}
+		query += lockClause
+	}
+
+	rows, err := c.queryRead(ctx, correlationId, query, params...)
 	if err != nil {
-		return nil, err
+		return nil, c.wrapRowLockError(correlationId, err)
 	}
 	defer rows.Close()
 
 	items = make([]T, 0, 1)
 	for rows.Next() {
-		if c.IsTerminated() {
+		if err := c.checkInterrupted(ctx, correlationId); err != nil {
 			rows.Close()
-			return nil, cerr.
-				NewError("query terminated").
-				WithCorrelationId(correlationId)
+			return nil, err
 		}
 		item, convErr := c.Overrides.ConvertToPublic(rows)
 		if convErr != nil {
@@ -762,44 +1892,290 @@ func (c *PostgresPersistence[T]) GetListByFilter(ctx context.Context, correlatio
 		c.Logger.Trace(ctx, correlationId, "Retrieved %d from %s", len(items), c.TableName)
 	}
 
-	return items, rows.Err()
+	return items, c.wrapRowLockError(correlationId, rows.Err())
 }
 
-// GetOneRandom gets a random item from items that match to a given filter.
-// This method shall be called by a func (c * PostgresPersistence) getOneRandom method from child class that
-// receives FilterParams and converts them into a filter function.
+// ExistsByFilter checks if there is at least one data item that matches to a given filter.
+// It does not retrieve and convert data items.
 //
 //	Parameters:
 //		- ctx context.Context
 //		- correlationId     (optional) transaction id to trace execution through call chain.
 //		- filter            (optional) a filter JSON object
-//	Returns: random item or error.
-func (c *PostgresPersistence[T]) GetOneRandom(ctx context.Context, correlationId string, filter string) (item T, err error) {
-	count, err := c.GetCountByFilter(ctx, correlationId, filter)
+//	Returns: true if items exist or error.
+func (c *PostgresPersistence[T]) ExistsByFilter(ctx context.Context, correlationId string, filter string) (bool, error) {
+	return c.ExistsByFilterWithParams(ctx, correlationId, filter, nil)
+}
+
+// ExistsByFilterWithParams checks if there is at least one data item that matches to a parameterized filter.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- filter            (optional) a WHERE clause with parameter placeholders
+//		- params            (optional) values of the filter parameters
+//	Returns: true if items exist or error.
+func (c *PostgresPersistence[T]) ExistsByFilterWithParams(ctx context.Context, correlationId string,
+	filter string, params []any) (bool, error) {
+	return withRetries(ctx, c, correlationId, "ExistsByFilterWithParams", func() (bool, error) {
+		return c.existsByFilterWithParams(ctx, correlationId, filter, params)
+	})
+}
+
+// existsByFilterWithParams is a single attempt of ExistsByFilterWithParams.
+func (c *PostgresPersistence[T]) existsByFilterWithParams(ctx context.Context, correlationId string,
+	filter string, params []any) (bool, error) {
+
+	filter, params, tenantErr := c.applyTenantFilter(ctx, correlationId, filter, params)
+	if tenantErr != nil {
+		return false, tenantErr
+	}
+
+	query := "SELECT 1 FROM " + c.composeFrom()
+	if len(filter) > 0 {
+		query += " WHERE " + filter
+	}
+	query += " LIMIT 1"
+
+	rows, err := c.queryRead(ctx, correlationId, query, params...)
 	if err != nil {
-		return item, err
+		return false, err
 	}
-	if count == 0 {
-		c.Logger.Trace(ctx, correlationId, "Can't retriev random item from %s. Table is empty.", c.TableName)
-		return item, nil
+	defer rows.Close()
+
+	exists := rows.Next()
+	return exists, rows.Err()
+}
+
+// GetDistinctByField gets unique values of the field in data items that match to a given filter.
+// Values are sorted in ascending order and null values are excluded.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- field             a field name, in JSON persistences it refers to a field of the data column.
+//		- filter            (optional) a filter JSON object
+//	Returns: a list of unique values or error.
+func (c *PostgresPersistence[T]) GetDistinctByField(ctx context.Context, correlationId string,
+	field string, filter string) ([]any, error) {
+
+	return c.GetDistinctByFieldWithParams(ctx, correlationId, field, filter, nil)
+}
+
+// GetDistinctByFieldWithParams gets unique values of the field in data items that match to a parameterized filter.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- field             a field name, in JSON persistences it refers to a field of the data column.
+//		- filter            (optional) a WHERE clause with parameter placeholders
+//		- params            (optional) values of the filter parameters
+//	Returns: a list of unique values or error.
+func (c *PostgresPersistence[T]) GetDistinctByFieldWithParams(ctx context.Context, correlationId string,
+	field string, filter string, params []any) ([]any, error) {
+	return withRetries(ctx, c, correlationId, "GetDistinctByFieldWithParams", func() ([]any, error) {
+		return c.getDistinctByFieldWithParams(ctx, correlationId, field, filter, params)
+	})
+}
+
+// getDistinctByFieldWithParams is a single attempt of GetDistinctByFieldWithParams.
+func (c *PostgresPersistence[T]) getDistinctByFieldWithParams(ctx context.Context, correlationId string,
+	field string, filter string, params []any) ([]any, error) {
+
+	filter, params, tenantErr := c.applyTenantFilter(ctx, correlationId, filter, params)
+	if tenantErr != nil {
+		return nil, tenantErr
 	}
-	if c.IsTerminated() {
-		return item, cerr.
-			NewError("query terminated").
-			WithCorrelationId(correlationId)
+
+	expr := c.ComposeField(field)
+	query := "SELECT DISTINCT " + expr + " AS value FROM " + c.composeFrom() + " WHERE " + expr + " IS NOT NULL"
+	if len(filter) > 0 {
+		query += " AND (" + filter + ")"
+	}
+	query += " ORDER BY value"
+
+	rows, err := c.queryRead(ctx, correlationId, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make([]any, 0)
+	for rows.Next() {
+		if err := c.checkInterrupted(ctx, correlationId); err != nil {
+			return nil, err
+		}
+		rowValues, err := rows.Values()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, rowValues[0])
+	}
+
+	c.Logger.Trace(ctx, correlationId, "Retrieved %d distinct values of %s from %s", len(values), field, c.TableName)
+	return values, rows.Err()
+}
+
+// GetAggregateByFilter calculates aggregate values over groups of data items that match to a parameterized filter.
+// Groups are sorted by values of the group-by fields. Without group-by fields a single row is returned.
+// In JSON persistences fields refer to fields of the data column and are cast to numeric for SUM and AVG.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- filter            (optional) a WHERE clause with parameter placeholders
+//		- params            (optional) values of the filter parameters
+//		- groupBy           (optional) fields to group data items by
+//		- aggregates        aggregate functions to calculate
+//	Returns: a list of rows with group-by fields and aggregate values or error.
+func (c *PostgresPersistence[T]) GetAggregateByFilter(ctx context.Context, correlationId string,
+	filter string, params []any, groupBy []string, aggregates []PostgresAggregate) ([]map[string]any, error) {
+	return withRetries(ctx, c, correlationId, "GetAggregateByFilter", func() ([]map[string]any, error) {
+		return c.getAggregateByFilter(ctx, correlationId, filter, params, groupBy, aggregates)
+	})
+}
+
+// getAggregateByFilter is a single attempt of GetAggregateByFilter.
+func (c *PostgresPersistence[T]) getAggregateByFilter(ctx context.Context, correlationId string,
+	filter string, params []any, groupBy []string, aggregates []PostgresAggregate) ([]map[string]any, error) {
+
+	if len(aggregates) == 0 {
+		return nil, cerr.NewBadRequestError(correlationId, "NO_AGGREGATES", "At least one aggregate function must be set")
+	}
+
+	filter, params, tenantErr := c.applyTenantFilter(ctx, correlationId, filter, params)
+	if tenantErr != nil {
+		return nil, tenantErr
+	}
+
+	selection := make([]string, 0, len(groupBy)+len(aggregates))
+	groups := make([]string, 0, len(groupBy))
+	for _, field := range groupBy {
+		expr := c.ComposeField(field)
+		selection = append(selection, expr+" AS "+Column(field))
+		groups = append(groups, expr)
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	pos := rand.Int63n(int64(count))
+	for _, aggregate := range aggregates {
+		if !aggregate.isValid() {
+			return nil, cerr.NewBadRequestError(correlationId, "INVALID_AGGREGATE",
+				"Aggregate function "+aggregate.Function+" is not supported").
+				WithDetails("function", aggregate.Function).
+				WithDetails("field", aggregate.Field)
+		}
+
+		function := strings.ToUpper(aggregate.Function)
+		expr := "*"
+		if function != "COUNT" {
+			expr = c.ComposeField(aggregate.Field)
+			if c.jsonColumn != "" && aggregate.Field != c.idColumn && (function == "SUM" || function == "AVG") {
+				expr = "(" + expr + ")::numeric"
+			}
+		}
+		selection = append(selection, function+"("+expr+") AS "+Column(aggregate.alias()))
+	}
 
-	// build query
-	query := "SELECT * FROM " + c.QuotedTableName()
+	query := "SELECT " + strings.Join(selection, ",") + " FROM " + c.composeFrom()
 	if len(filter) > 0 {
 		query += " WHERE " + filter
 	}
-	query += " OFFSET " + strconv.FormatInt(pos, 10) + " LIMIT 1"
+	if len(groups) > 0 {
+		query += " GROUP BY " + strings.Join(groups, ",") + " ORDER BY " + strings.Join(groups, ",")
+	}
+
+	rows, err := c.queryRead(ctx, correlationId, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := rows.FieldDescriptions()
+	result := make([]map[string]any, 0)
+	for rows.Next() {
+		if err := c.checkInterrupted(ctx, correlationId); err != nil {
+			return nil, err
+		}
+		values, err := rows.Values()
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]any, len(columns))
+		for index, column := range columns {
+			row[column.Name] = values[index]
+		}
+		result = append(result, row)
+	}
+
+	c.Logger.Trace(ctx, correlationId, "Aggregated %d groups in %s", len(result), c.TableName)
+	return result, rows.Err()
+}
+
+// GetOneRandom gets a random item from items that match to a given filter.
+// This method shall be called by a func (c * PostgresPersistence) getOneRandom method from child class that
+// receives FilterParams and converts them into a filter function.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- filter            (optional) a filter JSON object
+//	Returns: random item or error.
+func (c *PostgresPersistence[T]) GetOneRandom(ctx context.Context, correlationId string, filter string) (item T, err error) {
+	return c.GetOneRandomWithParams(ctx, correlationId, filter, nil)
+}
+
+// GetOneRandomWithParams gets a random item from items that match to a parameterized filter.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- filter            (optional) a WHERE clause with parameter placeholders
+//		- params            (optional) values of the filter parameters
+//	Returns: random item or error.
+func (c *PostgresPersistence[T]) GetOneRandomWithParams(ctx context.Context, correlationId string,
+	filter string, params []any) (item T, err error) {
+	return withRetries(ctx, c, correlationId, "GetOneRandomWithParams", func() (T, error) {
+		return c.getOneRandomWithParams(ctx, correlationId, filter, params)
+	})
+}
+
+// getOneRandomWithParams is a single attempt of GetOneRandomWithParams.
+func (c *PostgresPersistence[T]) getOneRandomWithParams(ctx context.Context, correlationId string,
+	filter string, params []any) (item T, err error) {
+
+	// The count query applies the tenant filter itself
+	randomFilter, randomParams, tenantErr := c.applyTenantFilter(ctx, correlationId, filter, params)
+	if tenantErr != nil {
+		return item, tenantErr
+	}
+
+	var query string
+	if c.randomMethod == RandomMethodOrder || c.randomMethod == RandomMethodSample {
+		query = c.composeRandomQuery(randomFilter)
+	} else {
+		count, err := c.getCountByFilterWithParams(ctx, correlationId, filter, params)
+		if err != nil {
+			return item, err
+		}
+		if count == 0 {
+			c.Logger.Trace(ctx, correlationId, "Can't retriev random item from %s. Table is empty.", c.TableName)
+			return item, nil
+		}
+		if err := c.checkInterrupted(ctx, correlationId); err != nil {
+			return item, err
+		}
+
+		rand.Seed(time.Now().UnixNano())
+		pos := rand.Int63n(int64(count))
+
+		// build query
+		query = "SELECT " + c.composeSelection("") + " FROM " + c.composeFrom()
+		if len(randomFilter) > 0 {
+			query += " WHERE " + randomFilter
+		}
+		query += " OFFSET " + strconv.FormatInt(pos, 10) + " LIMIT 1"
+	}
 
-	rows, err := c.Client.Query(ctx, query)
+	rows, err := c.queryRead(ctx, correlationId, query, randomParams...)
 	if err != nil {
 		return item, err
 	}
@@ -827,19 +2203,42 @@ func (c *PostgresPersistence[T]) GetOneRandom(ctx context.Context, correlationId
 //		- item              an item to be created.
 //	Returns: (optional) callback function that receives created item or error.
 func (c *PostgresPersistence[T]) Create(ctx context.Context, correlationId string, item T) (result T, err error) {
+	if item, err = c.beforeCreate(ctx, correlationId, item); err != nil {
+		return result, err
+	}
+	if result, err = c.create(ctx, correlationId, item); err != nil {
+		return result, err
+	}
+	return result, c.afterCreate(ctx, correlationId, result)
+}
+
+// create inserts the item without calling interceptors.
+func (c *PostgresPersistence[T]) create(ctx context.Context, correlationId string, item T) (result T, err error) {
+	if err := c.inferColumns(ctx, correlationId, item); err != nil {
+		return result, err
+	}
 	objMap, convErr := c.Overrides.ConvertFromPublic(item)
 	if convErr != nil {
 		return result, convErr
 	}
+	c.omitGeneratedId(objMap)
+	if err := c.applyTenantValue(ctx, correlationId, objMap); err != nil {
+		return result, err
+	}
 	columns, values := c.GenerateColumnsAndValues(objMap)
 
-	columnsStr := c.GenerateColumns(columns)
-	paramsStr := c.GenerateParameters(len(values))
+	query := c.getCreateStatement(columns)
 
-	query := "INSERT INTO " + c.QuotedTableName() +
-		" (" + columnsStr + ") VALUES (" + paramsStr + ") RETURNING *"
+	// Generated ids are known only from RETURNING
+	if c.skipReturning && !c.generatesIds() {
+		if _, err = c.exec(ctx, correlationId, strings.TrimSuffix(query, " RETURNING *"), values...); err != nil {
+			return result, err
+		}
+		c.Logger.Trace(ctx, correlationId, "Created in %s with id = %s", c.TableName, GetObjectId[any](item))
+		return item, nil
+	}
 
-	rows, err := c.Client.Query(ctx, query, values...)
+	rows, err := c.query(ctx, correlationId, query, values...)
 	if err != nil {
 		return result, err
 	}
@@ -858,6 +2257,235 @@ func (c *PostgresPersistence[T]) Create(ctx context.Context, correlationId strin
 	return result, nil
 }
 
+// getCreateStatement returns a cached INSERT statement for the set of columns.
+func (c *PostgresPersistence[T]) getCreateStatement(columns []string) string {
+	return c.GetStatement("create:"+strings.Join(columns, ","), func() string {
+		columnsStr := c.GenerateColumns(columns)
+		paramsStr := c.GenerateParameters(len(columns))
+
+		return "INSERT INTO " + c.QuotedTableName() +
+			" (" + columnsStr + ") VALUES (" + paramsStr + ") RETURNING *"
+	})
+}
+
+// insertMany inserts data items in multi-row INSERT statements and returns the resulting rows.
+// Consecutive items with the same set of columns are inserted together in chunks of up to MaxBatchSize items.
+// The conflict clause, if set, is built for the set of columns and appended to each statement.
+func (c *PostgresPersistence[T]) insertMany(ctx context.Context, correlationId string, objMaps []map[string]any,
+	conflictClause func(columns []string) string) ([]T, error) {
+
+	for _, objMap := range objMaps {
+		if err := c.applyTenantValue(ctx, correlationId, objMap); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]T, 0, len(objMaps))
+	for start := 0; start < len(objMaps); {
+		columns, values := c.GenerateColumnsAndValues(objMaps[start])
+		columnsKey := strings.Join(columns, ",")
+
+		chunkSize := c.MaxBatchSize
+		if len(columns) > 0 && chunkSize > maxStatementParameters/len(columns) {
+			chunkSize = maxStatementParameters / len(columns)
+		}
+		if chunkSize < 1 {
+			chunkSize = 1
+		}
+
+		end := start + 1
+		for end < len(objMaps) && end-start < chunkSize {
+			nextColumns, nextValues := c.GenerateColumnsAndValues(objMaps[end])
+			if strings.Join(nextColumns, ",") != columnsKey {
+				break
+			}
+			values = append(values, nextValues...)
+			end++
+		}
+
+		query := "INSERT INTO " + c.QuotedTableName() + " (" + c.GenerateColumns(columns) + ")" +
+			" VALUES " + c.generateRowsParameters(len(columns), end-start)
+		if conflictClause != nil {
+			query += conflictClause(columns)
+		}
+		query += " RETURNING *"
+
+		items, err := c.queryItems(ctx, correlationId, query, values...)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, items...)
+		start = end
+	}
+	return results, nil
+}
+
+// queryItems executes a query and converts all returned rows into data items.
+func (c *PostgresPersistence[T]) queryItems(ctx context.Context, correlationId string, query string, args ...any) ([]T, error) {
+	rows, err := c.query(ctx, correlationId, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]T, 0)
+	for rows.Next() {
+		if err := c.checkInterrupted(ctx, correlationId); err != nil {
+			return nil, err
+		}
+		item, convErr := c.Overrides.ConvertToPublic(rows)
+		if convErr != nil {
+			return nil, convErr
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// generateRowsParameters generates a list of value rows for a multi-row INSERT, e.g. ($1,$2),($3,$4).
+func (c *PostgresPersistence[T]) generateRowsParameters(columnsCount int, rowsCount int) string {
+	builder := strings.Builder{}
+	index := 1
+	for row := 0; row < rowsCount; row++ {
+		if row > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString("(")
+		for column := 0; column < columnsCount; column++ {
+			if column > 0 {
+				builder.WriteString(",")
+			}
+			builder.WriteString("$")
+			builder.WriteString(strconv.Itoa(index))
+			index++
+		}
+		builder.WriteString(")")
+	}
+	return builder.String()
+}
+
+// NewBatch creates a new batch to queue multiple statements and send them in a single round trip.
+//
+//	Returns: *PostgresBatch[T]
+func (c *PostgresPersistence[T]) NewBatch() *PostgresBatch[T] {
+	return &PostgresBatch[T]{}
+}
+
+// QueueCreate adds creation of a data item to the batch.
+//
+//	Parameters:
+//		- batch a batch to add the statement to.
+//		- item an item to be created.
+//	Returns: error if the item can't be converted.
+func (c *PostgresPersistence[T]) QueueCreate(batch *PostgresBatch[T], item T) error {
+	objMap, err := c.Overrides.ConvertFromPublic(item)
+	if err != nil {
+		return err
+	}
+	c.omitGeneratedId(objMap)
+	columns, values := c.GenerateColumnsAndValues(objMap)
+	batch.Queue(c.getCreateStatement(columns), values...)
+	return nil
+}
+
+// ExecuteBatch sends all queued statements to the database in a single round trip.
+// Results are returned in the same order as the statements were queued.
+// Errors of individual statements are returned in the results, while the error
+// is returned only when the batch can't be sent.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- batch a batch with queued statements.
+//	Returns: results of the statements or error.
+func (c *PostgresPersistence[T]) ExecuteBatch(ctx context.Context, correlationId string,
+	batch *PostgresBatch[T]) ([]PostgresBatchResult[T], error) {
+
+	if batch == nil || batch.Len() == 0 {
+		return []PostgresBatchResult[T]{}, nil
+	}
+	defer c.forgetTable(ctx)
+	// Queued statements are composed without the context, so they can't be restricted to the tenant
+	if c.tenantColumn != "" {
+		return nil, cerr.NewInvalidStateError(correlationId, "TENANT_BATCH",
+			"Batches are not supported by "+c.TableName+" with options.tenant_column").
+			WithDetails("table", c.TableName)
+	}
+	if err := c.ensureOpen(ctx, correlationId); err != nil {
+		return nil, err
+	}
+
+	ctx = conn.NewContextWithCorrelationId(ctx, correlationId)
+	if c.queryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.queryTimeout)
+		defer cancel()
+	}
+
+	// Settings are set by leading statements and stay local to the implicit transaction of the batch
+	settings := c.sessionSettings(ctx, correlationId)
+	sendBatch := &batch.batch
+	if len(settings) > 0 {
+		sendBatch = &pgx.Batch{}
+		for _, setting := range settings {
+			sendBatch.Queue("SELECT set_config($1, $2, true)", setting.name, setting.value)
+		}
+		sendBatch.QueuedQueries = append(sendBatch.QueuedQueries, batch.batch.QueuedQueries...)
+	}
+
+	batchResults := c.GetClient(ctx).SendBatch(ctx, sendBatch)
+	defer batchResults.Close()
+
+	for range settings {
+		if _, err := batchResults.Exec(); err != nil {
+			return nil, c.wrapConnectionError(correlationId, err)
+		}
+	}
+
+	results := make([]PostgresBatchResult[T], batch.Len())
+	failed := 0
+	for index := range results {
+		results[index] = c.readBatchResult(batchResults)
+		if results[index].Err != nil {
+			if index == 0 && len(settings) == 0 && conn.IsConnectionError(results[index].Err) {
+				return nil, c.wrapConnectionError(correlationId, results[index].Err)
+			}
+			failed++
+		}
+	}
+
+	c.Logger.Trace(ctx, correlationId, "Executed batch of %d statements in %s with %d errors",
+		len(results), c.TableName, failed)
+	return results, nil
+}
+
+// readBatchResult reads a result of the next statement in the batch.
+func (c *PostgresPersistence[T]) readBatchResult(batchResults pgx.BatchResults) (result PostgresBatchResult[T]) {
+	rows, err := batchResults.Query()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		item, convErr := c.Overrides.ConvertToPublic(rows)
+		if convErr != nil {
+			result.Err = convErr
+			return result
+		}
+		result.Item = item
+		result.Found = true
+	}
+	rows.Close()
+	result.RowsAffected = rows.CommandTag().RowsAffected()
+	result.Err = rows.Err()
+	return result
+}
+
 // DeleteByFilter deletes data items that match to a given filter.
 // This method shall be called by a func (c * PostgresPersistence) deleteByFilter method from child class that
 // receives FilterParams and converts them into a filter function.
@@ -866,29 +2494,135 @@ func (c *PostgresPersistence[T]) Create(ctx context.Context, correlationId strin
 //		- ctx context.Context
 //		- correlationId     (optional) transaction id to trace execution through call chain.
 //		- filter            (optional) a filter JSON object.
-//	Returns: error or nil for success.
-func (c *PostgresPersistence[T]) DeleteByFilter(ctx context.Context, correlationId string, filter string) error {
+//	Returns: number of deleted items or error.
+func (c *PostgresPersistence[T]) DeleteByFilter(ctx context.Context, correlationId string, filter string) (int64, error) {
+	return c.DeleteByFilterWithParams(ctx, correlationId, filter, nil)
+}
+
+// DeleteByFilterWithParams deletes data items that match to a parameterized filter.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- filter            (optional) a WHERE clause with parameter placeholders
+//		- params            (optional) values of the filter parameters
+//	Returns: number of deleted items or error.
+func (c *PostgresPersistence[T]) DeleteByFilterWithParams(ctx context.Context, correlationId string,
+	filter string, params []any) (int64, error) {
+
+	defer c.forgetTable(ctx)
+	filter, params, tenantErr := c.applyTenantFilter(ctx, correlationId, filter, params)
+	if tenantErr != nil {
+		return 0, tenantErr
+	}
+
 	query := "DELETE FROM " + c.QuotedTableName()
 	if len(filter) > 0 {
 		query += " WHERE " + filter
 	}
 
-	rows, err := c.Client.Query(ctx, query)
+	tag, err := c.exec(ctx, correlationId, query, params...)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	defer rows.Close()
 
-	var count int64 = 0
-	if !rows.Next() {
-		return rows.Err()
+	count := tag.RowsAffected()
+	c.Logger.Trace(ctx, correlationId, "Deleted %d items from %s", count, c.TableName)
+	return count, nil
+}
+
+// RestoreByFilter restores soft-deleted data items that match to a given filter
+// by clearing their deletion marker in the column set by options.deleted_column.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- filter            (optional) a filter JSON object.
+//	Returns: restored items or error.
+func (c *PostgresPersistence[T]) RestoreByFilter(ctx context.Context, correlationId string, filter string) ([]T, error) {
+	return c.RestoreByFilterWithParams(ctx, correlationId, filter, nil)
+}
+
+// RestoreByFilterWithParams restores soft-deleted data items that match to a parameterized filter
+// by clearing their deletion marker in the column set by options.deleted_column.
+// Items which are not deleted are not changed and not returned.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- filter            (optional) a WHERE clause with parameter placeholders
+//		- params            (optional) values of the filter parameters
+//	Returns: restored items or error.
+func (c *PostgresPersistence[T]) RestoreByFilterWithParams(ctx context.Context, correlationId string,
+	filter string, params []any) ([]T, error) {
+
+	defer c.forgetTable(ctx)
+	if c.deletedColumn == "" {
+		return nil, cerr.NewConfigError(correlationId, "NO_DELETED_COLUMN",
+			"Column of the deletion marker is not set in options.deleted_column")
 	}
-	values, _ := rows.Values()
-	if len(values) == 1 {
-		count = cconv.LongConverter.ToLong(values[0])
+
+	filter, params, tenantErr := c.applyTenantFilter(ctx, correlationId, filter, params)
+	if tenantErr != nil {
+		return nil, tenantErr
 	}
-	c.Logger.Trace(ctx, correlationId, "Deleted %d items from %s", count, c.TableName)
-	return nil
+
+	column := c.QuoteIdentifier(c.deletedColumn)
+	query := "UPDATE " + c.QuotedTableName() + " SET " + column + "=NULL WHERE " + column + " IS NOT NULL"
+	if len(filter) > 0 {
+		query += " AND (" + filter + ")"
+	}
+	query += " RETURNING *"
+
+	items, err := c.queryItems(ctx, correlationId, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	c.Logger.Trace(ctx, correlationId, "Restored %d items in %s", len(items), c.TableName)
+	return items, nil
+}
+
+// ExecuteQuery executes an arbitrary parameterized query and converts returned rows into data items.
+// The query must return columns expected by ConvertToPublic method.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- query             an SQL query with parameter placeholders ($1, $2, ...)
+//		- args              (optional) values of the query parameters
+//	Returns: a list of data items or error.
+func (c *PostgresPersistence[T]) ExecuteQuery(ctx context.Context, correlationId string,
+	query string, args ...any) ([]T, error) {
+
+	items, err := c.queryItems(ctx, correlationId, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(ctx, correlationId, "Retrieved %d items by query from %s", len(items), c.TableName)
+	return items, nil
+}
+
+// ExecuteNonQuery executes an arbitrary parameterized statement which does not return rows.
+// Items of the table are removed from the identity map of the context, see NewContextWithIdentityMap.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- query             an SQL statement with parameter placeholders ($1, $2, ...)
+//		- args              (optional) values of the statement parameters
+//	Returns: number of affected rows or error.
+func (c *PostgresPersistence[T]) ExecuteNonQuery(ctx context.Context, correlationId string,
+	query string, args ...any) (int64, error) {
+
+	defer c.forgetTable(ctx)
+	tag, err := c.exec(ctx, correlationId, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	c.Logger.Trace(ctx, correlationId, "Executed statement affecting %d rows in %s", tag.RowsAffected(), c.TableName)
+	return tag.RowsAffected(), nil
 }
 
 func (c *PostgresPersistence[T]) cloneItem(item any) T {
@@ -900,3 +2634,78 @@ func (c *PostgresPersistence[T]) cloneItem(item any) T {
 	newItem, _ := c.JsonConvertor.FromJson(strObject)
 	return newItem
 }
+
+// timeoutRows cancels the query context with the timeout and stops the termination callback when the rows are closed.
+type timeoutRows[T any] struct {
+	pgx.Rows
+	persistence   *PostgresPersistence[T]
+	ctx           context.Context
+	timeoutCtx    context.Context
+	terminated    context.Context
+	cancel        context.CancelFunc
+	correlationId string
+}
+
+func (r *timeoutRows[T]) Close() {
+	r.Rows.Close()
+	r.cancel()
+}
+
+func (r *timeoutRows[T]) Err() error {
+	return r.persistence.wrapTimeoutError(r.ctx, r.timeoutCtx, r.terminated, r.correlationId, r.Rows.Err())
+}
+
+// searchPath returns the search_path value which resolves unqualified names in the persistence schema.
+// The public schema stays in the path to resolve functions of extensions installed there.
+func (c *PostgresPersistence[T]) searchPath() string {
+	return c.QuoteIdentifier(c.SchemaName) + ", public"
+}
+
+// sessionSetting is a run-time setting set for the time of the query.
+type sessionSetting struct {
+	name  string
+	value string
+}
+
+// taggedRows releases the tagged connection back to the pool when the rows are closed.
+type taggedRows struct {
+	pgx.Rows
+	conn     *pgxpool.Conn
+	settings []sessionSetting
+	once     sync.Once
+}
+
+func (r *taggedRows) Close() {
+	r.Rows.Close()
+	r.once.Do(func() {
+		resetSessionSettings(r.conn, r.settings)
+	})
+}
+
+// resetSessionSettings restores default values of the settings and returns the connection to the pool.
+func resetSessionSettings(poolConn *pgxpool.Conn, settings []sessionSetting) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// Statements without parameters are sent by the simple protocol, so all settings are reset in one round trip
+	statements := make([]string, 0, len(settings))
+	for _, setting := range settings {
+		statements = append(statements, "RESET "+pgx.Identifier(strings.Split(setting.name, ".")).Sanitize())
+	}
+	if _, err := poolConn.Exec(ctx, strings.Join(statements, "; ")); err != nil {
+		// Do not return the connection with stale settings to the pool
+		_ = poolConn.Conn().Close(ctx)
+	}
+	poolConn.Release()
+}
+
+// composeSetConfig returns a statement which sets all the settings in one round trip,
+// for the current transaction only when local is true.
+func composeSetConfig(settings []sessionSetting, local bool) (string, []any) {
+	calls := make([]string, 0, len(settings))
+	params := make([]any, 0, len(settings)*2)
+	for _, setting := range settings {
+		calls = append(calls, "set_config($"+strconv.Itoa(len(params)+1)+", $"+strconv.Itoa(len(params)+2)+", "+strconv.FormatBool(local)+")")
+		params = append(params, setting.name, setting.value)
+	}
+	return "SELECT " + strings.Join(calls, ", "), params
+}
@@ -0,0 +1,49 @@
+package persistence
+
+import (
+	"strconv"
+)
+
+// Methods to select random items by GetOneRandom.
+const (
+	// RandomMethodOffset counts matching items and reads one at a random offset. It takes two queries.
+	RandomMethodOffset = "offset"
+	// RandomMethodOrder sorts matching items in random order in one query. It reads all matching rows.
+	RandomMethodOrder = "order"
+	// RandomMethodSample reads matching items from a random sample of table pages in one query
+	// and falls back to random order when the sample has no matching items. It's the fastest method
+	// for large tables, but items in the same pages are selected together more often.
+	RandomMethodSample = "sample"
+)
+
+// DefaultRandomSamplePercent is a default percentage of table pages sampled by RandomMethodSample.
+const DefaultRandomSamplePercent = 1.0
+
+// composeRandomQuery returns a single query which selects a random item by RandomMethodOrder
+// or RandomMethodSample methods.
+func (c *PostgresPersistence[T]) composeRandomQuery(filter string) string {
+	where := ""
+	if len(filter) > 0 {
+		where = " WHERE " + filter
+	}
+	joins := ""
+	for _, join := range c.joins {
+		joins += join.composeClause()
+	}
+	selection := "SELECT " + c.composeSelection("") + " FROM " + c.QuotedTableName()
+	ordered := selection + joins + where + " ORDER BY random() LIMIT 1"
+
+	if c.randomMethod != RandomMethodSample {
+		return ordered
+	}
+
+	percent := c.samplePercent
+	if percent <= 0 || percent > 100 {
+		percent = DefaultRandomSamplePercent
+	}
+	sampled := selection + " TABLESAMPLE SYSTEM (" + strconv.FormatFloat(percent, 'f', -1, 64) + ")" +
+		joins + where + " ORDER BY random() LIMIT 1"
+
+	// The second branch is executed only when the sample returns nothing
+	return "SELECT * FROM ((" + sampled + ") UNION ALL (" + ordered + ")) AS random LIMIT 1"
+}
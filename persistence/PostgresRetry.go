@@ -0,0 +1,94 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+)
+
+// Default retry policy for transient errors.
+const (
+	DefaultMaxRetries   = 0
+	DefaultRetryTimeout = 100 * time.Millisecond
+)
+
+// IsTransientError checks if an operation failed because of a transient error and can be retried:
+// a serialization failure (40001), a deadlock (40P01) or a lost connection.
+//
+//	Parameters:
+//		- err an error to check.
+//	Returns: true if the operation can be retried.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if pgErr.Code == "40001" || pgErr.Code == "40P01" {
+			return true
+		}
+	}
+
+	var appErr *cerr.ApplicationError
+	if errors.As(err, &appErr) {
+		return appErr.Code == "CONNECTION_FAILED"
+	}
+
+	return conn.IsConnectionError(err)
+}
+
+// retry executes an idempotent action and repeats it with exponential backoff while it fails with transient errors.
+// Actions within explicit transactions are not retried, because a failed statement aborts the whole transaction.
+func (c *PostgresPersistence[T]) retry(ctx context.Context, correlationId string, operation string, action func() error) error {
+	if _, ok := conn.TransactionFromContext(ctx); ok {
+		return action()
+	}
+
+	timeout := c.retryTimeout
+	for attempt := 1; ; attempt++ {
+		err := action()
+		if err == nil || attempt > c.maxRetries || !IsTransientError(err) {
+			return err
+		}
+
+		c.Logger.Debug(ctx, correlationId, "Retrying %s in %s after transient error (attempt %d of %d): %s",
+			operation, c.TableName, attempt, c.maxRetries, err.Error())
+		c.Counters.IncrementOne(ctx, "postgres."+c.TableName+".retries")
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(timeout):
+		}
+		timeout *= 2
+	}
+}
+
+// withRetries executes an idempotent action which returns a value and repeats it while it fails with transient errors.
+func withRetries[T any, R any](ctx context.Context, c *PostgresPersistence[T], correlationId string,
+	operation string, action func() (R, error)) (result R, err error) {
+
+	err = c.retry(ctx, correlationId, operation, func() error {
+		var actionErr error
+		result, actionErr = action()
+		return actionErr
+	})
+	return result, err
+}
+
+// withRetriesIf executes the action with retries only when it is idempotent. Non-idempotent actions,
+// e.g. updates which increment the version, run once, because a retry after a committed attempt
+// whose reply was lost would apply them twice.
+func withRetriesIf[T any, R any](ctx context.Context, c *PostgresPersistence[T], correlationId string,
+	operation string, idempotent bool, action func() (R, error)) (R, error) {
+
+	if !idempotent {
+		return action()
+	}
+	return withRetries(ctx, c, correlationId, operation, action)
+}
@@ -0,0 +1,120 @@
+package persistence
+
+import (
+	"context"
+	"regexp"
+)
+
+var returningPattern = regexp.MustCompile(`(?i)\bRETURNING\b`)
+
+// withReturning appends RETURNING * to the statement unless it already has a RETURNING clause.
+func withReturning(query string) string {
+	if returningPattern.MatchString(query) {
+		return query
+	}
+	return query + " RETURNING *"
+}
+
+// ExecuteReturning executes an INSERT, UPDATE or DELETE statement which may affect many rows
+// and converts all rows returned by its RETURNING clause into data items.
+// RETURNING * is appended when the statement has no RETURNING clause.
+//
+//	Example:
+//		items, err := c.ExecuteReturning(ctx, correlationId,
+//			"UPDATE "+c.QuotedTableName()+" SET \"content\"=$1 WHERE \"key\"=$2", content, key)
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- query             an SQL statement with parameter placeholders ($1, $2, ...)
+//		- args              (optional) values of the statement parameters
+//	Returns: changed data items or error.
+func (c *PostgresPersistence[T]) ExecuteReturning(ctx context.Context, correlationId string,
+	query string, args ...any) ([]T, error) {
+
+	defer c.forgetTable(ctx)
+	items, err := c.queryItems(ctx, correlationId, withReturning(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(ctx, correlationId, "Changed %d items in %s", len(items), c.TableName)
+	return items, nil
+}
+
+// ExecuteReturningEach executes an INSERT, UPDATE or DELETE statement which may affect many rows
+// and streams rows returned by its RETURNING clause converted into data items to the callback,
+// so large bulk operations don't keep all changed items in memory.
+// RETURNING * is appended when the statement has no RETURNING clause.
+// The statement is applied entirely even when the callback stops the iteration with an error.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- query             an SQL statement with parameter placeholders ($1, $2, ...)
+//		- args              (optional) values of the statement parameters
+//		- callback          a function called for every changed item. Returned error stops the iteration.
+//	Returns: number of passed items or error.
+func (c *PostgresPersistence[T]) ExecuteReturningEach(ctx context.Context, correlationId string,
+	query string, args []any, callback func(item T) error) (int64, error) {
+
+	defer c.forgetTable(ctx)
+	rows, err := c.query(ctx, correlationId, withReturning(query), args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var count int64
+	for rows.Next() {
+		if err := c.checkInterrupted(ctx, correlationId); err != nil {
+			return count, err
+		}
+		item, convErr := c.Overrides.ConvertToPublic(rows)
+		if convErr != nil {
+			return count, convErr
+		}
+		if err := callback(item); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+
+	c.Logger.Trace(ctx, correlationId, "Changed %d items in %s", count, c.TableName)
+	return count, nil
+}
+
+// DeleteByFilterReturning deletes data items that match to a parameterized filter
+// and returns the deleted items.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- filter            (optional) a WHERE clause with parameter placeholders
+//		- params            (optional) values of the filter parameters
+//	Returns: deleted items or error.
+func (c *PostgresPersistence[T]) DeleteByFilterReturning(ctx context.Context, correlationId string,
+	filter string, params []any) ([]T, error) {
+
+	defer c.forgetTable(ctx)
+	filter, params, tenantErr := c.applyTenantFilter(ctx, correlationId, filter, params)
+	if tenantErr != nil {
+		return nil, tenantErr
+	}
+
+	query := "DELETE FROM " + c.QuotedTableName()
+	if len(filter) > 0 {
+		query += " WHERE " + filter
+	}
+
+	items, err := c.queryItems(ctx, correlationId, query+" RETURNING *", params...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(ctx, correlationId, "Deleted %d items from %s", len(items), c.TableName)
+	return items, nil
+}
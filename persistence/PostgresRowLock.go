@@ -0,0 +1,94 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+)
+
+// Row locks acquired by reading queries within transactions.
+const (
+	// RowLockForUpdate locks the selected rows against concurrent changes and waits for other locks.
+	RowLockForUpdate = "FOR UPDATE"
+	// RowLockForUpdateNoWait locks the selected rows or fails immediately when any of them is locked.
+	RowLockForUpdateNoWait = "FOR UPDATE NOWAIT"
+	// RowLockForUpdateSkipLocked locks the selected rows skipping rows which are already locked.
+	RowLockForUpdateSkipLocked = "FOR UPDATE SKIP LOCKED"
+	// RowLockForShare locks the selected rows against concurrent changes, but allows other shared locks.
+	RowLockForShare = "FOR SHARE"
+)
+
+type rowLockContextKey struct{}
+
+// NewContextWithRowLock creates a child context which makes GetListByFilter and GetListByFilterWithParams
+// calls lock the retrieved rows. The context must carry a transaction started by PostgresConnection,
+// because locks are held only until the end of the transaction.
+//
+//	Example:
+//		ctx = persist.NewContextWithRowLock(ctx, persist.RowLockForUpdateSkipLocked)
+//		items, err := persistence.GetListByFilter(ctx, correlationId, filter, sort, "")
+//
+//	Parameters:
+//		- ctx context.Context
+//		- lock a row lock: RowLockForUpdate, RowLockForUpdateNoWait, RowLockForUpdateSkipLocked or RowLockForShare.
+//	Returns: a new context.Context
+func NewContextWithRowLock(ctx context.Context, lock string) context.Context {
+	return context.WithValue(ctx, rowLockContextKey{}, lock)
+}
+
+// RowLockFromContext retrieves a row lock previously bound to the context.
+//
+//	Parameters:
+//		- ctx context.Context
+//	Returns: the row lock and true if it was found or an empty string and false otherwise.
+func RowLockFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	lock, ok := ctx.Value(rowLockContextKey{}).(string)
+	return lock, ok && lock != ""
+}
+
+// composeRowLock returns the locking clause for a query of the persistence table.
+// When tables are joined only rows of the persistence table are locked.
+//
+//	Returns: the locking clause or an error when the lock is unknown or there is no transaction.
+func (c *PostgresPersistence[T]) composeRowLock(ctx context.Context, correlationId string, lock string) (string, error) {
+	switch lock {
+	case RowLockForUpdate, RowLockForUpdateNoWait, RowLockForUpdateSkipLocked, RowLockForShare:
+	default:
+		return "", cerr.NewBadRequestError(correlationId, "INVALID_ROW_LOCK", "Row lock "+lock+" is not supported").
+			WithDetails("lock", lock)
+	}
+
+	if _, ok := conn.TransactionFromContext(ctx); !ok {
+		return "", cerr.NewInvalidStateError(correlationId, "NO_TRANSACTION",
+			"Rows in "+c.TableName+" can be locked only within a transaction")
+	}
+
+	if len(c.joins) == 0 {
+		return " " + lock, nil
+	}
+	// The locking strength goes first, the waiting policy after the list of tables
+	strength, policy := lock, ""
+	for _, suffix := range []string{" NOWAIT", " SKIP LOCKED"} {
+		if strings.HasSuffix(lock, suffix) {
+			strength, policy = strings.TrimSuffix(lock, suffix), suffix
+		}
+	}
+	return " " + strength + " OF " + c.QuoteIdentifier(c.TableName) + policy, nil
+}
+
+// wrapRowLockError converts a failure to acquire a lock with NOWAIT into ConflictError.
+func (c *PostgresPersistence[T]) wrapRowLockError(correlationId string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "55P03" {
+		return cerr.NewConflictError(correlationId, "ROW_LOCKED", "Rows in "+c.TableName+" are locked by another transaction").
+			WithCause(err)
+	}
+	return err
+}
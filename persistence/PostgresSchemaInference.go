@@ -0,0 +1,151 @@
+package persistence
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// EnsureTableFromTypes adds statements which create the table of a map persistence with the given columns,
+// so dynamic data models don't require hand-written DDL. The id column is the primary key of the type
+// derived from the id type of identifiable persistences unless it is given. Other columns are added
+// to existing tables every time the persistence is opened, like by EnsureColumn.
+// Columns of fields missing in the types can be added on writes by options.infer_schema.
+//
+//	Example:
+//		c.EnsureTableFromTypes(map[string]string{"key": "TEXT", "amount": "NUMERIC(12,2)", "tags": "JSONB"})
+//
+//	Parameters:
+//		- columnTypes PostgreSQL types of columns mapped to their names.
+func (c *PostgresPersistence[T]) EnsureTableFromTypes(columnTypes map[string]string) {
+	idType := columnTypes[c.idColumn]
+	if idType == "" {
+		idType = c.defaultIdType()
+	}
+
+	definitions := c.quoteColumn(c.idColumn) + " " + idType + " PRIMARY KEY" + c.composeIdGeneration(idType)
+	c.declareColumn(c.idColumn, idType)
+	if definition := c.composeTenantColumn(); definition != "" {
+		definitions += ", " + definition
+	}
+	c.declaredTable = true
+	if c.idSequence != "" {
+		c.EnsureSequence(c.idSequence, 0)
+	}
+	c.EnsureSchema(c.CreateTableClause() + " (" + definitions + ")" + c.TableStorageClause())
+
+	names := make([]string, 0, len(columnTypes))
+	for name := range columnTypes {
+		if name != c.idColumn {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c.EnsureColumn(name, columnTypes[name], "")
+	}
+}
+
+// defaultIdType returns a type of the id column derived from the id type of identifiable persistences.
+func (c *PostgresPersistence[T]) defaultIdType() string {
+	if c.idType != "" {
+		return c.idType
+	}
+	return "TEXT"
+}
+
+// inferColumns adds columns of fields of the written maps which are missing in the table when options.infer_schema
+// is set, and creates the table on the first write when it doesn't exist. Column types are derived from Go types
+// of the values like by EnsureTableFromStruct, and columns of null values are created as TEXT.
+// Items of other types than maps are ignored.
+func (c *PostgresPersistence[T]) inferColumns(ctx context.Context, correlationId string, items ...any) (err error) {
+	if !c.inferSchema || c.jsonColumn != "" {
+		return nil
+	}
+	columns, err := c.getTableColumns(ctx, correlationId)
+	if err != nil {
+		return err
+	}
+
+	missing := make(map[string]string)
+	for _, item := range items {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		for field, value := range fields {
+			column := c.NamingStrategy.ColumnName(field)
+			if columns[column] || (c.tenantColumn != "" && column == c.NamingStrategy.ColumnName(c.tenantColumn)) {
+				continue
+			}
+			if value == nil {
+				if _, ok := missing[column]; !ok {
+					missing[column] = ""
+				}
+			} else if missing[column] == "" {
+				missing[column] = composeColumnType(reflect.TypeOf(value))
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// A table always has columns, so no columns mean that it doesn't exist yet
+	statements := make([]string, 0, len(missing)+1)
+	if len(columns) == 0 {
+		idType := c.defaultIdType()
+		if c.idType == "" && missing[c.idColumn] != "" {
+			idType = missing[c.idColumn]
+		}
+		definitions := c.quoteColumn(c.idColumn) + " " + idType + " PRIMARY KEY" + c.composeIdGeneration(idType)
+		if c.tenantColumn != "" {
+			definitions += ", " + c.quoteColumn(c.NamingStrategy.ColumnName(c.tenantColumn)) + " TEXT NOT NULL"
+		}
+		statements = append(statements, c.CreateTableClause()+" ("+definitions+")"+c.TableStorageClause())
+		delete(missing, c.idColumn)
+	}
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pgType := missing[name]
+		if pgType == "" {
+			pgType = "TEXT"
+		}
+		statements = append(statements, "ALTER TABLE "+c.QuotedTableName()+" ADD COLUMN IF NOT EXISTS "+c.quoteColumn(name)+" "+pgType)
+	}
+
+	// Columns are added within the transaction of the context when it carries one, so they are rolled back
+	// with it, and concurrent writers wait for each other by the lock which protects creation of the table
+	tx, err := c.GetClient(ctx).Begin(ctx)
+	if err != nil {
+		return c.wrapConnectionError(correlationId, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+	if _, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", c.schemaLockKey()); err != nil {
+		return err
+	}
+	if c.useSearchPath && c.SchemaName != "" {
+		if _, err = tx.Exec(ctx, "SELECT set_config('search_path', $1, true)", c.searchPath()); err != nil {
+			return err
+		}
+	}
+	if err = c.executeSchemaStatements(ctx, correlationId, tx, statements); err != nil {
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return c.wrapConnectionError(correlationId, err)
+	}
+
+	c.resetTableColumns()
+	c.Logger.Debug(ctx, correlationId, "Added inferred columns %s to %s", strings.Join(names, ", "), c.TableName)
+	return nil
+}
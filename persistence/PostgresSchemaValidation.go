@@ -0,0 +1,199 @@
+package persistence
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+)
+
+var typeSpacesPattern = regexp.MustCompile(`\s+`)
+
+// Aliases of PostgreSQL types mapped to names returned by format_type function.
+var typeAliases = map[string]string{
+	"int":         "integer",
+	"int4":        "integer",
+	"serial":      "integer",
+	"serial4":     "integer",
+	"int2":        "smallint",
+	"smallserial": "smallint",
+	"int8":        "bigint",
+	"bigserial":   "bigint",
+	"serial8":     "bigint",
+	"bool":        "boolean",
+	"float4":      "real",
+	"float8":      "double precision",
+	"float":       "double precision",
+	"decimal":     "numeric",
+	"varchar":     "character varying",
+	"char":        "character",
+	"timestamptz": "timestamp with time zone",
+	"timestamp":   "timestamp without time zone",
+	"timetz":      "time with time zone",
+	"time":        "time without time zone",
+}
+
+// declaredColumn is a column declared by schema helpers.
+type declaredColumn struct {
+	name   string
+	pgType string
+}
+
+// Schema validation modes.
+const (
+	// SchemaValidationNone skips validation of existing tables.
+	SchemaValidationNone = "none"
+	// SchemaValidationWarn logs differences between the declared and the actual schema.
+	SchemaValidationWarn = "warn"
+	// SchemaValidationStrict fails opening when the actual schema differs from the declared one.
+	SchemaValidationStrict = "strict"
+)
+
+// declareColumn remembers a column declared by schema helpers to validate it later.
+func (c *PostgresPersistence[T]) declareColumn(name string, pgType string) {
+	for index, column := range c.declaredColumns {
+		if column.name == name {
+			c.declaredColumns[index].pgType = pgType
+			return
+		}
+	}
+	c.declaredColumns = append(c.declaredColumns, declaredColumn{name: name, pgType: pgType})
+}
+
+// ValidateSchema compares columns and indexes declared with schema helpers (EnsureTableFromStruct,
+// EnsureColumn, EnsureIndex and EnsureTable of JSON persistences) with the actual table in the database.
+// Objects created by raw statements added with EnsureSchema are not validated.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//	Returns: a list of found differences or error.
+func (c *PostgresPersistence[T]) ValidateSchema(ctx context.Context, correlationId string) ([]string, error) {
+	if err := c.ensureOpen(ctx, correlationId); err != nil {
+		return nil, err
+	}
+	return c.validateSchema(ctx, correlationId, c.GetClient(ctx))
+}
+
+// validateSchema compares the declared schema with the actual table using the given client.
+func (c *PostgresPersistence[T]) validateSchema(ctx context.Context, correlationId string,
+	client conn.IPostgresClient) ([]string, error) {
+
+	differences := make([]string, 0)
+
+	rows, err := c.queryClient(ctx, correlationId, client,
+		"SELECT attname, format_type(atttypid, atttypmod) FROM pg_attribute"+
+			" WHERE attrelid=to_regclass($1) AND attnum>0 AND NOT attisdropped", c.qualifiedTableName())
+	if err != nil {
+		return nil, err
+	}
+	actualColumns := make(map[string]string)
+	for rows.Next() {
+		var name, pgType string
+		if err = rows.Scan(&name, &pgType); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		actualColumns[name] = pgType
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(actualColumns) == 0 {
+		return append(differences, "table "+c.qualifiedTableName()+" does not exist"), nil
+	}
+
+	declared := make(map[string]bool, len(c.declaredColumns))
+	for _, column := range c.declaredColumns {
+		declared[column.name] = true
+		actualType, ok := actualColumns[column.name]
+		if !ok {
+			differences = append(differences, "column "+column.name+" is missing")
+			continue
+		}
+		if expected := normalizeType(column.pgType); expected != normalizeType(actualType) {
+			differences = append(differences, "column "+column.name+" has type "+actualType+" instead of "+expected)
+		}
+	}
+	if c.declaredTable {
+		for name := range actualColumns {
+			if !declared[name] {
+				differences = append(differences, "column "+name+" is not declared")
+			}
+		}
+	}
+
+	if len(c.declaredIndexes) > 0 {
+		rows, err = c.queryClient(ctx, correlationId, client,
+			"SELECT relname FROM pg_index JOIN pg_class ON pg_class.oid=pg_index.indexrelid"+
+				" WHERE indrelid=to_regclass($1)", c.qualifiedTableName())
+		if err != nil {
+			return nil, err
+		}
+		actualIndexes := make(map[string]bool)
+		for rows.Next() {
+			var name string
+			if err = rows.Scan(&name); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			actualIndexes[name] = true
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+
+		for _, name := range c.declaredIndexes {
+			if !actualIndexes[name] {
+				differences = append(differences, "index "+name+" is missing")
+			}
+		}
+	}
+
+	return differences, nil
+}
+
+// validateExistingSchema validates the existing table according to the schema validation mode.
+func (c *PostgresPersistence[T]) validateExistingSchema(ctx context.Context, correlationId string,
+	client conn.IPostgresClient) error {
+
+	if c.schemaValidation != SchemaValidationWarn && c.schemaValidation != SchemaValidationStrict {
+		return nil
+	}
+
+	differences, err := c.validateSchema(ctx, correlationId, client)
+	if err != nil || len(differences) == 0 {
+		return err
+	}
+
+	message := "Schema of " + c.QuotedTableName() + " differs from the declared one: " + strings.Join(differences, "; ")
+	if c.schemaValidation == SchemaValidationStrict {
+		return cerr.NewInvalidStateError(correlationId, "SCHEMA_DRIFT", message).
+			WithDetails("differences", differences)
+	}
+	c.Logger.Warn(ctx, correlationId, "%s", message)
+	return nil
+}
+
+// normalizeType converts a type name into the form returned by format_type function.
+func normalizeType(pgType string) string {
+	pgType = strings.ToLower(strings.TrimSpace(typeSpacesPattern.ReplaceAllString(pgType, " ")))
+	pgType = strings.ReplaceAll(pgType, " (", "(")
+	if index := strings.Index(pgType, " collate "); index > 0 {
+		pgType = pgType[:index]
+	}
+
+	name, modifier := pgType, ""
+	if index := strings.Index(pgType, "("); index > 0 {
+		name, modifier = pgType[:index], pgType[index:]
+	}
+	if alias, ok := typeAliases[name]; ok {
+		name = alias
+	}
+	return name + modifier
+}
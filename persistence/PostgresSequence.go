@@ -0,0 +1,35 @@
+package persistence
+
+import (
+	"strconv"
+	"strings"
+)
+
+// EnsureSequence adds a sequence definition to create it on opening.
+// Call it before the table definition which uses the sequence in a column default.
+// The sequence is created for existing tables as well.
+//
+//	Parameters:
+//		- name a sequence name.
+//		- start (optional) the first value of the sequence, 0 to start from 1.
+func (c *PostgresPersistence[T]) EnsureSequence(name string, start int64) {
+	statement := "CREATE SEQUENCE IF NOT EXISTS " + c.quotedSchemaObjectName(name)
+	if start != 0 {
+		statement += " START WITH " + strconv.FormatInt(start, 10)
+	}
+	c.EnsureSchema(statement)
+	c.updateStatements = append(c.updateStatements, statement)
+}
+
+// NextValExpression returns an expression which takes the next value of the sequence,
+// e.g. nextval('"schema"."sequence"'). Use it in column defaults of hand-written table definitions:
+//
+//	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() +
+//		" (\"id\" BIGINT PRIMARY KEY DEFAULT " + c.NextValExpression("dummies_id") + ", \"key\" TEXT)")
+//
+//	Parameters:
+//		- name a sequence name.
+//	Returns: the nextval expression.
+func (c *PostgresPersistence[T]) NextValExpression(name string) string {
+	return "nextval('" + strings.ReplaceAll(c.quotedSchemaObjectName(name), "'", "''") + "')"
+}
@@ -0,0 +1,193 @@
+package persistence
+
+import (
+	"regexp"
+	"strings"
+
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+)
+
+var sortCastPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_ ]*(\(\d+(,\s*\d+)?\))?(\[\])?$`)
+
+// Types of JSON fields declared by WithTypes in addition to JsonTypeString, JsonTypeNumber and JsonTypeBoolean.
+const (
+	// JsonTypeDate is a type of JSON strings with dates, e.g. 2024-01-31.
+	JsonTypeDate = "date"
+	// JsonTypeDateTime is a type of JSON strings with date and time in ISO 8601 format, e.g. 2024-01-31T10:00:00Z.
+	JsonTypeDateTime = "datetime"
+)
+
+// jsonTypeCasts maps types of JSON fields into types they are cast to before sorting.
+var jsonTypeCasts = map[string]string{
+	JsonTypeNumber:   "numeric",
+	JsonTypeBoolean:  "boolean",
+	JsonTypeDate:     "date",
+	JsonTypeDateTime: "timestamptz",
+}
+
+// PostgresSortBuilder translates SortParams into a safely quoted ORDER BY clause.
+// Field names are always quoted as identifiers, so they can be taken from user input.
+// Dot-separated names are treated as paths inside JSONB columns, e.g. "data.key" becomes "data"->>'key'.
+//
+//	Example:
+//		builder := NewPostgresSortBuilder().
+//			WithJsonColumn("data", "id").
+//			WithCast("price", "numeric").
+//			WithTypes(map[string]string{"created": JsonTypeDateTime}).
+//			WithCollation("name", "C")
+//		sort := builder.Build(*cdata.NewSortParams([]cdata.SortField{cdata.NewSortField("price", false)}))
+//		// sort: ("data"->>'price')::numeric DESC
+type PostgresSortBuilder struct {
+	jsonColumn string
+	columns    map[string]bool
+	aliases    map[string]string
+	casts      map[string]string
+	collations map[string]string
+	naming     INamingStrategy
+}
+
+// NewPostgresSortBuilder creates a new instance of the sort builder.
+//
+//	Returns: *PostgresSortBuilder
+func NewPostgresSortBuilder() *PostgresSortBuilder {
+	return &PostgresSortBuilder{
+		columns:    make(map[string]bool),
+		aliases:    make(map[string]string),
+		casts:      make(map[string]string),
+		collations: make(map[string]string),
+	}
+}
+
+// WithJsonColumn sets a JSONB column where sort fields are located by default.
+//
+//	Parameters:
+//		- column a name of the JSONB column.
+//		- columns names of table columns which are sorted directly instead of the JSON fields.
+//	Returns: the builder to chain calls.
+func (c *PostgresSortBuilder) WithJsonColumn(column string, columns ...string) *PostgresSortBuilder {
+	c.jsonColumn = column
+	for _, name := range columns {
+		c.columns[name] = true
+	}
+	return c
+}
+
+// WithFieldColumn sorts the field by the table column instead of the JSON field,
+// e.g. by a column extracted from the JSON data.
+//
+//	Parameters:
+//		- field a sort field name.
+//		- column a name of the table column.
+//	Returns: the builder to chain calls.
+func (c *PostgresSortBuilder) WithFieldColumn(field string, column string) *PostgresSortBuilder {
+	if column != "" {
+		c.aliases[field] = column
+	}
+	return c
+}
+
+// WithNamingStrategy sets a strategy to convert sort fields into names of table columns.
+// It is not applied to fields inside JSON columns.
+//
+//	Parameters:
+//		- strategy a naming strategy.
+//	Returns: the builder to chain calls.
+func (c *PostgresSortBuilder) WithNamingStrategy(strategy INamingStrategy) *PostgresSortBuilder {
+	c.naming = strategy
+	return c
+}
+
+// WithCast sets a type the field is cast to before sorting.
+// It is required to sort JSON fields by their numeric or date values instead of text.
+// Invalid type names are ignored.
+//
+//	Parameters:
+//		- field a sort field name.
+//		- pgType a PostgreSQL type name, e.g. numeric, timestamp or varchar(50).
+//	Returns: the builder to chain calls.
+func (c *PostgresSortBuilder) WithCast(field string, pgType string) *PostgresSortBuilder {
+	if sortCastPattern.MatchString(pgType) {
+		c.casts[field] = pgType
+	}
+	return c
+}
+
+// WithTypes declares types of JSON fields, so they are sorted by their values instead of text,
+// e.g. ("data"->>'price')::numeric. String fields and fields of unknown types are sorted as text.
+// Sorting fails when stored values can't be converted into the declared types.
+//
+//	Parameters:
+//		- types sort field names mapped to their types: JsonTypeString, JsonTypeNumber,
+//		  JsonTypeBoolean, JsonTypeDate or JsonTypeDateTime.
+//	Returns: the builder to chain calls.
+func (c *PostgresSortBuilder) WithTypes(types map[string]string) *PostgresSortBuilder {
+	for field, jsonType := range types {
+		if cast, ok := jsonTypeCasts[strings.ToLower(jsonType)]; ok {
+			c.casts[field] = cast
+		}
+	}
+	return c
+}
+
+// WithCollation sets a collation the field is sorted with, so case and locale sensitive sorting
+// doesn't depend on the database default. The field must have a text type or be cast to it.
+//
+//	Parameters:
+//		- field a sort field name.
+//		- collation a collation name, e.g. C, und-x-icu or en_US.
+//	Returns: the builder to chain calls.
+func (c *PostgresSortBuilder) WithCollation(field string, collation string) *PostgresSortBuilder {
+	if collation != "" {
+		c.collations[field] = collation
+	}
+	return c
+}
+
+// Build translates the sort parameters into an ORDER BY clause.
+//
+//	Parameters:
+//		- sort sort parameters.
+//	Returns: an ORDER BY clause without the ORDER BY keywords or empty string.
+func (c *PostgresSortBuilder) Build(sort cdata.SortParams) string {
+	clauses := make([]string, 0, len(sort))
+	for _, field := range sort {
+		if field.Name == "" {
+			continue
+		}
+
+		expr := c.composeField(field.Name)
+		if cast, ok := c.casts[field.Name]; ok {
+			expr = "(" + expr + ")::" + cast
+		}
+		if collation, ok := c.collations[field.Name]; ok {
+			expr += " COLLATE " + Column(collation)
+		}
+		if field.Ascending {
+			expr += " ASC"
+		} else {
+			expr += " DESC"
+		}
+		clauses = append(clauses, expr)
+	}
+	return strings.Join(clauses, ",")
+}
+
+// composeField converts the sort field name into a column or a JSON path expression.
+func (c *PostgresSortBuilder) composeField(name string) string {
+	if column, ok := c.aliases[name]; ok {
+		return Column(column)
+	}
+	if c.columns[name] {
+		return Column(name)
+	}
+	if c.jsonColumn != "" {
+		return JsonField(c.jsonColumn, name)
+	}
+	if index := strings.Index(name, "."); index > 0 {
+		return JsonField(name[:index], name[index+1:])
+	}
+	if c.naming != nil {
+		return Column(c.naming.ColumnName(name))
+	}
+	return Column(name)
+}
@@ -0,0 +1,291 @@
+package persistence
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+var storageParameterPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Types of tables created by the persistence.
+const (
+	// TableTypeLogged creates regular tables.
+	TableTypeLogged = "logged"
+	// TableTypeUnlogged creates tables which are not written to the write-ahead log.
+	// They are faster, but truncated after a crash and not replicated.
+	TableTypeUnlogged = "unlogged"
+	// TableTypeTemporary creates tables which exist only in the session that created them.
+	TableTypeTemporary = "temporary"
+)
+
+// Storage modes of the JSON data column set by options.data_storage.
+const (
+	// ColumnStoragePlain keeps values inline and uncompressed.
+	ColumnStoragePlain = "plain"
+	// ColumnStorageMain compresses values and moves them out of line only when rows still don't fit.
+	ColumnStorageMain = "main"
+	// ColumnStorageExternal moves large values out of line without compression,
+	// which makes reads of large documents and their fields faster at the cost of space.
+	ColumnStorageExternal = "external"
+	// ColumnStorageExtended compresses large values and moves them out of line. It's a default of JSONB columns.
+	ColumnStorageExtended = "extended"
+)
+
+// CreateTableClause returns the beginning of a CREATE TABLE statement for the persistence table
+// according to options.table_type, e.g. CREATE UNLOGGED TABLE IF NOT EXISTS "schema"."table".
+// Use it in DefineSchema to apply the configured table type to hand-written table definitions.
+//
+// Temporary tables are visible only to the connection which created them and dropped when it is closed,
+// so they require options.max_pool_size set to 1. The schema option is ignored for them.
+//
+//	Returns: the statement beginning without the column definitions.
+func (c *PostgresPersistence[T]) CreateTableClause() string {
+	clause := "CREATE "
+	switch c.tableType {
+	case TableTypeUnlogged:
+		clause += "UNLOGGED "
+	case TableTypeTemporary:
+		clause += "TEMPORARY "
+	}
+	return clause + "TABLE IF NOT EXISTS " + c.QuotedTableName()
+}
+
+// TableStorageClause returns the end of a CREATE TABLE statement with storage parameters
+// and a tablespace set by options.storage and options.tablespace, e.g. WITH (fillfactor='70') TABLESPACE "fast".
+// Use it in DefineSchema to apply the configured storage to hand-written table definitions.
+//
+//	Returns: the statement end after the column definitions or an empty string.
+func (c *PostgresPersistence[T]) TableStorageClause() string {
+	clause := ""
+	if len(c.tableStorage) > 0 {
+		names := make([]string, 0, len(c.tableStorage))
+		for name := range c.tableStorage {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		parameters := make([]string, 0, len(names))
+		for _, name := range names {
+			if !storageParameterPattern.MatchString(name) {
+				continue
+			}
+			parameters = append(parameters, name+"='"+strings.ReplaceAll(c.tableStorage[name], "'", "''")+"'")
+		}
+		if len(parameters) > 0 {
+			clause += " WITH (" + strings.Join(parameters, ", ") + ")"
+		}
+	}
+	if c.tablespace != "" {
+		clause += " TABLESPACE " + c.QuoteIdentifier(c.tablespace)
+	}
+	return clause
+}
+
+// columnStorageStatements returns statements which set the storage mode and compression of the column
+// by options.data_storage and options.data_compression, and the TOAST tuple target of the table by options.toast_tuple_target.
+// They are executed every time the persistence is opened, so changes apply to existing tables,
+// but only to rows written after them.
+//
+//	Parameters:
+//		- column a name of the column
+//		- tables quoted names of tables which keep data of the table and receive the TOAST tuple target
+func (c *PostgresPersistence[T]) columnStorageStatements(column string, tables []string) []string {
+	statements := make([]string, 0)
+	alterColumn := "ALTER TABLE " + c.QuotedTableName() + " ALTER COLUMN " + c.QuoteIdentifier(column)
+	switch c.dataStorage {
+	case ColumnStoragePlain, ColumnStorageMain, ColumnStorageExternal, ColumnStorageExtended:
+		statements = append(statements, alterColumn+" SET STORAGE "+strings.ToUpper(c.dataStorage))
+	}
+	if c.dataCompression != "" && storageParameterPattern.MatchString(c.dataCompression) {
+		statements = append(statements, alterColumn+" SET COMPRESSION "+c.dataCompression)
+	}
+	if c.toastTupleTarget > 0 {
+		setTarget := " SET (toast_tuple_target=" + strconv.Itoa(c.toastTupleTarget) + ")"
+		for _, table := range tables {
+			statements = append(statements, "ALTER TABLE "+table+setTarget)
+		}
+	}
+
+	return statements
+}
+
+// postgresColumnSchema is a column definition derived from a struct field.
+type postgresColumnSchema struct {
+	name       string
+	pgType     string
+	primaryKey bool
+	notNull    bool
+	unique     bool
+	collation  string
+}
+
+// EnsureTableFromStruct adds a CREATE TABLE statement derived from fields of the data type T.
+// Column names are taken from json tags, because data items are converted into columns
+// through JSON, and converted with the naming strategy. Column types are derived from
+// field types and can be refined with postgres tags:
+//
+//	type Dummy struct {
+//		Id      string    `json:"id" postgres:"pk"`
+//		Key     string    `json:"key" postgres:"type=VARCHAR(50),notnull,unique,collate=C"`
+//		Content string    `json:"content"`
+//		Secret  string    `json:"secret" postgres:"-"`
+//	}
+//
+// A field with the "id" column is the primary key unless another field is tagged with pk.
+// Text columns get the collation set by options.collation unless they are tagged with their own one.
+// When options.id_sequence is set the sequence is created and used as the primary key default,
+// when options.server_ids is set the primary key defaults to gen_random_uuid(),
+// and integer primary keys of identifiable persistences are identity columns.
+// Fields of other struct, slice and map types are stored as JSONB.
+// Nothing is added when T is not a struct. The table type is set by options.table_type, see CreateTableClause,
+// and the storage by options.storage and options.tablespace, see TableStorageClause.
+func (c *PostgresPersistence[T]) EnsureTableFromStruct() {
+	var item T
+	itemType := reflect.TypeOf(item)
+	if itemType != nil && itemType.Kind() == reflect.Pointer {
+		itemType = itemType.Elem()
+	}
+	if itemType == nil || itemType.Kind() != reflect.Struct {
+		return
+	}
+
+	columns := composeColumnSchemas(itemType)
+	if len(columns) == 0 {
+		return
+	}
+
+	hasPrimaryKey := false
+	for _, column := range columns {
+		hasPrimaryKey = hasPrimaryKey || column.primaryKey
+	}
+
+	definitions := make([]string, 0, len(columns))
+	for _, column := range columns {
+		definition := c.quoteColumn(column.name) + " " + column.pgType
+		collation := column.collation
+		if collation == "" && isTextType(column.pgType) {
+			collation = c.collation
+		}
+		if collation != "" {
+			definition += " COLLATE " + c.QuoteIdentifier(collation)
+		}
+		if column.primaryKey || (!hasPrimaryKey && column.name == "id") {
+			definition += " PRIMARY KEY"
+			definition += c.composeIdGeneration(column.pgType)
+		} else {
+			if column.notNull {
+				definition += " NOT NULL"
+			}
+			if column.unique {
+				definition += " UNIQUE"
+			}
+		}
+		definitions = append(definitions, definition)
+		c.declareColumn(c.NamingStrategy.ColumnName(column.name), column.pgType)
+	}
+	if definition := c.composeTenantColumn(); definition != "" {
+		definitions = append(definitions, definition)
+	}
+	c.declaredTable = true
+
+	if c.idSequence != "" {
+		c.EnsureSequence(c.idSequence, 0)
+	}
+	c.EnsureSchema(c.CreateTableClause() + " (" + strings.Join(definitions, ", ") + ")" + c.TableStorageClause())
+}
+
+// composeColumnSchemas derives column definitions from exported fields of the struct type.
+// Fields of embedded structs are promoted like in JSON serialization.
+func composeColumnSchemas(structType reflect.Type) []postgresColumnSchema {
+	columns := make([]postgresColumnSchema, 0, structType.NumField())
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		if !field.IsExported() {
+			continue
+		}
+
+		jsonTag := field.Tag.Get("json")
+		postgresTag := field.Tag.Get("postgres")
+		if jsonTag == "-" || postgresTag == "-" {
+			continue
+		}
+
+		name := strings.Split(jsonTag, ",")[0]
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				columns = append(columns, composeColumnSchemas(fieldType)...)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		column := postgresColumnSchema{name: name, pgType: composeColumnType(field.Type)}
+		for _, option := range strings.Split(postgresTag, ",") {
+			option = strings.TrimSpace(option)
+			switch {
+			case option == "pk":
+				column.primaryKey = true
+			case option == "notnull":
+				column.notNull = true
+			case option == "unique":
+				column.unique = true
+			case strings.HasPrefix(option, "type="):
+				column.pgType = strings.TrimPrefix(option, "type=")
+			case strings.HasPrefix(option, "collate="):
+				column.collation = strings.TrimPrefix(option, "collate=")
+			}
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// isTextType checks if the PostgreSQL type is a character type which supports collations.
+func isTextType(pgType string) bool {
+	name := normalizeType(pgType)
+	return name == "text" || strings.HasPrefix(name, "character") || name == "citext"
+}
+
+// composeColumnType maps a Go type into a PostgreSQL column type.
+func composeColumnType(fieldType reflect.Type) string {
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType == timeType {
+		return "TIMESTAMP WITH TIME ZONE"
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String:
+		return "TEXT"
+	case reflect.Bool:
+		return "BOOLEAN"
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "SMALLINT"
+	case reflect.Int32, reflect.Uint16:
+		return "INTEGER"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return "BIGINT"
+	case reflect.Float32:
+		return "REAL"
+	case reflect.Float64:
+		return "DOUBLE PRECISION"
+	case reflect.Slice:
+		if fieldType.Elem().Kind() == reflect.Uint8 {
+			return "BYTEA"
+		}
+	}
+	return "JSONB"
+}
@@ -0,0 +1,139 @@
+package persistence
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+)
+
+type tenantContextKey struct{}
+
+// NewContextWithTenant creates a child context with a tenant id used by persistences
+// configured with options.tenant_column. Created items are assigned to the tenant
+// and all other operations see only items of the tenant.
+//
+//	Example:
+//		ctx = persist.NewContextWithTenant(ctx, tenantId)
+//		page, err := persistence.GetPageByFilter(ctx, correlationId, filter, paging)
+//
+//	Parameters:
+//		- ctx context.Context
+//		- tenantId an id of the tenant.
+//	Returns: a new context.Context
+func NewContextWithTenant(ctx context.Context, tenantId string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantId)
+}
+
+// TenantFromContext retrieves a tenant id previously bound to the context.
+//
+//	Parameters:
+//		- ctx context.Context
+//	Returns: the tenant id and true if it was found or an empty string and false otherwise.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	tenantId, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenantId, ok && tenantId != ""
+}
+
+// requireTenant returns the tenant id from the context.
+// It fails when options.tenant_column is set and the context has no tenant,
+// so operations can't accidentally access items of all tenants.
+func (c *PostgresPersistence[T]) requireTenant(ctx context.Context, correlationId string) (string, error) {
+	tenantId, ok := TenantFromContext(ctx)
+	if !ok {
+		return "", cerr.NewUnauthorizedError(correlationId, "NO_TENANT",
+			"Operations with "+c.TableName+" require a tenant in the context").
+			WithDetails("table", c.TableName)
+	}
+	return tenantId, nil
+}
+
+// quotedTenantColumn returns the tenant column qualified with the table name,
+// so it's not ambiguous in queries with joined tables.
+func (c *PostgresPersistence[T]) quotedTenantColumn() string {
+	return c.QuotedTableName() + "." + c.quoteColumn(c.tenantColumn)
+}
+
+// applyTenantFilter restricts the filter to items of the tenant when options.tenant_column is set.
+// The tenant id is added as the parameter after the filter parameters.
+//
+//	Returns: the filter, its parameters or an error when the context has no tenant.
+func (c *PostgresPersistence[T]) applyTenantFilter(ctx context.Context, correlationId string,
+	filter string, params []any) (string, []any, error) {
+
+	if c.tenantColumn == "" {
+		return filter, params, nil
+	}
+	tenantId, err := c.requireTenant(ctx, correlationId)
+	if err != nil {
+		return "", nil, err
+	}
+
+	condition := c.quotedTenantColumn() + "=$" + strconv.Itoa(len(params)+1)
+	if len(filter) > 0 {
+		condition = "(" + filter + ") AND " + condition
+	}
+	// Copy the parameters to keep the caller's slice unchanged
+	tenantParams := make([]any, 0, len(params)+1)
+	tenantParams = append(tenantParams, params...)
+	return condition, append(tenantParams, tenantId), nil
+}
+
+// applyTenantCondition adds the tenant condition to the end of the WHERE clause of the statement,
+// before its RETURNING clause, when options.tenant_column is set.
+// The tenant id is added as the parameter after the statement parameters.
+//
+//	Returns: the statement, its parameters or an error when the context has no tenant.
+func (c *PostgresPersistence[T]) applyTenantCondition(ctx context.Context, correlationId string,
+	query string, params []any) (string, []any, error) {
+
+	if c.tenantColumn == "" {
+		return query, params, nil
+	}
+	tenantId, err := c.requireTenant(ctx, correlationId)
+	if err != nil {
+		return "", nil, err
+	}
+
+	returning := ""
+	if strings.HasSuffix(query, " RETURNING *") {
+		query, returning = strings.TrimSuffix(query, " RETURNING *"), " RETURNING *"
+	}
+	query += " AND " + c.quotedTenantColumn() + "=$" + strconv.Itoa(len(params)+1) + returning
+	return query, append(params, tenantId), nil
+}
+
+// applyTenantValue assigns the item to the tenant when options.tenant_column is set.
+//
+//	Returns: an error when the context has no tenant.
+func (c *PostgresPersistence[T]) applyTenantValue(ctx context.Context, correlationId string, objMap map[string]any) error {
+	if c.tenantColumn == "" {
+		return nil
+	}
+	tenantId, err := c.requireTenant(ctx, correlationId)
+	if err != nil {
+		return err
+	}
+	objMap[c.NamingStrategy.ColumnName(c.tenantColumn)] = tenantId
+	return nil
+}
+
+// composeTenantColumn returns a definition of the tenant column added to tables created by
+// EnsureTableFromStruct and EnsureTable when options.tenant_column is set and the column isn't declared yet.
+func (c *PostgresPersistence[T]) composeTenantColumn() string {
+	if c.tenantColumn == "" {
+		return ""
+	}
+	name := c.NamingStrategy.ColumnName(c.tenantColumn)
+	for _, column := range c.declaredColumns {
+		if column.name == name {
+			return ""
+		}
+	}
+	c.declareColumn(name, "TEXT")
+	return c.quoteColumn(name) + " TEXT NOT NULL"
+}
@@ -0,0 +1,183 @@
+package persistence
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+)
+
+// DefaultSearchConfig is a default text search configuration of search columns.
+const DefaultSearchConfig = "english"
+
+// PostgresSearchHit is a data item found by a text search with its rank and highlighted fragments.
+type PostgresSearchHit[T any] struct {
+	// The found data item.
+	Item T
+	// Relevance of the item to the search query. Items with higher ranks are more relevant.
+	Rank float64
+	// Fragments of the searched fields with highlighted matching words.
+	Headline string
+}
+
+// EnsureSearchColumn adds a stored tsvector column generated from fields of the JSON data
+// and its GIN index to the table on opening, so the data can be searched by SearchByText.
+// Like EnsureGeneratedColumn it is executed every time the persistence is opened, so the column is added to existing tables.
+//
+//	Parameters:
+//		- name a column name (default: search).
+//		- fields field names or dot-separated paths of nested fields to be searched.
+//		- config a text search configuration, e.g. simple or german (default: english).
+func (c *IdentifiableJsonPostgresPersistence[T, K]) EnsureSearchColumn(name string, fields []string, config string) {
+	if name == "" {
+		name = "search"
+	}
+	if config == "" {
+		config = DefaultSearchConfig
+	}
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, "COALESCE("+JsonField(c.jsonColumn, field)+", '')")
+	}
+	c.searchColumn = name
+	c.searchConfig = config
+	c.searchDocument = strings.Join(parts, " || ' ' || ")
+	if c.searchDocument == "" {
+		c.searchDocument = "''"
+	}
+
+	expr := "to_tsvector(" + c.quotedSearchConfig() + ", " + c.searchDocument + ")"
+	c.declareColumn(name, "tsvector")
+	c.updateStatements = append(c.updateStatements, "ALTER TABLE "+c.QuotedTableName()+
+		" ADD COLUMN IF NOT EXISTS "+c.QuoteIdentifier(name)+" tsvector GENERATED ALWAYS AS ("+expr+") STORED")
+
+	// The index is created after the column, so it can't be a regular schema statement
+	indexName := c.TableName + "_" + name
+	c.declaredIndexes = append(c.declaredIndexes, indexName)
+	c.updateStatements = append(c.updateStatements, "CREATE INDEX IF NOT EXISTS "+c.QuoteIdentifier(indexName)+
+		" ON "+c.QuotedTableName()+" USING gin ("+c.QuoteIdentifier(name)+")")
+}
+
+// quotedSearchConfig returns the text search configuration as a regconfig literal.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) quotedSearchConfig() string {
+	return QuoteLiteral(c.searchConfig) + "::regconfig"
+}
+
+// composeSearch returns the condition matching the search column with the query in the given parameter
+// and the expression of the query. Queries use the web search syntax: quoted phrases, OR and -excluded words.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) composeSearch(correlationId string, placeholder string) (string, string, error) {
+	if c.searchColumn == "" {
+		return "", "", cerr.NewConfigError(correlationId, "NO_SEARCH_COLUMN",
+			"Search column is not defined by EnsureSearchColumn").
+			WithDetails("table", c.TableName)
+	}
+	tsQuery := "websearch_to_tsquery(" + c.quotedSearchConfig() + ", " + placeholder + ")"
+	return c.QuoteIdentifier(c.searchColumn) + " @@ " + tsQuery, tsQuery, nil
+}
+
+// SearchByText gets a page of data items matching the text search query sorted by relevance.
+// The query uses the web search syntax, e.g. "quick fox" -dog, and fields searched are defined by EnsureSearchColumn.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- query             a text search query.
+//		- paging            (optional) paging parameters
+//	Returns: a data page or error.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) SearchByText(ctx context.Context, correlationId string,
+	query string, paging cdata.PagingParams) (page cdata.DataPage[T], err error) {
+
+	filter, tsQuery, err := c.composeSearch(correlationId, "$1")
+	if err != nil {
+		return page, err
+	}
+	sort := "ts_rank(" + c.QuoteIdentifier(c.searchColumn) + ", " + tsQuery + ") DESC, " + c.quotedIdColumn()
+	return c.GetPageByFilterWithParams(ctx, correlationId, filter, []any{query}, paging, sort, "")
+}
+
+// SearchByTextWithHeadlines gets a page of data items matching the text search query sorted by relevance
+// with their ranks and fragments of the searched fields where matching words are highlighted.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId     (optional) transaction id to trace execution through call chain.
+//		- query             a text search query, see SearchByText.
+//		- options           (optional) ts_headline options, e.g. StartSel=<mark>, StopSel=</mark>, MaxFragments=2
+//		- paging            (optional) paging parameters
+//	Returns: a data page of search hits or error.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) SearchByTextWithHeadlines(ctx context.Context, correlationId string,
+	query string, options string, paging cdata.PagingParams) (page cdata.DataPage[PostgresSearchHit[T]], err error) {
+
+	filter, tsQuery, err := c.composeSearch(correlationId, "$1")
+	if err != nil {
+		return page, err
+	}
+	// The count query applies the tenant filter itself
+	pageFilter, pageParams, err := c.applyTenantFilter(ctx, correlationId, filter, []any{query, options})
+	if err != nil {
+		return page, err
+	}
+
+	maxPageSize := (int64)(c.MaxPageSize)
+	if size, ok := MaxPageSizeFromContext(ctx); ok {
+		maxPageSize = size
+	}
+	sql := "SELECT *, ts_rank(" + c.QuoteIdentifier(c.searchColumn) + ", " + tsQuery + ") AS \"search_rank\"," +
+		" ts_headline(" + c.quotedSearchConfig() + ", " + c.searchDocument + ", " + tsQuery + ", $2) AS \"search_headline\"" +
+		" FROM " + c.QuotedTableName() + " WHERE " + pageFilter + " ORDER BY \"search_rank\" DESC, " + c.quotedIdColumn()
+	if skip := paging.GetSkip(-1); skip >= 0 {
+		sql += " OFFSET " + strconv.FormatInt(skip, 10)
+	}
+	sql += " LIMIT " + strconv.FormatInt(paging.GetTake(maxPageSize), 10)
+
+	rows, err := c.queryRead(ctx, correlationId, sql, pageParams...)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	hits := make([]PostgresSearchHit[T], 0)
+	for rows.Next() {
+		if err := c.checkInterrupted(ctx, correlationId); err != nil {
+			return page, err
+		}
+		item, convErr := c.Overrides.ConvertToPublic(rows)
+		if convErr != nil {
+			return page, convErr
+		}
+		hit := PostgresSearchHit[T]{Item: item}
+		values, err := rows.Values()
+		if err != nil {
+			return page, err
+		}
+		for index, field := range rows.FieldDescriptions() {
+			switch field.Name {
+			case "search_rank":
+				hit.Rank = cconv.DoubleConverter.ToDouble(values[index])
+			case "search_headline":
+				hit.Headline = cconv.StringConverter.ToString(values[index])
+			}
+		}
+		hits = append(hits, hit)
+	}
+
+	// Rows must be released before the count query when running inside a transaction
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return page, err
+	}
+	c.Logger.Trace(ctx, correlationId, "Found %d items by text in %s", len(hits), c.TableName)
+
+	if !paging.Total {
+		return *cdata.NewDataPage(hits, cdata.EmptyTotalValue), nil
+	}
+	count, err := c.GetCountByFilterWithParams(ctx, correlationId, filter, []any{query})
+	if err != nil {
+		return page, err
+	}
+	return *cdata.NewDataPage(hits, int(count)), nil
+}
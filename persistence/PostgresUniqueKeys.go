@@ -0,0 +1,91 @@
+package persistence
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+)
+
+// EnsureUniqueDataKey adds a unique index over fields of the JSON data to create it with the table on opening,
+// so natural keys of documents, e.g. emails or codes, can't be duplicated. Documents without the fields
+// aren't checked, as their keys are nulls. Operations which violate the key fail with ConflictError.
+//
+//	Parameters:
+//		- paths field names or dot-separated paths of nested fields in the key.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) EnsureUniqueDataKey(paths ...string) {
+	c.EnsureUniqueDataKeyWithOptions(nil, paths...)
+}
+
+// EnsureUniqueDataKeyWithOptions adds a unique index over fields of the JSON data like EnsureUniqueDataKey.
+// When options.tenant_column is set keys are unique within tenants. Tables partitioned by options.partitions
+// can't have unique keys over other columns than the id, so the index fails to be created for them.
+//
+//	Parameters:
+//		- options index options:
+//			- name: (optional) an index name (default: <table>_<paths>_key)
+//			- where: (optional) a predicate to create a partial index, so only matching documents are checked,
+//			  e.g. "data"->>'status' <> 'deleted'
+//			- with, tablespace: (optional) see EnsureIndex
+//		- paths field names or dot-separated paths of nested fields in the key.
+func (c *IdentifiableJsonPostgresPersistence[T, K]) EnsureUniqueDataKeyWithOptions(options map[string]string, paths ...string) {
+	if len(paths) == 0 {
+		return
+	}
+
+	name := options["name"]
+	if name == "" {
+		name = c.TableName + "_" + strings.ReplaceAll(strings.Join(paths, "_"), ".", "_") + "_key"
+	}
+
+	keys := make([]PostgresIndexKey, 0, len(paths)+1)
+	if c.tenantColumn != "" {
+		keys = append(keys, IndexKey(c.tenantColumn))
+	}
+	for _, path := range paths {
+		keys = append(keys, IndexKey("("+JsonField(c.jsonColumn, path)+")"))
+	}
+
+	indexOptions := map[string]string{"unique": "true"}
+	for _, option := range []string{"where", "with", "tablespace"} {
+		if value, ok := options[option]; ok {
+			indexOptions[option] = value
+		}
+	}
+	c.EnsureIndexWithKeys(name, keys, indexOptions)
+
+	if c.uniqueKeys == nil {
+		c.uniqueKeys = make(map[string][]string)
+	}
+	c.uniqueKeys[name] = paths
+}
+
+// wrapUniqueKeyError converts a violation of a unique key declared by EnsureUniqueDataKey into ConflictError.
+func (c *PostgresPersistence[T]) wrapUniqueKeyError(correlationId string, err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		return err
+	}
+	paths, ok := c.uniqueKeys[pgErr.ConstraintName]
+	if !ok {
+		return err
+	}
+	return cerr.NewConflictError(correlationId, "DUPLICATE_KEY",
+		"Document with the same "+strings.Join(paths, ", ")+" already exists in "+c.TableName).
+		WithDetails("table", c.TableName).
+		WithDetails("key", paths).
+		WithCause(err)
+}
+
+// uniqueKeyRows converts violations of unique keys reported while rows are read into ConflictError.
+type uniqueKeyRows[T any] struct {
+	pgx.Rows
+	persistence   *PostgresPersistence[T]
+	correlationId string
+}
+
+func (r *uniqueKeyRows[T]) Err() error {
+	return r.persistence.wrapUniqueKeyError(r.correlationId, r.Rows.Err())
+}
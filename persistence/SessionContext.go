@@ -0,0 +1,46 @@
+package persistence
+
+import (
+	"context"
+)
+
+type sessionSettingsContextKey struct{}
+
+// NewContextWithSessionSettings creates a child context with run-time settings which are set
+// for every query executed with this context, e.g. a tenant id used by row-level security policies.
+// Settings are local to the operation: they are reset before the connection is returned to the pool.
+// Settings of the parent context are inherited and can be overridden.
+//
+//	Example:
+//		ctx = persist.NewContextWithSessionSettings(ctx, map[string]string{"app.tenant_id": tenantId})
+//		page, err := persistence.GetPageByFilter(ctx, correlationId, filter, paging)
+//
+//	Parameters:
+//		- ctx context.Context
+//		- settings names of settings, e.g. app.tenant_id, mapped to their values.
+//	Returns: a new context.Context
+func NewContextWithSessionSettings(ctx context.Context, settings map[string]string) context.Context {
+	merged := SessionSettingsFromContext(ctx)
+	for name, value := range settings {
+		merged[name] = value
+	}
+	return context.WithValue(ctx, sessionSettingsContextKey{}, merged)
+}
+
+// SessionSettingsFromContext retrieves run-time settings previously bound to the context.
+//
+//	Parameters:
+//		- ctx context.Context
+//	Returns: a copy of the settings or an empty map if no settings were found.
+func SessionSettingsFromContext(ctx context.Context) map[string]string {
+	result := make(map[string]string)
+	if ctx == nil {
+		return result
+	}
+	if settings, ok := ctx.Value(sessionSettingsContextKey{}).(map[string]string); ok {
+		for name, value := range settings {
+			result[name] = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,479 @@
+package state
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	cref "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
+	cstate "github.com/pip-services3-gox/pip-services3-components-gox/state"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+)
+
+// DefaultStateTable is the default name of the state table.
+const DefaultStateTable = "state"
+
+// PostgresStateStore is a state store that keeps states in a PostgreSQL table,
+// so stateful microservices can keep durable state in the same database as their data.
+//
+// States are stored as JSON with a version which is incremented on every save.
+// Besides the IStateStore methods, which overwrite states, LoadVersion and SaveVersion
+// implement optimistic concurrency: a state is saved only when its version didn't change since
+// it was loaded, otherwise the save fails with ConflictError. The table is created when the component is opened.
+//
+// IStateStore methods don't return errors, so their errors are logged and reported as missing states.
+//
+//	Configuration parameters
+//		- table:                    (optional) a name of the state table (default: state)
+//		- schema:                   (optional) a schema of the state table (default: the search path)
+//		- connection(s):
+//			- discovery_key:        (optional) a key to retrieve the connection from IDiscovery
+//			- host:                 host name or IP address
+//			- port:                 port number (default: 5432)
+//			- uri:                  resource URI or connection string with all parameters in it
+//		- credential(s):
+//			- store_key:            (optional) a key to retrieve the credentials from ICredentialStore
+//			- username:             (optional) user name
+//			- password:             (optional) user password
+//		- dependencies:
+//			- connection:           (optional) descriptor of the shared PostgresConnection (default: *:connection:postgres:*:1.0)
+//
+//	References
+//		- *:logger:*:*:1.0            (optional) ILogger components to pass log messages
+//		- *:connection:postgres:*:1.0 (optional) shared PostgresConnection, otherwise a local connection is created
+//		- *:discovery:*:*:1.0         (optional) IDiscovery services
+//		- *:credential-store:*:*:1.0  (optional) Credential stores to resolve credentials
+//
+//	Example:
+//		store := state.NewPostgresStateStore[MyState]()
+//		store.Configure(ctx, cconf.NewConfigParamsFromTuples(
+//			"connection.host", "localhost",
+//			"connection.port", 5432,
+//			"connection.database", "test",
+//		))
+//		_ = store.Open(ctx, "123")
+//
+//		value, version, err := store.LoadVersion(ctx, "123", "key1")
+//		value.Counter++
+//		version, err = store.SaveVersion(ctx, "123", "key1", value, version) // ConflictError if saved concurrently
+type PostgresStateStore[T any] struct {
+	defaultConfig *cconf.ConfigParams
+	config        *cconf.ConfigParams
+	references    cref.IReferences
+
+	// The dependency resolver.
+	DependencyResolver *cref.DependencyResolver
+	// The logger.
+	Logger *clog.CompositeLogger
+	// The PostgreSQL connection component.
+	Connection *conn.PostgresConnection
+	// The name of the state table.
+	TableName string
+	// The schema of the state table.
+	SchemaName string
+
+	localConnection bool
+	opened          bool
+	convertor       cconv.IJSONEngine[T]
+}
+
+// NewPostgresStateStore creates a new instance of the state store component.
+//
+//	Returns: *PostgresStateStore
+func NewPostgresStateStore[T any]() *PostgresStateStore[T] {
+	c := &PostgresStateStore[T]{
+		defaultConfig: cconf.NewConfigParamsFromTuples(
+			"dependencies.connection", "*:connection:postgres:*:1.0",
+		),
+		Logger:    clog.NewCompositeLogger(),
+		TableName: DefaultStateTable,
+		convertor: cconv.NewDefaultCustomTypeJsonConvertor[T](),
+	}
+	c.DependencyResolver = cref.NewDependencyResolver()
+	c.DependencyResolver.Configure(context.Background(), c.defaultConfig)
+	return c
+}
+
+// Configure component by passing configuration parameters.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- config configuration parameters to be set.
+func (c *PostgresStateStore[T]) Configure(ctx context.Context, config *cconf.ConfigParams) {
+	config = config.SetDefaults(c.defaultConfig)
+	c.config = config
+
+	c.DependencyResolver.Configure(ctx, config)
+	c.TableName = config.GetAsStringWithDefault("table", c.TableName)
+	c.SchemaName = config.GetAsStringWithDefault("schema", c.SchemaName)
+}
+
+// SetReferences references to dependent components.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- references references to locate the component dependencies.
+func (c *PostgresStateStore[T]) SetReferences(ctx context.Context, references cref.IReferences) {
+	c.references = references
+	c.Logger.SetReferences(ctx, references)
+	c.DependencyResolver.SetReferences(ctx, references)
+
+	if dep, ok := c.DependencyResolver.GetOneOptional("connection").(*conn.PostgresConnection); ok {
+		c.Connection = dep
+		c.localConnection = false
+	}
+	// Or create a local one
+	if c.Connection == nil {
+		c.Connection = c.createConnection(ctx)
+		c.localConnection = true
+	}
+}
+
+// UnsetReferences (clears) previously set references to dependent components.
+func (c *PostgresStateStore[T]) UnsetReferences() {
+	c.Connection = nil
+}
+
+func (c *PostgresStateStore[T]) createConnection(ctx context.Context) *conn.PostgresConnection {
+	connection := conn.NewPostgresConnection()
+	if c.config != nil {
+		connection.Configure(ctx, c.config)
+	}
+	if c.references != nil {
+		connection.SetReferences(ctx, c.references)
+	}
+	return connection
+}
+
+// IsOpen checks if the component is opened.
+//
+//	Returns: true if the component has been opened and false otherwise.
+func (c *PostgresStateStore[T]) IsOpen() bool {
+	return c.opened
+}
+
+// Open the component and creates the state table.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occurred.
+func (c *PostgresStateStore[T]) Open(ctx context.Context, correlationId string) (err error) {
+	if c.opened {
+		return nil
+	}
+
+	if c.Connection == nil {
+		c.Connection = c.createConnection(ctx)
+		c.localConnection = true
+	}
+	if c.localConnection && !c.Connection.IsOpen() {
+		if err = c.Connection.Open(ctx, correlationId); err != nil {
+			return err
+		}
+	}
+	pool := c.Connection.GetConnection()
+	if pool == nil {
+		err = cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "PostgreSQL connection is not opened")
+	} else if err = c.createTable(ctx, pool); err != nil {
+		err = cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to create state table "+c.quotedTableName()).
+			WithCause(err)
+	}
+	if err != nil {
+		// The local connection is not used when the component fails to open
+		if c.localConnection {
+			_ = c.Connection.Close(ctx, correlationId)
+		}
+		return err
+	}
+
+	c.opened = true
+	c.Logger.Debug(ctx, correlationId, "Opened postgres state store %s", c.quotedTableName())
+	return nil
+}
+
+// Close component.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occurred.
+func (c *PostgresStateStore[T]) Close(ctx context.Context, correlationId string) (err error) {
+	if !c.opened {
+		return nil
+	}
+
+	if c.localConnection && c.Connection != nil {
+		if err = c.Connection.Close(ctx, correlationId); err != nil {
+			return err
+		}
+		c.Connection = nil
+	}
+	c.opened = false
+	return nil
+}
+
+// quotedTableName returns the quoted name of the state table qualified with the schema.
+func (c *PostgresStateStore[T]) quotedTableName() string {
+	if c.SchemaName != "" {
+		return pgx.Identifier{c.SchemaName, c.TableName}.Sanitize()
+	}
+	return pgx.Identifier{c.TableName}.Sanitize()
+}
+
+// createTable creates the state table when it doesn't exist.
+func (c *PostgresStateStore[T]) createTable(ctx context.Context, pool *pgxpool.Pool) error {
+	statements := []string{
+		"CREATE TABLE IF NOT EXISTS " + c.quotedTableName() +
+			" (\"key\" TEXT PRIMARY KEY, \"value\" JSONB, \"version\" BIGINT NOT NULL," +
+			" \"update_time\" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now())",
+	}
+	if c.SchemaName != "" {
+		statements = append([]string{"CREATE SCHEMA IF NOT EXISTS " + pgx.Identifier{c.SchemaName}.Sanitize()}, statements...)
+	}
+	for _, statement := range statements {
+		if _, err := pool.Exec(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getClient returns the connection pool or an error when the component is not opened.
+func (c *PostgresStateStore[T]) getClient(correlationId string) (*pgxpool.Pool, error) {
+	if c.opened && c.Connection != nil {
+		if pool := c.Connection.GetConnection(); pool != nil {
+			return pool, nil
+		}
+	}
+	return nil, cerr.NewInvalidStateError(correlationId, "NO_CONNECTION", "PostgreSQL connection is not opened")
+}
+
+// checkKey returns an error when the key is empty.
+func checkKey(correlationId string, key string) error {
+	if key == "" {
+		return cerr.NewInvalidStateError(correlationId, "INVALID_KEY", "key can not be empty string")
+	}
+	return nil
+}
+
+// fromJson converts a stored JSON value, which is nil for null values, to a state.
+func (c *PostgresStateStore[T]) fromJson(buf *string) (value T, err error) {
+	if buf == nil {
+		return value, nil
+	}
+	return c.convertor.FromJson(*buf)
+}
+
+// LoadVersion loads a state and its version from the store using its key.
+// If the state is missing it returns the zero value and version 0.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- key a unique state key.
+//	Returns: the state value, its version or error.
+func (c *PostgresStateStore[T]) LoadVersion(ctx context.Context, correlationId string, key string) (value T, version int64, err error) {
+	if err = checkKey(correlationId, key); err != nil {
+		return value, 0, err
+	}
+	pool, err := c.getClient(correlationId)
+	if err != nil {
+		return value, 0, err
+	}
+
+	var buf *string
+	err = pool.QueryRow(ctx, "SELECT \"value\"::text, \"version\" FROM "+c.quotedTableName()+
+		" WHERE \"key\"=$1", key).Scan(&buf, &version)
+	if err == pgx.ErrNoRows {
+		return value, 0, nil
+	}
+	if err != nil {
+		return value, 0, cerr.NewConnectionError(correlationId, "STATE_FAILED", "Failed to load state "+key).
+			WithDetails("key", key).
+			WithCause(err)
+	}
+	value, err = c.fromJson(buf)
+	return value, version, err
+}
+
+// SaveVersion saves a state into the store when its version in the store equals the given version,
+// which is 0 for states that must not exist yet.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- key a unique state key.
+//		- value a state value.
+//		- version the version of the state returned by LoadVersion.
+//	Returns: the new version of the state, or ConflictError when the state was changed or created concurrently.
+func (c *PostgresStateStore[T]) SaveVersion(ctx context.Context, correlationId string, key string, value T, version int64) (int64, error) {
+	if err := checkKey(correlationId, key); err != nil {
+		return 0, err
+	}
+	pool, err := c.getClient(correlationId)
+	if err != nil {
+		return 0, err
+	}
+	buf, err := c.convertor.ToJson(value)
+	if err != nil {
+		return 0, err
+	}
+
+	var query string
+	if version == 0 {
+		query = "INSERT INTO " + c.quotedTableName() + " (\"key\", \"value\", \"version\") VALUES ($1, $2::jsonb, 1)" +
+			" ON CONFLICT (\"key\") DO NOTHING RETURNING \"version\""
+	} else {
+		query = "UPDATE " + c.quotedTableName() + " SET \"value\"=$2::jsonb, \"version\"=\"version\"+1, \"update_time\"=now()" +
+			" WHERE \"key\"=$1 AND \"version\"=$3 RETURNING \"version\""
+	}
+
+	var newVersion int64
+	if version == 0 {
+		err = pool.QueryRow(ctx, query, key, buf).Scan(&newVersion)
+	} else {
+		err = pool.QueryRow(ctx, query, key, buf, version).Scan(&newVersion)
+	}
+	if err == pgx.ErrNoRows {
+		return 0, cerr.NewConflictError(correlationId, "STATE_CONFLICT", "State "+key+" was changed concurrently").
+			WithDetails("key", key).
+			WithDetails("version", version)
+	}
+	if err != nil {
+		return 0, cerr.NewConnectionError(correlationId, "STATE_FAILED", "Failed to save state "+key).
+			WithDetails("key", key).
+			WithCause(err)
+	}
+	return newVersion, nil
+}
+
+// Load state from the store using its key.
+// If value is missing in the store it returns the zero value.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- key a unique state key.
+//	Returns: the state value or the zero value if value wasn't found.
+func (c *PostgresStateStore[T]) Load(ctx context.Context, correlationId string, key string) T {
+	value, _, err := c.LoadVersion(ctx, correlationId, key)
+	if err != nil {
+		c.Logger.Error(ctx, correlationId, err, "Failed to load state %s", key)
+	}
+	return value
+}
+
+// LoadBulk loads an array of states from the store using their keys.
+// Missing states are not included in the result.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- keys unique state keys.
+//	Returns: an array with state values and their corresponding keys.
+func (c *PostgresStateStore[T]) LoadBulk(ctx context.Context, correlationId string, keys []string) []cstate.StateValue[T] {
+	result := make([]cstate.StateValue[T], 0, len(keys))
+	if len(keys) == 0 {
+		return result
+	}
+	pool, err := c.getClient(correlationId)
+	if err != nil {
+		c.Logger.Error(ctx, correlationId, err, "Failed to load states")
+		return result
+	}
+
+	rows, err := pool.Query(ctx, "SELECT \"key\", \"value\"::text FROM "+c.quotedTableName()+
+		" WHERE \"key\"=ANY($1)", keys)
+	if err != nil {
+		c.Logger.Error(ctx, correlationId, err, "Failed to load states")
+		return result
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var buf *string
+		if err = rows.Scan(&key, &buf); err != nil {
+			break
+		}
+		value, err := c.fromJson(buf)
+		if err != nil {
+			c.Logger.Error(ctx, correlationId, err, "Failed to convert state %s", key)
+			continue
+		}
+		result = append(result, cstate.StateValue[T]{Key: key, Value: value})
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	if err != nil {
+		c.Logger.Error(ctx, correlationId, err, "Failed to load states")
+	}
+	return result
+}
+
+// Save state into the store regardless of its version.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- key a unique state key.
+//		- value a state value.
+//	Returns: the state that was stored in the store or the zero value when it failed.
+func (c *PostgresStateStore[T]) Save(ctx context.Context, correlationId string, key string, value T) (result T) {
+	err := checkKey(correlationId, key)
+	var pool *pgxpool.Pool
+	if err == nil {
+		pool, err = c.getClient(correlationId)
+	}
+	var buf string
+	if err == nil {
+		buf, err = c.convertor.ToJson(value)
+	}
+	if err == nil {
+		_, err = pool.Exec(ctx, "INSERT INTO "+c.quotedTableName()+" (\"key\", \"value\", \"version\") VALUES ($1, $2::jsonb, 1)"+
+			" ON CONFLICT (\"key\") DO UPDATE SET \"value\"=EXCLUDED.\"value\","+
+			" \"version\"="+c.quotedTableName()+".\"version\"+1, \"update_time\"=now()",
+			key, buf)
+	}
+	if err != nil {
+		c.Logger.Error(ctx, correlationId, err, "Failed to save state %s", key)
+		return result
+	}
+	return value
+}
+
+// Delete a state from the store by its key.
+//
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId (optional) transaction id to trace execution through call chain.
+//		- key a unique state key.
+//	Returns: the state that was deleted in the store or the zero value if it wasn't found.
+func (c *PostgresStateStore[T]) Delete(ctx context.Context, correlationId string, key string) (result T) {
+	err := checkKey(correlationId, key)
+	var pool *pgxpool.Pool
+	if err == nil {
+		pool, err = c.getClient(correlationId)
+	}
+	var buf *string
+	if err == nil {
+		err = pool.QueryRow(ctx, "DELETE FROM "+c.quotedTableName()+" WHERE \"key\"=$1 RETURNING \"value\"::text", key).
+			Scan(&buf)
+		if err == pgx.ErrNoRows {
+			return result
+		}
+	}
+	if err == nil {
+		result, err = c.fromJson(buf)
+	}
+	if err != nil {
+		c.Logger.Error(ctx, correlationId, err, "Failed to delete state %s", key)
+	}
+	return result
+}
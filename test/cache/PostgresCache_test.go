@@ -0,0 +1,132 @@
+package test_cache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cref "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	pcache "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/cache"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresCacheWithClosedConnection(t *testing.T) {
+	cache := pcache.NewPostgresCache[string]()
+
+	_, err := cache.Store(context.Background(), "", "key_closed", "ABC", 1000)
+	assert.NotNil(t, err)
+	_, err = cache.Retrieve(context.Background(), "", "key_closed")
+	assert.NotNil(t, err)
+	assert.False(t, cache.Contains(context.Background(), "", "key_closed"))
+
+	_, err = cache.Retrieve(context.Background(), "", "")
+	assert.NotNil(t, err)
+}
+
+func TestPostgresCache(t *testing.T) {
+	postgresUri := os.Getenv("POSTGRES_URI")
+	postgresHost := os.Getenv("POSTGRES_HOST")
+	if postgresHost == "" {
+		postgresHost = "localhost"
+	}
+	postgresPort := os.Getenv("POSTGRES_PORT")
+	if postgresPort == "" {
+		postgresPort = "5432"
+	}
+	postgresDatabase := os.Getenv("POSTGRES_DB")
+	if postgresDatabase == "" {
+		postgresDatabase = "test"
+	}
+	postgresUser := os.Getenv("POSTGRES_USER")
+	if postgresUser == "" {
+		postgresUser = "postgres"
+	}
+	postgresPassword := os.Getenv("POSTGRES_PASSWORD")
+	if postgresPassword == "" {
+		postgresPassword = "postgres#"
+	}
+
+	dbConfig := cconf.NewConfigParamsFromTuples(
+		"connection.uri", postgresUri,
+		"connection.host", postgresHost,
+		"connection.port", postgresPort,
+		"connection.database", postgresDatabase,
+		"credential.username", postgresUser,
+		"credential.password", postgresPassword,
+	)
+
+	connection := conn.NewPostgresConnection()
+	connection.Configure(context.Background(), dbConfig)
+	err := connection.Open(context.Background(), "")
+	if err != nil {
+		t.Error("Error opened connection", err)
+		return
+	}
+	defer connection.Close(context.Background(), "")
+
+	references := cref.NewReferencesFromTuples(context.Background(),
+		cref.NewDescriptor("pip-services", "connection", "postgres", "default", "1.0"), connection)
+
+	cache := pcache.NewPostgresCache[map[string]any]()
+	cache.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"table", "test_cache",
+		"options.cleanup_interval", 100,
+	))
+	cache.SetReferences(context.Background(), references)
+	assert.Nil(t, cache.Open(context.Background(), ""))
+	defer cache.Close(context.Background(), "")
+
+	t.Run("StoreAndRetrieve", func(t *testing.T) {
+		value := map[string]any{"name": "ABC", "count": float64(1)}
+		_, err := cache.Store(context.Background(), "", "key1", value, 5000)
+		assert.Nil(t, err)
+
+		result, err := cache.Retrieve(context.Background(), "", "key1")
+		assert.Nil(t, err)
+		assert.Equal(t, value, result)
+		assert.True(t, cache.Contains(context.Background(), "", "key1"))
+
+		// Stored values replace previous ones
+		value = map[string]any{"name": "XYZ"}
+		_, err = cache.Store(context.Background(), "", "key1", value, 5000)
+		assert.Nil(t, err)
+		result, err = cache.Retrieve(context.Background(), "", "key1")
+		assert.Nil(t, err)
+		assert.Equal(t, value, result)
+
+		result, err = cache.Retrieve(context.Background(), "", "key_missing")
+		assert.Nil(t, err)
+		assert.Nil(t, result)
+		assert.False(t, cache.Contains(context.Background(), "", "key_missing"))
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		_, err := cache.Store(context.Background(), "", "key2", map[string]any{"name": "ABC"}, 5000)
+		assert.Nil(t, err)
+
+		assert.Nil(t, cache.Remove(context.Background(), "", "key2"))
+		result, err := cache.Retrieve(context.Background(), "", "key2")
+		assert.Nil(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Expiration", func(t *testing.T) {
+		_, err := cache.Store(context.Background(), "", "key3", map[string]any{"name": "ABC"}, 200)
+		assert.Nil(t, err)
+		assert.True(t, cache.Contains(context.Background(), "", "key3"))
+
+		time.Sleep(500 * time.Millisecond)
+		assert.False(t, cache.Contains(context.Background(), "", "key3"))
+		result, err := cache.Retrieve(context.Background(), "", "key3")
+		assert.Nil(t, err)
+		assert.Nil(t, result)
+
+		// Expired values are removed by the background cleanup
+		count, err := cache.Cleanup(context.Background(), "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+}
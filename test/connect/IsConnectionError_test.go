@@ -0,0 +1,23 @@
+package test_connect
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	assert.False(t, conn.IsConnectionError(nil))
+	assert.False(t, conn.IsConnectionError(context.Canceled))
+	assert.False(t, conn.IsConnectionError(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, conn.IsConnectionError(errors.New("syntax error")))
+
+	assert.True(t, conn.IsConnectionError(io.ErrUnexpectedEOF))
+	assert.True(t, conn.IsConnectionError(&pgconn.PgError{Code: "08006"}))
+	assert.True(t, conn.IsConnectionError(&pgconn.PgError{Code: "57P01"}))
+}
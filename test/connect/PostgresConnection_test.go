@@ -2,11 +2,15 @@ package test_connect
 
 import (
 	"context"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
 	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
-	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/connect"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestPostgresConnection(t *testing.T) {
@@ -50,8 +54,13 @@ func TestPostgresConnection(t *testing.T) {
 		"options.idle_timeout", 100,
 	)
 
+	var hookCalls int32
 	connection = conn.NewPostgresConnection()
 	connection.Configure(context.Background(), dbConfig)
+	connection.OnAfterConnect(func(ctx context.Context, c *pgx.Conn) error {
+		atomic.AddInt32(&hookCalls, 1)
+		return nil
+	})
 	err := connection.Open(context.Background(), "")
 	assert.Nil(t, err)
 
@@ -60,4 +69,51 @@ func TestPostgresConnection(t *testing.T) {
 	assert.NotNil(t, connection.GetConnection())
 	assert.NotNil(t, connection.GetDatabaseName())
 	assert.NotEqual(t, "", connection.GetDatabaseName())
+
+	t.Run("AfterConnect", func(t *testing.T) {
+		assert.True(t, atomic.LoadInt32(&hookCalls) > 0)
+	})
+
+	t.Run("WarmUp", func(t *testing.T) {
+		warmConnection := conn.NewPostgresConnection()
+		warmConnection.Configure(context.Background(), dbConfig.Override(
+			cconf.NewConfigParamsFromTuples("options.min_pool_size", 2),
+		))
+		err := warmConnection.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer warmConnection.Close(context.Background(), "")
+
+		assert.GreaterOrEqual(t, warmConnection.GetConnection().Stat().TotalConns(), int32(2))
+	})
+
+	t.Run("Ping", func(t *testing.T) {
+		err := connection.Ping(context.Background(), "")
+		assert.Nil(t, err)
+
+		err = conn.NewPostgresConnection().Ping(context.Background(), "")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ListenNotify", func(t *testing.T) {
+		payloads := make(chan string, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		err := connection.Listen(ctx, "", "test_channel", func(ctx context.Context, channel string, payload string) {
+			payloads <- payload
+		})
+		assert.Nil(t, err)
+
+		err = connection.Notify(context.Background(), "", "test_channel", "test payload")
+		assert.Nil(t, err)
+
+		select {
+		case payload := <-payloads:
+			assert.Equal(t, "test payload", payload)
+		case <-time.After(5 * time.Second):
+			t.Error("Notification was not received")
+		}
+	})
 }
@@ -0,0 +1,24 @@
+package test_connect
+
+import (
+	"context"
+	"testing"
+
+	cref "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresHealthCheckWithClosedConnection(t *testing.T) {
+	connection := conn.NewPostgresConnection()
+
+	healthCheck := conn.NewPostgresHealthCheck()
+	descr := cref.NewDescriptor("pip-services", "connection", "postgres", "default", "1.0")
+	healthCheck.SetReferences(context.Background(), cref.NewReferencesFromTuples(context.Background(), descr, connection))
+
+	status, err := healthCheck.Check(context.Background(), "")
+	assert.NotNil(t, err)
+	assert.False(t, status.Up)
+	assert.False(t, healthCheck.IsUp())
+	assert.Equal(t, status.CheckedAt, healthCheck.GetStatus().CheckedAt)
+}
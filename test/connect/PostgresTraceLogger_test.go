@@ -0,0 +1,61 @@
+package test_connect
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/tracelog"
+	cref "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+	"github.com/stretchr/testify/assert"
+)
+
+type traceMessage struct {
+	level         clog.LevelType
+	correlationId string
+	err           error
+	message       string
+}
+
+type traceWriter struct {
+	messages []traceMessage
+}
+
+func (c *traceWriter) Write(ctx context.Context, level clog.LevelType, correlationId string, err error, message string) {
+	c.messages = append(c.messages, traceMessage{level, correlationId, err, message})
+}
+
+func TestPostgresTraceLogger(t *testing.T) {
+	writer := &traceWriter{}
+	logger := clog.InheritLogger(writer)
+	logger.SetLevel(clog.LevelTrace)
+
+	composite := clog.NewCompositeLogger()
+	composite.SetReferences(context.Background(), cref.NewReferencesFromTuples(context.Background(),
+		cref.NewDescriptor("pip-services", "logger", "test", "default", "1.0"), logger,
+	))
+
+	traceLogger := conn.NewPostgresTraceLogger(composite)
+	ctx := conn.NewContextWithCorrelationId(context.Background(), "123")
+	assert.Equal(t, "123", conn.CorrelationIdFromContext(ctx))
+
+	traceLogger.Log(ctx, tracelog.LogLevelInfo, "Query", map[string]any{
+		"sql":  "SELECT * FROM dummies WHERE key LIKE '%a%'",
+		"args": []any{"secret"},
+	})
+	traceLogger.Log(ctx, tracelog.LogLevelError, "Query", map[string]any{
+		"sql": "SELECT 1",
+		"err": errors.New("failed"),
+	})
+
+	assert.Len(t, writer.messages, 2)
+	assert.Equal(t, clog.LevelDebug, writer.messages[0].level)
+	assert.Equal(t, "123", writer.messages[0].correlationId)
+	assert.Equal(t, "postgres: Query sql=SELECT * FROM dummies WHERE key LIKE '%a%'", writer.messages[0].message)
+	assert.NotContains(t, writer.messages[0].message, "secret")
+
+	assert.Equal(t, clog.LevelWarn, writer.messages[1].level)
+	assert.NotNil(t, writer.messages[1].err)
+}
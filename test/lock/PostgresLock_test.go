@@ -0,0 +1,147 @@
+package test_lock
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cref "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+	plock "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/lock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresLockWithClosedConnection(t *testing.T) {
+	lock := plock.NewPostgresLock()
+
+	locked, err := lock.TryAcquireLock(context.Background(), "", "lock_closed", 1000)
+	assert.NotNil(t, err)
+	assert.False(t, locked)
+	assert.Nil(t, lock.ReleaseLock(context.Background(), "", "lock_closed"))
+}
+
+func TestPostgresLock(t *testing.T) {
+	postgresUri := os.Getenv("POSTGRES_URI")
+	postgresHost := os.Getenv("POSTGRES_HOST")
+	if postgresHost == "" {
+		postgresHost = "localhost"
+	}
+	postgresPort := os.Getenv("POSTGRES_PORT")
+	if postgresPort == "" {
+		postgresPort = "5432"
+	}
+	postgresDatabase := os.Getenv("POSTGRES_DB")
+	if postgresDatabase == "" {
+		postgresDatabase = "test"
+	}
+	postgresUser := os.Getenv("POSTGRES_USER")
+	if postgresUser == "" {
+		postgresUser = "postgres"
+	}
+	postgresPassword := os.Getenv("POSTGRES_PASSWORD")
+	if postgresPassword == "" {
+		postgresPassword = "postgres#"
+	}
+
+	dbConfig := cconf.NewConfigParamsFromTuples(
+		"connection.uri", postgresUri,
+		"connection.host", postgresHost,
+		"connection.port", postgresPort,
+		"connection.database", postgresDatabase,
+		"credential.username", postgresUser,
+		"credential.password", postgresPassword,
+	)
+
+	connection := conn.NewPostgresConnection()
+	connection.Configure(context.Background(), dbConfig)
+	err := connection.Open(context.Background(), "")
+	if err != nil {
+		t.Error("Error opened connection", err)
+		return
+	}
+	defer connection.Close(context.Background(), "")
+
+	references := cref.NewReferencesFromTuples(context.Background(),
+		cref.NewDescriptor("pip-services", "connection", "postgres", "default", "1.0"), connection)
+
+	lock1 := plock.NewPostgresLock()
+	lock1.Configure(context.Background(), cconf.NewConfigParamsFromTuples("options.retry_timeout", 50))
+	lock1.SetReferences(context.Background(), references)
+	assert.Nil(t, lock1.Open(context.Background(), ""))
+	defer lock1.Close(context.Background(), "")
+
+	// Locks of other sessions conflict like locks of other processes
+	lock2 := plock.NewPostgresLock()
+	lock2.Configure(context.Background(), cconf.NewConfigParamsFromTuples("options.retry_timeout", 50))
+	lock2.SetReferences(context.Background(), references)
+	assert.Nil(t, lock2.Open(context.Background(), ""))
+	defer lock2.Close(context.Background(), "")
+
+	t.Run("TryAcquireLock", func(t *testing.T) {
+		locked, err := lock1.TryAcquireLock(context.Background(), "", "lock_try", 0)
+		assert.Nil(t, err)
+		assert.True(t, locked)
+
+		locked, err = lock1.TryAcquireLock(context.Background(), "", "lock_try", 0)
+		assert.Nil(t, err)
+		assert.False(t, locked)
+
+		locked, err = lock2.TryAcquireLock(context.Background(), "", "lock_try", 0)
+		assert.Nil(t, err)
+		assert.False(t, locked)
+
+		assert.Nil(t, lock1.ReleaseLock(context.Background(), "", "lock_try"))
+
+		locked, err = lock2.TryAcquireLock(context.Background(), "", "lock_try", 0)
+		assert.Nil(t, err)
+		assert.True(t, locked)
+		assert.Nil(t, lock2.ReleaseLock(context.Background(), "", "lock_try"))
+	})
+
+	t.Run("AcquireLock", func(t *testing.T) {
+		assert.Nil(t, lock1.AcquireLock(context.Background(), "", "lock_acquire", 0, 1000))
+
+		err := lock2.AcquireLock(context.Background(), "", "lock_acquire", 0, 200)
+		assert.NotNil(t, err)
+
+		assert.Nil(t, lock1.ReleaseLock(context.Background(), "", "lock_acquire"))
+		assert.Nil(t, lock2.AcquireLock(context.Background(), "", "lock_acquire", 0, 1000))
+		assert.Nil(t, lock2.ReleaseLock(context.Background(), "", "lock_acquire"))
+	})
+
+	t.Run("Ttl", func(t *testing.T) {
+		locked, err := lock1.TryAcquireLock(context.Background(), "", "lock_ttl", 200)
+		assert.Nil(t, err)
+		assert.True(t, locked)
+
+		locked, err = lock2.TryAcquireLock(context.Background(), "", "lock_ttl", 200)
+		assert.Nil(t, err)
+		assert.False(t, locked)
+
+		// Expired locks are released by their holders
+		time.Sleep(500 * time.Millisecond)
+		locked, err = lock2.TryAcquireLock(context.Background(), "", "lock_ttl", 0)
+		assert.Nil(t, err)
+		assert.True(t, locked)
+		assert.Nil(t, lock2.ReleaseLock(context.Background(), "", "lock_ttl"))
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		lock3 := plock.NewPostgresLock()
+		lock3.SetReferences(context.Background(), references)
+		assert.Nil(t, lock3.Open(context.Background(), ""))
+
+		locked, err := lock3.TryAcquireLock(context.Background(), "", "lock_close", 0)
+		assert.Nil(t, err)
+		assert.True(t, locked)
+
+		// Closing releases all locks of the component
+		assert.Nil(t, lock3.Close(context.Background(), ""))
+		locked, err = lock1.TryAcquireLock(context.Background(), "", "lock_close", 0)
+		assert.Nil(t, err)
+		assert.True(t, locked)
+		assert.Nil(t, lock1.ReleaseLock(context.Background(), "", "lock_close"))
+	})
+}
@@ -0,0 +1,29 @@
+package test
+
+import (
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
+)
+
+// DummyExtendedJsonPostgresPersistence keeps dummies in a JSON table which is extended with a GIN index,
+// an extracted content column, a text search column and checks of data types.
+type DummyExtendedJsonPostgresPersistence struct {
+	*persist.IdentifiableJsonPostgresPersistence[fixtures.Dummy, string]
+}
+
+func NewDummyExtendedJsonPostgresPersistence() *DummyExtendedJsonPostgresPersistence {
+	c := &DummyExtendedJsonPostgresPersistence{}
+	c.IdentifiableJsonPostgresPersistence = persist.InheritIdentifiableJsonPostgresPersistence[fixtures.Dummy, string](c, "dummies_json_extended")
+	return c
+}
+
+func (c *DummyExtendedJsonPostgresPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiableJsonPostgresPersistence.DefineSchema()
+	c.EnsureTable("", "")
+	c.EnsureIndex(c.TableName+"_key", map[string]string{"(data->'key')": "1"}, map[string]string{"unique": "true"})
+	c.EnsureDataIndex(map[string]string{"ops": persist.JsonbPathOps})
+	c.EnsureExtractedColumn("content", "TEXT")
+	c.EnsureSearchColumn("search", []string{"key", "content"}, "simple")
+	c.EnsureDataTypes(map[string]string{"key": persist.JsonTypeString, "content": persist.JsonTypeString}, []string{"id"})
+}
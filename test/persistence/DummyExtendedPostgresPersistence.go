@@ -0,0 +1,30 @@
+package test
+
+import (
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
+)
+
+// DummyExtendedPostgresPersistence keeps dummies in a table which is extended with a covering partial index
+// and an updated_at column maintained by a trigger.
+type DummyExtendedPostgresPersistence struct {
+	persist.IdentifiablePostgresPersistence[fixtures.Dummy, string]
+}
+
+func NewDummyExtendedPostgresPersistence() *DummyExtendedPostgresPersistence {
+	c := &DummyExtendedPostgresPersistence{}
+	c.IdentifiablePostgresPersistence = *persist.InheritIdentifiablePostgresPersistence[fixtures.Dummy, string](c, "dummies_extended")
+	return c
+}
+
+func (c *DummyExtendedPostgresPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"key\" TEXT, \"content\" TEXT)")
+	c.EnsureIndex(c.IdentifiablePostgresPersistence.TableName+"_key", map[string]string{"key": "1"}, map[string]string{"unique": "true"})
+	c.EnsureIndexWithKeys(c.IdentifiablePostgresPersistence.TableName+"_content",
+		[]persist.PostgresIndexKey{persist.IndexKey("content"), persist.IndexKeyDesc("id")},
+		map[string]string{"type": "btree", "include": "key", "with": "fillfactor=90", "where": "\"content\" IS NOT NULL"})
+	c.EnsureColumn("updated_at", "TIMESTAMP WITH TIME ZONE", "now()")
+	c.EnsureUpdatedAt("updated_at")
+}
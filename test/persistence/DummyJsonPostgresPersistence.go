@@ -4,8 +4,8 @@ import (
 	"context"
 
 	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
-	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/persistence"
-	"github.com/pip-services3-gox/pip-services3-postgres-gox/test/fixtures"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
 )
 
 type DummyJsonPostgresPersistence struct {
@@ -25,17 +25,18 @@ func (c *DummyJsonPostgresPersistence) DefineSchema() {
 	c.EnsureIndex(c.TableName+"_key", map[string]string{"(data->'key')": "1"}, map[string]string{"unique": "true"})
 }
 
+func (c *DummyJsonPostgresPersistence) composeFilter(filter cdata.FilterParams) (string, []any) {
+	return c.JsonFilterBuilder().
+		Equal("Key", "key", persist.JsonTypeString).
+		Build(filter)
+}
+
 func (c *DummyJsonPostgresPersistence) GetPageByFilter(ctx context.Context, correlationId string,
 	filter cdata.FilterParams, paging cdata.PagingParams) (page cdata.DataPage[fixtures.Dummy], err error) {
 
-	key, ok := filter.GetAsNullableString("Key")
-	filterObj := ""
-	if ok && key != "" {
-		filterObj += "data->>'key'='" + key + "'"
-	}
-
-	return c.IdentifiableJsonPostgresPersistence.GetPageByFilter(ctx, correlationId,
-		filterObj, paging,
+	where, params := c.composeFilter(filter)
+	return c.IdentifiableJsonPostgresPersistence.GetPageByFilterWithParams(ctx, correlationId,
+		where, params, paging,
 		"", "",
 	)
 }
@@ -43,12 +44,8 @@ func (c *DummyJsonPostgresPersistence) GetPageByFilter(ctx context.Context, corr
 func (c *DummyJsonPostgresPersistence) GetCountByFilter(ctx context.Context, correlationId string,
 	filter cdata.FilterParams) (count int64, err error) {
 
-	filterObj := ""
-	if key, ok := filter.GetAsNullableString("Key"); ok && key != "" {
-		filterObj += "data->>'key'='" + key + "'"
-	}
-
-	return c.IdentifiableJsonPostgresPersistence.GetCountByFilter(ctx, correlationId, filterObj)
+	where, params := c.composeFilter(filter)
+	return c.IdentifiableJsonPostgresPersistence.GetCountByFilterWithParams(ctx, correlationId, where, params)
 }
 
 func (c *DummyJsonPostgresPersistence) GetOneRandom(ctx context.Context, correlationId string) (item fixtures.Dummy, err error) {
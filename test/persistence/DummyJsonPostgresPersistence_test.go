@@ -6,7 +6,7 @@ import (
 	"testing"
 
 	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
-	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/test/fixtures"
+	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
 )
 
 func TestDummyJsonPostgresPersistence(t *testing.T) {
@@ -78,5 +78,4 @@ func TestDummyJsonPostgresPersistence(t *testing.T) {
 	}
 
 	t.Run("DummyPostgresConnection:Batch", fixture.TestBatchOperations)
-
 }
@@ -3,7 +3,7 @@ package test
 import (
 	"context"
 	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
-	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/persistence"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
 )
 
 type DummyMapPostgresPersistence struct {
@@ -23,28 +23,25 @@ func (c *DummyMapPostgresPersistence) DefineSchema() {
 	c.EnsureIndex(c.IdentifiablePostgresPersistence.TableName+"_key", map[string]string{"key": "1"}, map[string]string{"unique": "true"})
 }
 
+func (c *DummyMapPostgresPersistence) composeFilter(filter cdata.FilterParams) (string, []any) {
+	return persist.NewPostgresFilterBuilder().
+		Equal("Key", persist.Column("key")).
+		Build(filter)
+}
+
 func (c *DummyMapPostgresPersistence) GetPageByFilter(ctx context.Context, correlationId string,
 	filter cdata.FilterParams, paging cdata.PagingParams) (page cdata.DataPage[map[string]any], err error) {
 
-	key, ok := filter.GetAsNullableString("Key")
-	filterObj := ""
-	if ok && key != "" {
-		filterObj += "key='" + key + "'"
-	}
-	sorting := ""
-
-	return c.IdentifiablePostgresPersistence.GetPageByFilter(ctx, correlationId,
-		filterObj, paging, sorting, "",
+	where, params := c.composeFilter(filter)
+	return c.IdentifiablePostgresPersistence.GetPageByFilterWithParams(ctx, correlationId,
+		where, params, paging,
+		"", "",
 	)
 }
 
 func (c *DummyMapPostgresPersistence) GetCountByFilter(ctx context.Context, correlationId string,
 	filter cdata.FilterParams) (count int64, err error) {
 
-	key, ok := filter.GetAsNullableString("Key")
-	filterObj := ""
-	if ok && key != "" {
-		filterObj += "key='" + key + "'"
-	}
-	return c.IdentifiablePostgresPersistence.GetCountByFilter(ctx, correlationId, filterObj)
+	where, params := c.composeFilter(filter)
+	return c.IdentifiablePostgresPersistence.GetCountByFilterWithParams(ctx, correlationId, where, params)
 }
@@ -4,9 +4,13 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
-	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/test/fixtures"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestDummyMapPostgresPersistence(t *testing.T) {
@@ -80,4 +84,91 @@ func TestDummyMapPostgresPersistence(t *testing.T) {
 
 	t.Run("DummyMapPostgresPersistence:Batch", fixture.TestBatchOperations)
 
+	t.Run("DummyMapPostgresPersistence:InferSchema", func(t *testing.T) {
+		inferredPersistence := &inferredMapPostgresPersistence{}
+		inferredPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[map[string]any, string](inferredPersistence, "dummies_inferred")
+		inferredPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.infer_schema", true,
+		).SetDefaults(dbConfig))
+
+		err := inferredPersistence.Open(context.Background(), "")
+		assert.Nil(t, err)
+		defer inferredPersistence.Close(context.Background(), "")
+		defer inferredPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE IF EXISTS "+inferredPersistence.QuotedTableName())
+
+		item, err := inferredPersistence.Create(context.Background(), "", map[string]any{
+			"id":      "inferred_1",
+			"key":     "Key 1",
+			"amount":  12.5,
+			"active":  true,
+			"created": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			"tags":    []any{"a", "b"},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "Key 1", item["key"])
+
+		item, err = inferredPersistence.UpdatePartially(context.Background(), "", "inferred_1",
+			*cdata.NewAnyValueMapFromTuples("comment", "Inferred comment"))
+		assert.Nil(t, err)
+		assert.Equal(t, "Inferred comment", item["comment"])
+
+		rows, err := inferredPersistence.ExecuteQuery(context.Background(), "",
+			"SELECT attname, format_type(atttypid, atttypmod) FROM pg_attribute"+
+				" WHERE attrelid=$1::regclass AND attnum>0 AND NOT attisdropped", inferredPersistence.QuotedTableName())
+		assert.Nil(t, err)
+		columns := make(map[string]string)
+		for _, row := range rows {
+			columns[row["attname"].(string)] = row["format_type"].(string)
+		}
+		assert.Equal(t, map[string]string{
+			"id":      "text",
+			"key":     "text",
+			"amount":  "double precision",
+			"active":  "boolean",
+			"created": "timestamp with time zone",
+			"tags":    "jsonb",
+			"comment": "text",
+		}, columns)
+	})
+
+	t.Run("DummyMapPostgresPersistence:TableFromTypes", func(t *testing.T) {
+		typedPersistence := &inferredMapPostgresPersistence{columnTypes: map[string]string{
+			"key":    "VARCHAR(50)",
+			"amount": "NUMERIC(12,2)",
+		}}
+		typedPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[map[string]any, string](typedPersistence, "dummies_typed")
+		typedPersistence.Configure(context.Background(), dbConfig)
+
+		err := typedPersistence.Open(context.Background(), "")
+		assert.Nil(t, err)
+		defer typedPersistence.Close(context.Background(), "")
+		defer typedPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+typedPersistence.QuotedTableName())
+
+		item, err := typedPersistence.Create(context.Background(), "", map[string]any{"id": "typed_1", "key": "Key 1", "amount": 10.25})
+		assert.Nil(t, err)
+		assert.Equal(t, "Key 1", item["key"])
+
+		// Fields without columns are not inferred unless options.infer_schema is set
+		_, err = typedPersistence.Create(context.Background(), "", map[string]any{"id": "typed_2", "comment": "Comment"})
+		assert.NotNil(t, err)
+
+		problems, err := typedPersistence.ValidateSchema(context.Background(), "")
+		assert.Nil(t, err)
+		assert.Len(t, problems, 0)
+	})
+}
+
+type inferredMapPostgresPersistence struct {
+	*persist.IdentifiablePostgresPersistence[map[string]any, string]
+	columnTypes map[string]string
+}
+
+func (c *inferredMapPostgresPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	if c.columnTypes != nil {
+		c.EnsureTableFromTypes(c.columnTypes)
+	}
 }
@@ -2,13 +2,16 @@ package test
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
 
+	"github.com/jackc/pgx/v5"
 	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
 	cref "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
-	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/connect"
-	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/test/fixtures"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -102,4 +105,61 @@ func TestDummyPostgresConnection(t *testing.T) {
 
 	t.Run("DummyPostgresConnection:Batch", fixture.TestBatchOperations)
 
+	opnErr = persistence.Clear(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
+	}
+
+	t.Run("DummyPostgresConnection:Transaction", func(t *testing.T) {
+		dummy := tf.Dummy{Id: "", Key: "Key tx", Content: "Content tx"}
+
+		// Rollback on error
+		err := connection.WithTransaction(context.Background(), "",
+			func(ctx context.Context, tx pgx.Tx) error {
+				_, err := persistence.Create(ctx, "", dummy)
+				assert.Nil(t, err)
+				return errors.New("rollback")
+			})
+		assert.NotNil(t, err)
+
+		count, err := persistence.GetCountByFilter(context.Background(), "", *cdata.NewEmptyFilterParams())
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), count)
+
+		// Commit on success
+		err = connection.WithTransaction(context.Background(), "",
+			func(ctx context.Context, tx pgx.Tx) error {
+				_, err := persistence.Create(ctx, "", dummy)
+				return err
+			})
+		assert.Nil(t, err)
+
+		count, err = persistence.GetCountByFilter(context.Background(), "", *cdata.NewEmptyFilterParams())
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("DummyPostgresConnection:TagSessions", func(t *testing.T) {
+		persistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.tag_sessions", true,
+		))
+		defer persistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.tag_sessions", false,
+		))
+
+		dummy, err := persistence.Create(context.Background(), "tag-123", tf.Dummy{Key: "Key tag", Content: "Content tag"})
+		assert.Nil(t, err)
+
+		item, err := persistence.GetOneById(context.Background(), "tag-123", dummy.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, dummy.Id, item.Id)
+
+		// Tags are removed when connections are returned to the pool
+		var count int
+		err = connection.GetConnection().QueryRow(context.Background(),
+			"SELECT count(*) FROM pg_stat_activity WHERE application_name=$1", "tag-123").Scan(&count)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
 }
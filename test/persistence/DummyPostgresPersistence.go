@@ -3,8 +3,8 @@ package test
 import (
 	"context"
 	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
-	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/persistence"
-	"github.com/pip-services3-gox/pip-services3-postgres-gox/test/fixtures"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
 )
 
 type DummyPostgresPersistence struct {
@@ -25,31 +25,27 @@ func (c *DummyPostgresPersistence) DefineSchema() {
 	c.EnsureIndex(c.IdentifiablePostgresPersistence.TableName+"_key", map[string]string{"key": "1"}, map[string]string{"unique": "true"})
 }
 
+func (c *DummyPostgresPersistence) composeFilter(filter cdata.FilterParams) (string, []any) {
+	return persist.NewPostgresFilterBuilder().
+		Equal("Key", persist.Column("key")).
+		Build(filter)
+}
+
 func (c *DummyPostgresPersistence) GetPageByFilter(ctx context.Context, correlationId string,
 	filter cdata.FilterParams, paging cdata.PagingParams) (page cdata.DataPage[fixtures.Dummy], err error) {
 
-	key, ok := filter.GetAsNullableString("Key")
-	filterObj := ""
-	if ok && key != "" {
-		filterObj += "key='" + key + "'"
-	}
-	sorting := ""
-
-	return c.IdentifiablePostgresPersistence.GetPageByFilter(ctx, correlationId,
-		filterObj, paging,
-		sorting, "",
+	where, params := c.composeFilter(filter)
+	return c.IdentifiablePostgresPersistence.GetPageByFilterWithParams(ctx, correlationId,
+		where, params, paging,
+		"", "",
 	)
 }
 
 func (c *DummyPostgresPersistence) GetCountByFilter(ctx context.Context, correlationId string,
 	filter cdata.FilterParams) (count int64, err error) {
 
-	key, ok := filter.GetAsNullableString("Key")
-	filterObj := ""
-	if ok && key != "" {
-		filterObj += "key='" + key + "'"
-	}
-	return c.IdentifiablePostgresPersistence.GetCountByFilter(ctx, correlationId, filterObj)
+	where, params := c.composeFilter(filter)
+	return c.IdentifiablePostgresPersistence.GetCountByFilterWithParams(ctx, correlationId, where, params)
 }
 
 func (c *DummyPostgresPersistence) GetOneRandom(ctx context.Context, correlationId string) (item fixtures.Dummy, err error) {
@@ -6,7 +6,7 @@ import (
 	"testing"
 
 	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
-	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/test/fixtures"
+	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
 )
 
 func TestDummyRefPostgresPersistence(t *testing.T) {
@@ -0,0 +1,25 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityMapContext(t *testing.T) {
+	assert.Nil(t, persist.IdentityMapFromContext(context.Background()))
+
+	ctx := persist.NewContextWithIdentityMap(context.Background())
+	identityMap := persist.IdentityMapFromContext(ctx)
+	assert.NotNil(t, identityMap)
+	assert.Equal(t, 0, identityMap.Len())
+
+	// Derived contexts share the identity map of the request
+	childCtx := persist.NewContextWithMaxPageSize(ctx, 10)
+	assert.Same(t, identityMap, persist.IdentityMapFromContext(childCtx))
+
+	identityMap.Clear()
+	assert.Equal(t, 0, identityMap.Len())
+}
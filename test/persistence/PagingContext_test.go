@@ -0,0 +1,23 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxPageSizeContext(t *testing.T) {
+	_, ok := persist.MaxPageSizeFromContext(context.Background())
+	assert.False(t, ok)
+
+	ctx := persist.NewContextWithMaxPageSize(context.Background(), 5000)
+	size, ok := persist.MaxPageSizeFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, int64(5000), size)
+
+	ctx = persist.NewContextWithMaxPageSize(context.Background(), 0)
+	_, ok = persist.MaxPageSizeFromContext(ctx)
+	assert.False(t, ok)
+}
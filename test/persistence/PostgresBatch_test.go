@@ -0,0 +1,113 @@
+package test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresBatch(t *testing.T) {
+	dbConfig := newPostgresTestConfig().Override(cconf.NewConfigParamsFromTuples("schema", "test_schema"))
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(context.Background(), dbConfig)
+
+	opnErr := persistence.Open(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error opened persistence", opnErr)
+		return
+	}
+	defer persistence.Close(context.Background(), "")
+
+	opnErr = persistence.Clear(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
+	}
+
+	t.Run("DummyPostgresPersistence:SendBatch", func(t *testing.T) {
+		batch := persistence.NewBatch()
+		for index := 0; index < 3; index++ {
+			err := persistence.QueueCreate(batch, tf.Dummy{Key: "Batch " + strconv.Itoa(index), Content: "Batch content"})
+			assert.Nil(t, err)
+		}
+		persistence.QueueDeleteById(batch, "unknown_id")
+		assert.Equal(t, 4, batch.Len())
+
+		results, err := persistence.ExecuteBatch(context.Background(), "", batch)
+		assert.Nil(t, err)
+		assert.Len(t, results, 4)
+		for index := 0; index < 3; index++ {
+			assert.Nil(t, results[index].Err)
+			assert.True(t, results[index].Found)
+			assert.NotEmpty(t, results[index].Item.Id)
+			assert.Equal(t, "Batch "+strconv.Itoa(index), results[index].Item.Key)
+		}
+		assert.Nil(t, results[3].Err)
+		assert.False(t, results[3].Found)
+		assert.Equal(t, int64(0), results[3].RowsAffected)
+
+		item := results[0].Item
+		item.Content = "Updated batch content"
+		batch = persistence.NewBatch()
+		assert.Nil(t, persistence.QueueUpdate(batch, item))
+		persistence.QueueDeleteById(batch, results[1].Item.Id)
+
+		results, err = persistence.ExecuteBatch(context.Background(), "", batch)
+		assert.Nil(t, err)
+		assert.Len(t, results, 2)
+		assert.Nil(t, results[0].Err)
+		assert.Equal(t, "Updated batch content", results[0].Item.Content)
+		assert.Nil(t, results[1].Err)
+		assert.Equal(t, int64(1), results[1].RowsAffected)
+	})
+
+	t.Run("DummyPostgresPersistence:CreateMany", func(t *testing.T) {
+		persistence.MaxBatchSize = 2
+		defer func() { persistence.MaxBatchSize = 1000 }()
+
+		items := []tf.Dummy{
+			{Key: "Many 1", Content: "Many content"},
+			{Id: "many_2", Key: "Many 2", Content: "Many content"},
+			{Key: "Many 3", Content: "Many content"},
+		}
+		created, err := persistence.CreateMany(context.Background(), "", items)
+		assert.Nil(t, err)
+		assert.Len(t, created, 3)
+		for index, item := range created {
+			assert.NotEmpty(t, item.Id)
+			assert.Equal(t, items[index].Key, item.Key)
+		}
+		assert.Equal(t, "many_2", created[1].Id)
+
+		created, err = persistence.CreateMany(context.Background(), "", []tf.Dummy{})
+		assert.Nil(t, err)
+		assert.Len(t, created, 0)
+	})
+
+	t.Run("DummyPostgresPersistence:SetMany", func(t *testing.T) {
+		_, err := persistence.Create(context.Background(), "", tf.Dummy{Id: "set_many_1", Key: "Set many 1", Content: "Old content"})
+		assert.Nil(t, err)
+
+		items := []tf.Dummy{
+			{Id: "set_many_1", Key: "Set many 1", Content: "New content"},
+			{Id: "set_many_2", Key: "Set many 2", Content: "First content"},
+			{Id: "set_many_2", Key: "Set many 2", Content: "Last content"},
+		}
+		results, err := persistence.SetMany(context.Background(), "", items)
+		assert.Nil(t, err)
+		assert.Len(t, results, 2)
+
+		item, err := persistence.GetOneById(context.Background(), "", "set_many_1")
+		assert.Nil(t, err)
+		assert.Equal(t, "New content", item.Content)
+
+		item, err = persistence.GetOneById(context.Background(), "", "set_many_2")
+		assert.Nil(t, err)
+		assert.Equal(t, "Last content", item.Content)
+	})
+}
@@ -0,0 +1,49 @@
+package test
+
+import (
+	"testing"
+
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresCondition(t *testing.T) {
+	where, params := persist.Eq(persist.Column("key"), "Key 1").Build()
+	assert.Equal(t, "\"key\"=$1", where)
+	assert.Equal(t, []any{"Key 1"}, params)
+
+	where, params = persist.And(
+		persist.Eq(persist.Column("key"), "Key 1"),
+		persist.Or(
+			persist.Like(persist.JsonField("data", "content"), "%test%"),
+			persist.Between(persist.Column("amount"), 1, 10),
+		),
+		persist.In(persist.Column("id"), []string{"1", "2"}),
+		persist.IsNotNull(persist.Column("content")),
+	).Build()
+	assert.Equal(t, "(\"key\"=$1 AND (\"data\"->>'content' LIKE $2 OR \"amount\" BETWEEN $3 AND $4)"+
+		" AND \"id\"=ANY($5) AND \"content\" IS NOT NULL)", where)
+	assert.Equal(t, []any{"Key 1", "%test%", 1, 10, []string{"1", "2"}}, params)
+}
+
+func TestPostgresConditionBuildFrom(t *testing.T) {
+	where, params := persist.Not(persist.Or(
+		persist.Gt(persist.Column("amount"), 5),
+		persist.ILike(persist.Column("key"), "abc%"),
+	)).BuildFrom(3)
+	assert.Equal(t, "NOT ((\"amount\">$3 OR \"key\" ILIKE $4))", where)
+	assert.Equal(t, []any{5, "abc%"}, params)
+}
+
+func TestPostgresConditionEmpty(t *testing.T) {
+	condition := persist.And(persist.Or(), persist.And())
+	assert.True(t, condition.IsEmpty())
+
+	where, params := condition.Build()
+	assert.Equal(t, "", where)
+	assert.Len(t, params, 0)
+
+	where, _ = persist.And(persist.Or(), persist.IsNull(persist.Column("key"))).Build()
+	assert.Equal(t, "\"key\" IS NULL", where)
+	assert.False(t, persist.Not(persist.IsNull(persist.Column("key"))).IsEmpty())
+}
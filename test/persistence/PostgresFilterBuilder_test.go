@@ -0,0 +1,35 @@
+package test
+
+import (
+	"testing"
+
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresFilterBuilder(t *testing.T) {
+	builder := persist.NewPostgresFilterBuilder().
+		Equal("key", persist.Column("key")).
+		In("ids", persist.Column("id")).
+		Like("search", persist.JsonField("data", "content.text")).
+		Compare("min_count", persist.Column("count"), ">=")
+
+	filter := *cdata.NewFilterParamsFromTuples(
+		"key", "k' OR '1'='1",
+		"ids", "1, 2,3",
+		"search", "50%",
+		"min_count", "5",
+	)
+	where, params := builder.Build(filter)
+	assert.Equal(t, "\"key\"=$1 AND \"id\"=ANY($2) AND \"data\"->'content'->>'text' ILIKE $3 AND \"count\">=$4", where)
+	assert.Equal(t, []any{"k' OR '1'='1", []string{"1", "2", "3"}, "%50\\%%", "5"}, params)
+
+	where, params = builder.BuildFrom(*cdata.NewFilterParamsFromTuples("min_count", "5"), 3)
+	assert.Equal(t, "\"count\">=$3", where)
+	assert.Equal(t, []any{"5"}, params)
+
+	where, params = builder.Build(*cdata.NewEmptyFilterParams())
+	assert.Equal(t, "", where)
+	assert.Len(t, params, 0)
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"os"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
+)
+
+// newPostgresTestConfig composes connection parameters for the test database
+// from POSTGRES_* environment variables.
+func newPostgresTestConfig() *cconf.ConfigParams {
+	postgresUri := os.Getenv("POSTGRES_URI")
+	postgresHost := os.Getenv("POSTGRES_HOST")
+	if postgresHost == "" {
+		postgresHost = "localhost"
+	}
+
+	postgresPort := os.Getenv("POSTGRES_PORT")
+	if postgresPort == "" {
+		postgresPort = "5432"
+	}
+
+	postgresDatabase := os.Getenv("POSTGRES_DB")
+	if postgresDatabase == "" {
+		postgresDatabase = "test"
+	}
+
+	postgresUser := os.Getenv("POSTGRES_USER")
+	if postgresUser == "" {
+		postgresUser = "postgres"
+	}
+	postgresPassword := os.Getenv("POSTGRES_PASSWORD")
+	if postgresPassword == "" {
+		postgresPassword = "postgres#"
+	}
+
+	if postgresUri == "" && postgresHost == "" {
+		panic("Connection params not set")
+	}
+
+	return cconf.NewConfigParamsFromTuples(
+		"connection.uri", postgresUri,
+		"connection.host", postgresHost,
+		"connection.port", postgresPort,
+		"connection.database", postgresDatabase,
+		"credential.username", postgresUser,
+		"credential.password", postgresPassword,
+	)
+}
+
+type autoDummyPostgresPersistence struct {
+	*persist.IdentifiablePostgresPersistence[tf.Dummy, string]
+}
+
+type nestedDummy struct {
+	Id   string         `json:"id"`
+	Key  string         `json:"key"`
+	Data map[string]any `json:"data"`
+}
+
+type nestedJsonPostgresPersistence struct {
+	*persist.IdentifiableJsonPostgresPersistence[nestedDummy, string]
+	dataType  string
+	uniqueKey []string
+}
+
+func (c *nestedJsonPostgresPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiableJsonPostgresPersistence.DefineSchema()
+	c.EnsureTable("", c.dataType)
+	if len(c.uniqueKey) > 0 {
+		c.EnsureUniqueDataKey(c.uniqueKey...)
+	}
+}
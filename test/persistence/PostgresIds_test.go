@@ -0,0 +1,175 @@
+package test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresIds(t *testing.T) {
+	dbConfig := newPostgresTestConfig().Override(cconf.NewConfigParamsFromTuples("schema", "test_schema"))
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(context.Background(), dbConfig)
+
+	opnErr := persistence.Open(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error opened persistence", opnErr)
+		return
+	}
+	defer persistence.Close(context.Background(), "")
+
+	opnErr = persistence.Clear(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
+	}
+
+	t.Run("DummyPostgresPersistence:GetListByIdsInOrder", func(t *testing.T) {
+		for _, id := range []string{"ordered_1", "ordered_2", "ordered_3"} {
+			_, err := persistence.Create(context.Background(), "", tf.Dummy{Id: id, Key: id, Content: "Ordered"})
+			assert.Nil(t, err)
+		}
+
+		items, missing, err := persistence.GetListByIdsInOrder(context.Background(), "",
+			[]string{"ordered_3", "ordered_missing", "ordered_1", "ordered_2", "ordered_3"})
+		assert.Nil(t, err)
+		assert.Len(t, items, 3)
+		assert.Equal(t, "ordered_3", items[0].Id)
+		assert.Equal(t, "ordered_1", items[1].Id)
+		assert.Equal(t, "ordered_2", items[2].Id)
+		assert.Equal(t, []string{"ordered_missing"}, missing)
+	})
+
+	t.Run("DummyPostgresPersistence:ManyIds", func(t *testing.T) {
+		// More ids than bound parameters PostgreSQL accepts in a statement
+		ids := make([]string, 70000)
+		for index := range ids {
+			ids[index] = "many_" + strconv.Itoa(index)
+		}
+		_, err := persistence.Create(context.Background(), "", tf.Dummy{Id: ids[len(ids)-1], Key: "Many", Content: "Many"})
+		assert.Nil(t, err)
+
+		items, err := persistence.GetListByIds(context.Background(), "", ids)
+		assert.Nil(t, err)
+		assert.Len(t, items, 1)
+
+		err = persistence.DeleteByIds(context.Background(), "", ids)
+		assert.Nil(t, err)
+
+		items, err = persistence.GetListByIds(context.Background(), "", ids)
+		assert.Nil(t, err)
+		assert.Len(t, items, 0)
+	})
+
+	t.Run("DummyPostgresPersistence:SequenceIds", func(t *testing.T) {
+		sequencePersistence := &sequenceDummyPostgresPersistence{}
+		sequencePersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[sequenceDummy, int64](sequencePersistence, "dummies_sequence")
+		sequencePersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.auto_create_table", true,
+			"options.id_sequence", "dummies_sequence_id",
+		).SetDefaults(dbConfig))
+
+		err := sequencePersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer sequencePersistence.Close(context.Background(), "")
+		defer sequencePersistence.ExecuteNonQuery(context.Background(), "",
+			"DROP SEQUENCE \"test_schema\".\"dummies_sequence_id\"")
+		defer sequencePersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+sequencePersistence.QuotedTableName())
+
+		item1, err := sequencePersistence.Create(context.Background(), "", sequenceDummy{Key: "Sequence 1"})
+		assert.Nil(t, err)
+		assert.Greater(t, item1.Id, int64(0))
+
+		items, err := sequencePersistence.CreateMany(context.Background(), "",
+			[]sequenceDummy{{Key: "Sequence 2"}, {Key: "Sequence 3"}})
+		assert.Nil(t, err)
+		assert.Len(t, items, 2)
+		assert.Greater(t, items[0].Id, item1.Id)
+		assert.Greater(t, items[1].Id, items[0].Id)
+
+		item, err := sequencePersistence.GetOneById(context.Background(), "", item1.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, "Sequence 1", item.Key)
+	})
+
+	t.Run("DummyPostgresPersistence:IntegerIds", func(t *testing.T) {
+		identityPersistence := &sequenceDummyPostgresPersistence{}
+		identityPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[sequenceDummy, int64](identityPersistence, "dummies_identity")
+		identityPersistence.Configure(context.Background(),
+			cconf.NewConfigParamsFromTuples("options.auto_create_table", true).SetDefaults(dbConfig))
+
+		err := identityPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer identityPersistence.Close(context.Background(), "")
+		defer identityPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+identityPersistence.QuotedTableName())
+
+		item1, err := identityPersistence.Create(context.Background(), "", sequenceDummy{Key: "Identity 1"})
+		assert.Nil(t, err)
+		assert.Greater(t, item1.Id, int64(0))
+
+		item2, err := identityPersistence.Create(context.Background(), "", sequenceDummy{Key: "Identity 2"})
+		assert.Nil(t, err)
+		assert.Greater(t, item2.Id, item1.Id)
+
+		items, err := identityPersistence.GetListByIds(context.Background(), "", []int64{item1.Id, item2.Id})
+		assert.Nil(t, err)
+		assert.Len(t, items, 2)
+
+		items, err = identityPersistence.GetListByIds(context.Background(), "", []int64{})
+		assert.Nil(t, err)
+		assert.Len(t, items, 0)
+
+		err = identityPersistence.DeleteByIds(context.Background(), "", []int64{item1.Id, item2.Id})
+		assert.Nil(t, err)
+
+		items, err = identityPersistence.GetListByIds(context.Background(), "", []int64{item1.Id, item2.Id})
+		assert.Nil(t, err)
+		assert.Len(t, items, 0)
+	})
+
+	t.Run("DummyPostgresPersistence:ServerIds", func(t *testing.T) {
+		uuidPersistence := &autoDummyPostgresPersistence{}
+		uuidPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[tf.Dummy, string](uuidPersistence, "dummies_uuid")
+		uuidPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.auto_create_table", true,
+			"options.server_ids", true,
+		).SetDefaults(dbConfig))
+
+		err := uuidPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer uuidPersistence.Close(context.Background(), "")
+		defer uuidPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+uuidPersistence.QuotedTableName())
+
+		item, err := uuidPersistence.Create(context.Background(), "", tf.Dummy{Key: "Uuid 1"})
+		assert.Nil(t, err)
+		assert.Len(t, item.Id, 36)
+
+		item, err = uuidPersistence.Create(context.Background(), "", tf.Dummy{Id: "uuid_2", Key: "Uuid 2"})
+		assert.Nil(t, err)
+		assert.Equal(t, "uuid_2", item.Id)
+	})
+}
+
+type sequenceDummy struct {
+	Id  int64  `json:"id"`
+	Key string `json:"key"`
+}
+
+type sequenceDummyPostgresPersistence struct {
+	*persist.IdentifiablePostgresPersistence[sequenceDummy, int64]
+}
@@ -0,0 +1,19 @@
+package test
+
+import (
+	"testing"
+
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresJoin(t *testing.T) {
+	join := persist.LeftJoin("customers", "c", "\"c\".\"id\"=\"orders\".\"customer_id\"")
+	assert.Equal(t, "LEFT", join.Type)
+	assert.Len(t, join.Columns, 0)
+
+	selected := join.Select("name").SelectAs("email", "customer_email")
+	assert.Equal(t, map[string]string{"name": "name", "email": "customer_email"}, selected.Columns)
+	// The original definition is not modified
+	assert.Len(t, join.Columns, 0)
+}
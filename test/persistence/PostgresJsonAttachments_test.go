@@ -0,0 +1,93 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresJsonAttachments(t *testing.T) {
+	dbConfig := newPostgresTestConfig()
+
+	persistence := NewDummyJsonPostgresPersistence()
+	persistence.Configure(context.Background(), dbConfig)
+
+	opnErr := persistence.Open(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error opened persistence", opnErr)
+		return
+	}
+	defer persistence.Close(context.Background(), "")
+
+	opnErr = persistence.Clear(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
+	}
+
+	t.Run("DummyPostgresConnection:Attachments", func(t *testing.T) {
+		attachmentPersistence := &nestedJsonPostgresPersistence{}
+		attachmentPersistence.IdentifiableJsonPostgresPersistence =
+			persist.InheritIdentifiableJsonPostgresPersistence[nestedDummy, string](attachmentPersistence, "dummies_json_files")
+		attachmentPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.attachments", true,
+			"options.attachment_chunk_size", 1000,
+		).SetDefaults(dbConfig))
+
+		err := attachmentPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer attachmentPersistence.Close(context.Background(), "")
+		defer attachmentPersistence.ExecuteNonQuery(context.Background(), "",
+			"DROP TABLE "+persist.Column("dummies_json_files_attachments")+", "+attachmentPersistence.QuotedTableName())
+
+		_, err = attachmentPersistence.WriteAttachment(context.Background(), "", "file_1", "missing.txt", strings.NewReader("text"))
+		assert.NotNil(t, err)
+
+		_, err = attachmentPersistence.Create(context.Background(), "", nestedDummy{Id: "file_1", Key: "Key 1"})
+		assert.Nil(t, err)
+
+		content := bytes.Repeat([]byte{0, 1, 2, 255}, 1000)
+		size, err := attachmentPersistence.WriteAttachment(context.Background(), "", "file_1", "image.bin", bytes.NewReader(content))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(len(content)), size)
+		_, err = attachmentPersistence.WriteAttachment(context.Background(), "", "file_1", "empty.txt", strings.NewReader(""))
+		assert.Nil(t, err)
+
+		var buf bytes.Buffer
+		size, err = attachmentPersistence.ReadAttachment(context.Background(), "", "file_1", "image.bin", &buf)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(len(content)), size)
+		assert.Equal(t, content, buf.Bytes())
+
+		attachments, err := attachmentPersistence.GetAttachments(context.Background(), "", "file_1")
+		assert.Nil(t, err)
+		assert.Equal(t, []persist.PostgresAttachment{
+			{Name: "empty.txt", Size: 0},
+			{Name: "image.bin", Size: int64(len(content))},
+		}, attachments)
+
+		deleted, err := attachmentPersistence.DeleteAttachment(context.Background(), "", "file_1", "empty.txt")
+		assert.Nil(t, err)
+		assert.True(t, deleted)
+		_, err = attachmentPersistence.ReadAttachment(context.Background(), "", "file_1", "empty.txt", &buf)
+		assert.NotNil(t, err)
+
+		// Attachments are deleted with their items
+		_, err = attachmentPersistence.DeleteById(context.Background(), "", "file_1")
+		assert.Nil(t, err)
+		count, err := attachmentPersistence.ExecuteNonQuery(context.Background(), "",
+			"SELECT 1 FROM "+persist.Column("dummies_json_files_attachments"))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), count)
+
+		_, err = persistence.GetAttachments(context.Background(), "", "file_1")
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,152 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresJsonEncryption(t *testing.T) {
+	dbConfig := newPostgresTestConfig()
+
+	t.Run("DummyPostgresConnection:Encryption", func(t *testing.T) {
+		encryptedPersistence := &nestedJsonPostgresPersistence{}
+		encryptedPersistence.IdentifiableJsonPostgresPersistence =
+			persist.InheritIdentifiableJsonPostgresPersistence[nestedDummy, string](encryptedPersistence, "dummies_json_encrypted")
+		encryptedPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.encryption_key", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)),
+			"options.deep_merge", true,
+		).SetDefaults(dbConfig))
+
+		err := encryptedPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer encryptedPersistence.Close(context.Background(), "")
+		defer encryptedPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+encryptedPersistence.QuotedTableName())
+
+		_, err = encryptedPersistence.Create(context.Background(), "", nestedDummy{
+			Id:   "secret_1",
+			Key:  "Secret key",
+			Data: map[string]any{"address": map[string]any{"city": "Boston"}},
+		})
+		assert.Nil(t, err)
+
+		count, err := encryptedPersistence.ExecuteNonQuery(context.Background(), "",
+			"SELECT 1 FROM "+encryptedPersistence.QuotedTableName()+" WHERE \"data\"::text LIKE '%Secret%'")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), count)
+
+		item, err := encryptedPersistence.UpdatePartially(context.Background(), "", "secret_1",
+			*cdata.NewAnyValueMapFromTuples("data", map[string]any{"address": map[string]any{"zip": "02101"}}))
+		assert.Nil(t, err)
+		assert.Equal(t, "Secret key", item.Key)
+		assert.Equal(t, map[string]any{"city": "Boston", "zip": "02101"}, item.Data["address"])
+
+		item, err = encryptedPersistence.GetOneById(context.Background(), "", "secret_1")
+		assert.Nil(t, err)
+		assert.Equal(t, "Secret key", item.Key)
+
+		_, err = encryptedPersistence.RemoveFields(context.Background(), "", "secret_1", []string{"key"})
+		assert.NotNil(t, err)
+
+		// Exported documents are decrypted
+		var exported bytes.Buffer
+		count, err = encryptedPersistence.Export(context.Background(), "", "", nil, &exported)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+		assert.Contains(t, exported.String(), "Secret key")
+
+		// Documents can't be read without their keys
+		encryptedPersistence.SetKeyProvider(persist.NewPostgresStaticKeyProvider("other",
+			map[string][]byte{"other": bytes.Repeat([]byte{8}, 16)}))
+		_, err = encryptedPersistence.GetOneById(context.Background(), "", "secret_1")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("DummyPostgresConnection:Masking", func(t *testing.T) {
+		maskedPersistence := &nestedJsonPostgresPersistence{}
+		maskedPersistence.IdentifiableJsonPostgresPersistence =
+			persist.InheritIdentifiableJsonPostgresPersistence[nestedDummy, string](maskedPersistence, "dummies_json_masked")
+		maskedPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.masked_fields", "key, data.address.zip, data.cards.*.number, data.missing",
+		).SetDefaults(dbConfig))
+
+		err := maskedPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer maskedPersistence.Close(context.Background(), "")
+		defer maskedPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+maskedPersistence.QuotedTableName())
+
+		item, err := maskedPersistence.Create(context.Background(), "", nestedDummy{
+			Id:  "masked_1",
+			Key: "Key 1",
+			Data: map[string]any{
+				"address": map[string]any{"city": "Boston", "zip": 2101},
+				"cards":   []any{map[string]any{"number": "4111", "type": "visa"}},
+			},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "masked_1", item.Id)
+		assert.Equal(t, persist.DefaultMask, item.Key)
+		assert.Equal(t, map[string]any{"city": "Boston", "zip": nil}, item.Data["address"])
+		assert.Equal(t, []any{map[string]any{"number": persist.DefaultMask, "type": "visa"}}, item.Data["cards"])
+
+		// The stored data is not changed
+		count, err := maskedPersistence.ExecuteNonQuery(context.Background(), "",
+			"SELECT 1 FROM "+maskedPersistence.QuotedTableName()+" WHERE "+persist.JsonField("data", "key")+"=$1", "Key 1")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+
+		// Exported documents are masked as well
+		var exported bytes.Buffer
+		_, err = maskedPersistence.Export(context.Background(), "", "", nil, &exported)
+		assert.Nil(t, err)
+		assert.NotContains(t, exported.String(), "4111")
+		assert.Contains(t, exported.String(), "Boston")
+	})
+
+	t.Run("DummyPostgresConnection:EncryptionWithMasking", func(t *testing.T) {
+		securedPersistence := &nestedJsonPostgresPersistence{}
+		securedPersistence.IdentifiableJsonPostgresPersistence =
+			persist.InheritIdentifiableJsonPostgresPersistence[nestedDummy, string](securedPersistence, "dummies_json_secured")
+		securedPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.encryption_key", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)),
+			"options.masked_fields", "data.ssn",
+		).SetDefaults(dbConfig))
+
+		err := securedPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer securedPersistence.Close(context.Background(), "")
+		defer securedPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+securedPersistence.QuotedTableName())
+
+		_, err = securedPersistence.Create(context.Background(), "", nestedDummy{
+			Id:   "secured_1",
+			Key:  "Key 1",
+			Data: map[string]any{"ssn": "123-45-6789"},
+		})
+		assert.Nil(t, err)
+
+		item, err := securedPersistence.UpdatePartially(context.Background(), "", "secured_1",
+			*cdata.NewAnyValueMapFromTuples("key", "Key 2"))
+		assert.Nil(t, err)
+		assert.Equal(t, "Key 2", item.Key)
+		assert.Equal(t, persist.DefaultMask, item.Data["ssn"])
+
+		// Partial updates keep the stored values of masked fields
+		securedPersistence.SetMaskedFields(nil)
+		item, err = securedPersistence.GetOneById(context.Background(), "", "secured_1")
+		assert.Nil(t, err)
+		assert.Equal(t, "Key 2", item.Key)
+		assert.Equal(t, "123-45-6789", item.Data["ssn"])
+	})
+}
@@ -0,0 +1,77 @@
+package test
+
+import (
+	"testing"
+
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresJsonFilterBuilder(t *testing.T) {
+	builder := persist.NewPostgresJsonFilterBuilder("").
+		Equal("key", "key", persist.JsonTypeString).
+		In("statuses", "status", persist.JsonTypeString).
+		Compare("min_count", "stats.count", ">=", persist.JsonTypeNumber).
+		Path("tag", "$.tags[*] ? (@ == $value)", persist.JsonTypeString)
+
+	filter := *cdata.NewFilterParamsFromTuples(
+		"key", "k' OR '1'='1",
+		"statuses", "new, active",
+		"min_count", "5",
+		"tag", "red",
+	)
+	where, params := builder.Build(filter)
+	assert.Equal(t, "\"data\" @> $1::jsonb"+
+		" AND jsonb_path_exists(\"data\", $2::jsonpath, $3::jsonb)"+
+		" AND jsonb_path_exists(\"data\", $4::jsonpath, $5::jsonb)"+
+		" AND jsonb_path_exists(\"data\", $6::jsonpath, $7::jsonb)", where)
+	assert.Equal(t, []any{
+		"{\"key\":\"k' OR '1'='1\"}",
+		"$.\"status\" ? (@ == $value[*])", "{\"value\":[\"new\",\"active\"]}",
+		"$.\"stats\".\"count\" ? (@ >= $value)", "{\"value\":5}",
+		"$.tags[*] ? (@ == $value)", "{\"value\":\"red\"}",
+	}, params)
+
+	// Values which can't be converted are kept as strings
+	where, params = persist.NewPostgresJsonFilterBuilder("doc").
+		Equal("active", "flags.active", persist.JsonTypeBoolean).
+		Equal("count", "count", persist.JsonTypeNumber).
+		BuildFrom(*cdata.NewFilterParamsFromTuples("active", "true", "count", "many"), 3)
+	assert.Equal(t, "\"doc\" @> $3::jsonb AND \"doc\" @> $4::jsonb", where)
+	assert.Equal(t, []any{"{\"flags\":{\"active\":true}}", "{\"count\":\"many\"}"}, params)
+
+	where, params = persist.NewPostgresJsonFilterBuilder("").
+		HasTag("tag", "tags").
+		HasAnyTag("any_tags", "labels.names").
+		HasAllTags("all_tags", "tags").
+		Build(*cdata.NewFilterParamsFromTuples("tag", "red", "any_tags", "new, hot", "all_tags", "red,blue"))
+	assert.Equal(t, "\"data\"->'tags' ? $1"+
+		" AND \"data\"->'labels'->'names' ?| $2::text[]"+
+		" AND \"data\"->'tags' ?& $3::text[]", where)
+	assert.Equal(t, []any{"red", []string{"new", "hot"}, []string{"red", "blue"}}, params)
+
+	where, params = persist.NewPostgresJsonFilterBuilder("").
+		WithExtractedColumn("stats.count", "stats_count", "INTEGER").
+		WithExtractedColumn("key", "key", "TEXT; DROP TABLE dummies").
+		Equal("key", "key", persist.JsonTypeString).
+		In("counts", "stats.count", persist.JsonTypeNumber).
+		Compare("min_count", "stats.count", "<>", persist.JsonTypeNumber).
+		Build(*cdata.NewFilterParamsFromTuples("key", "k1", "counts", "1, 2", "min_count", "5"))
+	assert.Equal(t, "\"data\" @> $1::jsonb"+
+		" AND \"stats_count\"=ANY($2::text[]::INTEGER[])"+
+		" AND \"stats_count\"<>$3::text::INTEGER", where)
+	assert.Equal(t, []any{"{\"key\":\"k1\"}", []string{"1", "2"}, "5"}, params)
+
+	where, params = persist.NewPostgresJsonFilterBuilder("").
+		WithColumnType("json").
+		Equal("key", "key", persist.JsonTypeString).
+		HasTag("tag", "tags").
+		Build(*cdata.NewFilterParamsFromTuples("key", "k1", "tag", "red"))
+	assert.Equal(t, "\"data\"::jsonb @> $1::jsonb AND \"data\"::jsonb->'tags' ? $2", where)
+	assert.Equal(t, []any{"{\"key\":\"k1\"}", "red"}, params)
+
+	where, params = builder.Build(*cdata.NewEmptyFilterParams())
+	assert.Equal(t, "", where)
+	assert.Len(t, params, 0)
+}
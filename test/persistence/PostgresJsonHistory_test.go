@@ -0,0 +1,71 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresJsonHistory(t *testing.T) {
+	dbConfig := newPostgresTestConfig()
+
+	persistence := NewDummyJsonPostgresPersistence()
+	persistence.Configure(context.Background(), dbConfig)
+
+	opnErr := persistence.Open(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error opened persistence", opnErr)
+		return
+	}
+	defer persistence.Close(context.Background(), "")
+
+	opnErr = persistence.Clear(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
+	}
+
+	t.Run("DummyPostgresConnection:History", func(t *testing.T) {
+		historyPersistence := &nestedJsonPostgresPersistence{}
+		historyPersistence.IdentifiableJsonPostgresPersistence =
+			persist.InheritIdentifiableJsonPostgresPersistence[nestedDummy, string](historyPersistence, "dummies_json_versions")
+		historyPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.history", true,
+		).SetDefaults(dbConfig))
+
+		err := historyPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer historyPersistence.Close(context.Background(), "")
+		defer historyPersistence.ExecuteNonQuery(context.Background(), "",
+			"DROP FUNCTION "+persist.Column("dummies_json_versions_history_record"))
+		defer historyPersistence.ExecuteNonQuery(context.Background(), "",
+			"DROP TABLE "+historyPersistence.QuotedTableName()+", "+persist.Column("dummies_json_versions_history"))
+
+		_, err = historyPersistence.Create(context.Background(), "", nestedDummy{Id: "history_1", Key: "Key 1"})
+		assert.Nil(t, err)
+		_, err = historyPersistence.UpdatePartially(context.Background(), "update_1", "history_1",
+			*cdata.NewAnyValueMapFromTuples("key", "Key 2"))
+		assert.Nil(t, err)
+		_, err = historyPersistence.DeleteById(context.Background(), "delete_1", "history_1")
+		assert.Nil(t, err)
+
+		records, err := historyPersistence.GetHistoryById(context.Background(), "", "history_1")
+		assert.Nil(t, err)
+		assert.Len(t, records, 2)
+		assert.Equal(t, persist.HistoryOperationDelete, records[0].Operation)
+		assert.Equal(t, "delete_1", records[0].CorrelationId)
+		assert.Equal(t, "Key 2", records[0].Item.Key)
+		assert.Equal(t, persist.HistoryOperationUpdate, records[1].Operation)
+		assert.Equal(t, "update_1", records[1].CorrelationId)
+		assert.Equal(t, "Key 1", records[1].Item.Key)
+
+		_, err = persistence.GetHistoryById(context.Background(), "", "history_1")
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,234 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresJsonQueries(t *testing.T) {
+	dbConfig := newPostgresTestConfig()
+
+	persistence := NewDummyJsonPostgresPersistence()
+	persistence.Configure(context.Background(), dbConfig)
+
+	opnErr := persistence.Open(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error opened persistence", opnErr)
+		return
+	}
+	defer persistence.Close(context.Background(), "")
+
+	opnErr = persistence.Clear(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
+	}
+
+	t.Run("DummyPostgresConnection:Projection", func(t *testing.T) {
+		_, err := persistence.Create(context.Background(), "", tf.Dummy{Id: "projected_1", Key: "Projected 1", Content: "Projected content"})
+		assert.Nil(t, err)
+
+		page, err := persistence.GetPageByFilterWithProjection(context.Background(), "",
+			"\"id\"=$1", []any{"projected_1"}, *cdata.NewEmptyPagingParams(), "",
+			*cdata.NewProjectionParamsFromStrings([]string{"key"}))
+		assert.Nil(t, err)
+		assert.Len(t, page.Data, 1)
+		assert.Equal(t, "projected_1", page.Data[0].Id)
+		assert.Equal(t, "Projected 1", page.Data[0].Key)
+		assert.Equal(t, "", page.Data[0].Content)
+
+		items, err := persistence.GetListByFilterWithProjection(context.Background(), "",
+			"\"id\"=$1", []any{"projected_1"}, "", *cdata.NewProjectionParamsFromStrings([]string{"id"}))
+		assert.Nil(t, err)
+		assert.Len(t, items, 1)
+		assert.Equal(t, "projected_1", items[0].Id)
+		assert.Equal(t, "", items[0].Key)
+	})
+
+	t.Run("DummyPostgresConnection:Stats", func(t *testing.T) {
+		_, err := persistence.Create(context.Background(), "", tf.Dummy{Id: "stats_1", Key: "Stats 1", Content: "Stats content"})
+		assert.Nil(t, err)
+
+		stats, err := persistence.Stats(context.Background(), "")
+		assert.Nil(t, err)
+		assert.True(t, stats.Count > 0)
+		assert.True(t, stats.AvgSize > 0)
+		assert.True(t, stats.MaxSize >= stats.MedianSize)
+		assert.True(t, stats.TotalSize >= stats.TableSize+stats.IndexesSize)
+	})
+
+	t.Run("DummyPostgresConnection:Export", func(t *testing.T) {
+		_, err := persistence.Create(context.Background(), "", tf.Dummy{Id: "export_1", Key: "Export 1", Content: "Export content"})
+		assert.Nil(t, err)
+		_, err = persistence.Create(context.Background(), "", tf.Dummy{Id: "export_2", Key: "Export 2", Content: "Export content"})
+		assert.Nil(t, err)
+
+		var buffer bytes.Buffer
+		count, err := persistence.Export(context.Background(), "",
+			persist.JsonField("data", "content")+"=$1", []any{"Export content"}, &buffer)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), count)
+
+		lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+		assert.Len(t, lines, 2)
+		var item tf.Dummy
+		assert.Nil(t, json.Unmarshal([]byte(lines[0]), &item))
+		assert.Equal(t, "export_1", item.Id)
+		assert.Equal(t, "Export 1", item.Key)
+	})
+
+	t.Run("DummyPostgresConnection:Migration", func(t *testing.T) {
+		relational := NewDummyPostgresPersistence()
+		relational.Configure(context.Background(), dbConfig)
+		err := relational.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer relational.Close(context.Background(), "")
+		defer relational.Clear(context.Background(), "")
+
+		err = relational.Clear(context.Background(), "")
+		assert.Nil(t, err)
+		err = persistence.Clear(context.Background(), "")
+		assert.Nil(t, err)
+		for _, id := range []string{"m3", "m1", "m2"} {
+			_, err = relational.Create(context.Background(), "", tf.Dummy{Id: id, Key: "Key " + id, Content: "Content " + id})
+			assert.Nil(t, err)
+		}
+
+		progress := make([]persist.PostgresMigrationProgress, 0)
+		count, err := persist.MigrateItems(context.Background(), "", &relational.IdentifiablePostgresPersistence,
+			persistence.IdentifiablePostgresPersistence, 2, func(p persist.PostgresMigrationProgress) {
+				progress = append(progress, p)
+			})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3), count)
+		assert.Equal(t, []persist.PostgresMigrationProgress{{Migrated: 2, Total: 3}, {Migrated: 3, Total: 3}}, progress)
+
+		item, err := persistence.GetOneById(context.Background(), "", "m2")
+		assert.Nil(t, err)
+		assert.Equal(t, "Content m2", item.Content)
+
+		// Items are migrated back the same way
+		err = relational.Clear(context.Background(), "")
+		assert.Nil(t, err)
+		count, err = persist.MigrateItems(context.Background(), "", persistence.IdentifiablePostgresPersistence,
+			&relational.IdentifiablePostgresPersistence, 0, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3), count)
+
+		relationalItem, err := relational.GetOneById(context.Background(), "", "m3")
+		assert.Nil(t, err)
+		assert.Equal(t, "Key m3", relationalItem.Key)
+
+		err = persistence.Clear(context.Background(), "")
+		assert.Nil(t, err)
+	})
+
+	t.Run("DummyPostgresConnection:DeepMerge", func(t *testing.T) {
+		mergePersistence := &nestedJsonPostgresPersistence{}
+		mergePersistence.IdentifiableJsonPostgresPersistence =
+			persist.InheritIdentifiableJsonPostgresPersistence[nestedDummy, string](mergePersistence, "dummies_json_merge")
+		mergePersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.deep_merge", true,
+		).SetDefaults(dbConfig))
+
+		err := mergePersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer mergePersistence.Close(context.Background(), "")
+		defer mergePersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+mergePersistence.QuotedTableName())
+
+		_, err = mergePersistence.Create(context.Background(), "", nestedDummy{
+			Id:   "merge_1",
+			Key:  "Key 1",
+			Data: map[string]any{"address": map[string]any{"city": "Boston", "zip": "02101"}},
+		})
+		assert.Nil(t, err)
+
+		item, err := mergePersistence.UpdatePartially(context.Background(), "", "merge_1",
+			*cdata.NewAnyValueMapFromTuples("data", map[string]any{
+				"address": map[string]any{"city": "Denver"},
+				"phone":   "555-1234",
+			}))
+		assert.Nil(t, err)
+		assert.Equal(t, "Key 1", item.Key)
+		assert.Equal(t, map[string]any{"city": "Denver", "zip": "02101"}, item.Data["address"])
+		assert.Equal(t, "555-1234", item.Data["phone"])
+
+		item, err = mergePersistence.RemoveFields(context.Background(), "", "merge_1",
+			[]string{"data.phone", "data.address.zip", "missing.field"})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]any{"address": map[string]any{"city": "Denver"}}, item.Data)
+
+		count, err := mergePersistence.UpdateByFilter(context.Background(), "",
+			persist.JsonField("data", "key")+"=$1", []any{"Key 1"},
+			*cdata.NewAnyValueMapFromTuples("data", map[string]any{"phone": "555-4321"}, "data.address.zip", "80201"))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+
+		item, err = mergePersistence.GetOneById(context.Background(), "", "merge_1")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]any{
+			"address": map[string]any{"city": "Denver", "zip": "80201"},
+			"phone":   "555-4321",
+		}, item.Data)
+	})
+
+	t.Run("DummyPostgresConnection:JsonColumn", func(t *testing.T) {
+		jsonPersistence := &nestedJsonPostgresPersistence{dataType: "JSON"}
+		jsonPersistence.IdentifiableJsonPostgresPersistence =
+			persist.InheritIdentifiableJsonPostgresPersistence[nestedDummy, string](jsonPersistence, "dummies_json_plain")
+		jsonPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.deep_merge", true,
+		).SetDefaults(dbConfig))
+
+		err := jsonPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer jsonPersistence.Close(context.Background(), "")
+		defer jsonPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+jsonPersistence.QuotedTableName())
+
+		_, err = jsonPersistence.Create(context.Background(), "", nestedDummy{
+			Id:   "plain_1",
+			Key:  "Key 1",
+			Data: map[string]any{"address": map[string]any{"city": "Boston", "zip": "02101"}},
+		})
+		assert.Nil(t, err)
+
+		item, err := jsonPersistence.UpdatePartially(context.Background(), "", "plain_1",
+			*cdata.NewAnyValueMapFromTuples("key", "Key 2", "data", map[string]any{"address": map[string]any{"city": "Denver"}}))
+		assert.Nil(t, err)
+		assert.Equal(t, "Key 2", item.Key)
+		assert.Equal(t, map[string]any{"city": "Denver", "zip": "02101"}, item.Data["address"])
+
+		item, err = jsonPersistence.RemoveFields(context.Background(), "", "plain_1", []string{"data.address.zip"})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]any{"address": map[string]any{"city": "Denver"}}, item.Data)
+
+		where, params := jsonPersistence.JsonFilterBuilder().
+			Equal("key", "key", persist.JsonTypeString).
+			Build(*cdata.NewFilterParamsFromTuples("key", "Key 2"))
+		count, err := jsonPersistence.UpdateByFilter(context.Background(), "", where, params,
+			*cdata.NewAnyValueMapFromTuples("data.address.zip", "80201"))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+
+		count, err = jsonPersistence.ExecuteNonQuery(context.Background(), "",
+			"SELECT 1 FROM "+jsonPersistence.QuotedTableName()+" WHERE pg_typeof(\"data\")='json'::regtype AND "+
+				persist.JsonField("data", "data.address.zip")+"=$1", "80201")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+}
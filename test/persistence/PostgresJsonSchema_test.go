@@ -0,0 +1,256 @@
+package test
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresJsonSchema(t *testing.T) {
+	dbConfig := newPostgresTestConfig()
+
+	persistence := NewDummyExtendedJsonPostgresPersistence()
+	persistence.Configure(context.Background(), dbConfig)
+
+	opnErr := persistence.Open(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error opened persistence", opnErr)
+		return
+	}
+	defer persistence.Close(context.Background(), "")
+
+	opnErr = persistence.Clear(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
+	}
+
+	t.Run("DummyPostgresConnection:GeneratedColumn", func(t *testing.T) {
+		_, err := persistence.Create(context.Background(), "", tf.Dummy{Id: "generated_1", Key: "Generated 1", Content: "Generated content"})
+		assert.Nil(t, err)
+
+		count, err := persistence.GetCountByFilterWithParams(context.Background(), "",
+			persist.Column("content")+"=$1", []any{"Generated content"})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+
+		count, err = persistence.ExecuteNonQuery(context.Background(), "",
+			"SELECT 1 FROM pg_indexes WHERE tablename=$1 AND indexname=$2", "dummies_json_extended", "dummies_json_extended_content")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+
+		// The extracted column is used by filters and sorting instead of the JSON field
+		where, params := persistence.JsonFilterBuilder().
+			Equal("content", "content", persist.JsonTypeString).
+			Build(*cdata.NewFilterParamsFromTuples("content", "Generated content"))
+		assert.Equal(t, "\"content\"=$1::text::TEXT", where)
+		page, err := persistence.GetPageByFilterWithParams(context.Background(), "", where, params,
+			*cdata.NewEmptyPagingParams(), persistence.ComposeSort(*cdata.NewSortParams([]cdata.SortField{cdata.NewSortField("content", true)})), "")
+		assert.Nil(t, err)
+		assert.Len(t, page.Data, 1)
+		assert.Equal(t, "generated_1", page.Data[0].Id)
+	})
+
+	t.Run("DummyPostgresConnection:TextSearch", func(t *testing.T) {
+		_, err := persistence.Create(context.Background(), "", tf.Dummy{Id: "search_1", Key: "Search 1", Content: "quick brown fox"})
+		assert.Nil(t, err)
+		_, err = persistence.Create(context.Background(), "", tf.Dummy{Id: "search_2", Key: "Search 2", Content: "lazy brown dog"})
+		assert.Nil(t, err)
+
+		page, err := persistence.SearchByText(context.Background(), "", "brown -dog", *cdata.NewPagingParams(0, 10, true))
+		assert.Nil(t, err)
+		assert.Len(t, page.Data, 1)
+		assert.Equal(t, "search_1", page.Data[0].Id)
+		assert.Equal(t, 1, page.Total)
+
+		hits, err := persistence.SearchByTextWithHeadlines(context.Background(), "", "fox",
+			"StartSel=<mark>, StopSel=</mark>", *cdata.NewEmptyPagingParams())
+		assert.Nil(t, err)
+		assert.Len(t, hits.Data, 1)
+		assert.Equal(t, "search_1", hits.Data[0].Item.Id)
+		assert.Greater(t, hits.Data[0].Rank, 0.0)
+		assert.Contains(t, hits.Data[0].Headline, "<mark>fox</mark>")
+	})
+
+	t.Run("DummyPostgresConnection:DataTypes", func(t *testing.T) {
+		_, err := persistence.ExecuteNonQuery(context.Background(), "",
+			"INSERT INTO "+persistence.QuotedTableName()+" (\"id\", \"data\") VALUES ($1, $2)",
+			"typed_1", "{\"id\":\"typed_1\",\"key\":5}")
+		assert.NotNil(t, err)
+
+		_, err = persistence.ExecuteNonQuery(context.Background(), "",
+			"INSERT INTO "+persistence.QuotedTableName()+" (\"id\", \"data\") VALUES ($1, $2)",
+			"typed_2", "{\"key\":\"Typed 2\"}")
+		assert.NotNil(t, err)
+
+		_, err = persistence.Create(context.Background(), "", tf.Dummy{Id: "typed_3", Key: "Typed 3"})
+		assert.Nil(t, err)
+	})
+
+	t.Run("DummyPostgresConnection:DataStorage", func(t *testing.T) {
+		storagePersistence := &nestedJsonPostgresPersistence{}
+		storagePersistence.IdentifiableJsonPostgresPersistence =
+			persist.InheritIdentifiableJsonPostgresPersistence[nestedDummy, string](storagePersistence, "dummies_json_storage")
+		storagePersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.data_storage", persist.ColumnStorageExternal,
+			"options.toast_tuple_target", 256,
+		).SetDefaults(dbConfig))
+
+		err := storagePersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer storagePersistence.Close(context.Background(), "")
+		defer storagePersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+storagePersistence.QuotedTableName())
+
+		count, err := storagePersistence.ExecuteNonQuery(context.Background(), "",
+			"SELECT 1 FROM pg_attribute WHERE attrelid=$1::regclass AND attname='data' AND attstorage='e'",
+			storagePersistence.QuotedTableName())
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+
+		_, err = storagePersistence.Create(context.Background(), "", nestedDummy{
+			Id:   "storage_1",
+			Key:  "Key 1",
+			Data: map[string]any{"content": strings.Repeat("Large content ", 1000)},
+		})
+		assert.Nil(t, err)
+	})
+
+	t.Run("DummyPostgresConnection:ColumnNames", func(t *testing.T) {
+		namedPersistence := &nestedJsonPostgresPersistence{}
+		namedPersistence.IdentifiableJsonPostgresPersistence =
+			persist.InheritIdentifiableJsonPostgresPersistence[nestedDummy, string](namedPersistence, "dummies_json_named")
+		namedPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.id_column", "doc_id",
+			"options.data_column", "payload",
+		).SetDefaults(dbConfig))
+
+		err := namedPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer namedPersistence.Close(context.Background(), "")
+		defer namedPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+namedPersistence.QuotedTableName())
+
+		_, err = namedPersistence.Create(context.Background(), "", nestedDummy{Id: "named_1", Key: "Key 1"})
+		assert.Nil(t, err)
+
+		item, err := namedPersistence.UpdatePartially(context.Background(), "", "named_1",
+			*cdata.NewAnyValueMapFromTuples("key", "Key 2"))
+		assert.Nil(t, err)
+		assert.Equal(t, "named_1", item.Id)
+		assert.Equal(t, "Key 2", item.Key)
+
+		count, err := namedPersistence.ExecuteNonQuery(context.Background(), "",
+			"SELECT 1 FROM "+namedPersistence.QuotedTableName()+" WHERE \"doc_id\"=$1 AND \"payload\"->>'key'=$2",
+			"named_1", "Key 2")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+
+		items, err := namedPersistence.GetListByFilterWithProjection(context.Background(), "", "", nil, "",
+			*cdata.NewProjectionParamsFromStrings([]string{"id"}))
+		assert.Nil(t, err)
+		assert.Len(t, items, 1)
+		assert.Equal(t, "named_1", items[0].Id)
+
+		item, err = namedPersistence.DeleteById(context.Background(), "", "named_1")
+		assert.Nil(t, err)
+		assert.Equal(t, "named_1", item.Id)
+	})
+
+	t.Run("DummyPostgresConnection:UniqueDataKey", func(t *testing.T) {
+		uniquePersistence := &nestedJsonPostgresPersistence{uniqueKey: []string{"key", "data.region"}}
+		uniquePersistence.IdentifiableJsonPostgresPersistence =
+			persist.InheritIdentifiableJsonPostgresPersistence[nestedDummy, string](uniquePersistence, "dummies_json_unique")
+		uniquePersistence.Configure(context.Background(), dbConfig)
+
+		err := uniquePersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer uniquePersistence.Close(context.Background(), "")
+		defer uniquePersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+uniquePersistence.QuotedTableName())
+
+		_, err = uniquePersistence.Create(context.Background(), "", nestedDummy{
+			Id: "unique_1", Key: "Key 1", Data: map[string]any{"region": "us"},
+		})
+		assert.Nil(t, err)
+		_, err = uniquePersistence.Create(context.Background(), "", nestedDummy{
+			Id: "unique_2", Key: "Key 1", Data: map[string]any{"region": "eu"},
+		})
+		assert.Nil(t, err)
+
+		_, err = uniquePersistence.Create(context.Background(), "", nestedDummy{
+			Id: "unique_3", Key: "Key 1", Data: map[string]any{"region": "us"},
+		})
+		assert.NotNil(t, err)
+		appErr, ok := err.(*cerr.ApplicationError)
+		assert.True(t, ok)
+		if ok {
+			assert.Equal(t, "DUPLICATE_KEY", appErr.Code)
+			assert.Equal(t, cerr.Conflict, appErr.Category)
+		}
+
+		_, err = uniquePersistence.UpdatePartially(context.Background(), "", "unique_2",
+			*cdata.NewAnyValueMapFromTuples("data", map[string]any{"region": "us"}))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("DummyPostgresConnection:Partitions", func(t *testing.T) {
+		partitionedPersistence := &nestedJsonPostgresPersistence{}
+		partitionedPersistence.IdentifiableJsonPostgresPersistence =
+			persist.InheritIdentifiableJsonPostgresPersistence[nestedDummy, string](partitionedPersistence, "dummies_json_partitioned")
+		partitionedPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.partitions", 4,
+		).SetDefaults(dbConfig))
+
+		err := partitionedPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer partitionedPersistence.Close(context.Background(), "")
+		defer partitionedPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+partitionedPersistence.QuotedTableName())
+
+		count, err := partitionedPersistence.ExecuteNonQuery(context.Background(), "",
+			"SELECT 1 FROM pg_inherits WHERE inhparent=$1::regclass", partitionedPersistence.QuotedTableName())
+		assert.Nil(t, err)
+		assert.Equal(t, int64(4), count)
+
+		for i := 1; i <= 10; i++ {
+			_, err = partitionedPersistence.Create(context.Background(), "", nestedDummy{
+				Id:   "partition_" + strconv.Itoa(i),
+				Key:  "Key " + strconv.Itoa(i),
+				Data: map[string]any{"content": "Partitioned content"},
+			})
+			assert.Nil(t, err)
+		}
+
+		item, err := partitionedPersistence.UpdatePartially(context.Background(), "", "partition_5",
+			*cdata.NewAnyValueMapFromTuples("key", "Key 50"))
+		assert.Nil(t, err)
+		assert.Equal(t, "Key 50", item.Key)
+
+		item, err = partitionedPersistence.GetOneById(context.Background(), "", "partition_5")
+		assert.Nil(t, err)
+		assert.Equal(t, "Key 50", item.Key)
+
+		total, err := partitionedPersistence.GetCountByFilter(context.Background(), "", "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(10), total)
+
+		stats, err := partitionedPersistence.Stats(context.Background(), "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(10), stats.Count)
+		assert.True(t, stats.TableSize > 0)
+		assert.True(t, stats.TotalSize >= stats.TableSize+stats.IndexesSize)
+	})
+}
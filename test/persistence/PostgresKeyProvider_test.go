@@ -0,0 +1,53 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresStaticKeyProvider(t *testing.T) {
+	provider := persist.NewPostgresStaticKeyProvider("k2", map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 16),
+		"k2": bytes.Repeat([]byte{2}, 32),
+		"k3": []byte("short"),
+	})
+
+	keyId, key, err := provider.CurrentKey()
+	assert.Nil(t, err)
+	assert.Equal(t, "k2", keyId)
+	assert.Len(t, key, 32)
+
+	key, err = provider.GetKey("k1")
+	assert.Nil(t, err)
+	assert.Len(t, key, 16)
+
+	_, err = provider.GetKey("k3")
+	assert.NotNil(t, err)
+	_, err = provider.GetKey("missing")
+	assert.NotNil(t, err)
+}
+
+func TestPostgresConfiguredEncryptionKey(t *testing.T) {
+	for _, key := range []string{"not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		persistence := NewDummyJsonPostgresPersistence()
+		persistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.encryption_key", key,
+		))
+
+		err := persistence.Open(context.Background(), "123")
+		assert.NotNil(t, err)
+		appErr, ok := err.(*cerr.ApplicationError)
+		assert.True(t, ok)
+		assert.Equal(t, "INVALID_ENCRYPTION_KEY", appErr.Code)
+		assert.Equal(t, "123", appErr.CorrelationId)
+		assert.Contains(t, appErr.Message, "options.encryption_key")
+		assert.False(t, persistence.IsOpen())
+	}
+}
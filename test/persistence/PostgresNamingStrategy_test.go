@@ -0,0 +1,37 @@
+package test
+
+import (
+	"testing"
+
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnakeCaseNamingStrategy(t *testing.T) {
+	strategy := persist.NewNamingStrategy("snake_case")
+
+	assert.Equal(t, "id", strategy.ColumnName("id"))
+	assert.Equal(t, "created_at", strategy.ColumnName("createdAt"))
+	assert.Equal(t, "user_id", strategy.ColumnName("userID"))
+	assert.Equal(t, "http_status", strategy.ColumnName("HTTPStatus"))
+	assert.Equal(t, "created_at", strategy.ColumnName("created_at"))
+
+	assert.Equal(t, "createdAt", strategy.FieldName("created_at"))
+	assert.Equal(t, "id", strategy.FieldName("id"))
+}
+
+func TestCamelCaseNamingStrategy(t *testing.T) {
+	strategy := persist.NewNamingStrategy("camel_case")
+
+	assert.Equal(t, "createdAt", strategy.ColumnName("created_at"))
+	assert.Equal(t, "createdAt", strategy.ColumnName("CreatedAt"))
+	assert.Equal(t, "createdAt", strategy.ColumnName("createdAt"))
+	assert.Equal(t, "createdAt", strategy.FieldName("createdAt"))
+}
+
+func TestAsIsNamingStrategy(t *testing.T) {
+	strategy := persist.NewNamingStrategy("unknown")
+
+	assert.Equal(t, "createdAt", strategy.ColumnName("createdAt"))
+	assert.Equal(t, "created_at", strategy.FieldName("created_at"))
+}
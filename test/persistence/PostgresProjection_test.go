@@ -0,0 +1,33 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonPostgresProjection(t *testing.T) {
+	persistence := NewDummyJsonPostgresPersistence()
+
+	selection, err := persistence.ComposeSelect(context.Background(), "",
+		*cdata.NewProjectionParamsFromStrings([]string{"id", "key", "content'; --"}))
+	assert.Nil(t, err)
+	assert.Equal(t, "\"id\",jsonb_strip_nulls(jsonb_build_object('id',\"id\",'key',\"data\"->'key','content''; --',\"data\"->'content''; --')) AS \"data\"", selection)
+
+	selection, err = persistence.ComposeSelect(context.Background(), "",
+		*cdata.NewProjectionParamsFromStrings([]string{"address.city", "address.zip", "stats", "stats.count"}))
+	assert.Nil(t, err)
+	assert.Equal(t, "\"id\",jsonb_strip_nulls(jsonb_build_object('id',\"id\","+
+		"'address',NULLIF(jsonb_strip_nulls(jsonb_build_object('city',\"data\"->'address'->'city','zip',\"data\"->'address'->'zip')), '{}'::jsonb),"+
+		"'stats',\"data\"->'stats')) AS \"data\"", selection)
+
+	selection, err = persistence.ComposeSelect(context.Background(), "", *cdata.NewProjectionParamsFromStrings([]string{"id"}))
+	assert.Nil(t, err)
+	assert.Equal(t, "\"id\"", selection)
+
+	selection, err = persistence.ComposeSelect(context.Background(), "", *cdata.NewEmptyProjectionParams())
+	assert.Nil(t, err)
+	assert.Equal(t, "", selection)
+}
@@ -0,0 +1,391 @@
+package test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresQueries(t *testing.T) {
+	dbConfig := newPostgresTestConfig().Override(cconf.NewConfigParamsFromTuples("schema", "test_schema"))
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(context.Background(), dbConfig)
+
+	opnErr := persistence.Open(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error opened persistence", opnErr)
+		return
+	}
+	defer persistence.Close(context.Background(), "")
+
+	opnErr = persistence.Clear(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
+	}
+
+	t.Run("DummyPostgresPersistence:Projection", func(t *testing.T) {
+		selection, err := persistence.ComposeSelect(context.Background(), "",
+			*cdata.NewProjectionParamsFromStrings([]string{"id", "key"}))
+		assert.Nil(t, err)
+		assert.Equal(t, "\"id\",\"key\"", selection)
+
+		_, err = persistence.ComposeSelect(context.Background(), "",
+			*cdata.NewProjectionParamsFromStrings([]string{"key", "unknown"}))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("DummyPostgresPersistence:ApproximateTotal", func(t *testing.T) {
+		persistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.approximate_total", true,
+			"options.approximate_total_threshold", 0,
+		))
+		defer persistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.approximate_total", false,
+		))
+
+		page, err := persistence.GetPageByFilter(context.Background(), "",
+			*cdata.NewFilterParamsFromTuples("Key", "Key 1"), *cdata.NewPagingParams(0, 10, true))
+		assert.Nil(t, err)
+		assert.True(t, page.HasTotal())
+		assert.GreaterOrEqual(t, page.Total, 0)
+	})
+
+	t.Run("DummyPostgresPersistence:Cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := persistence.GetPageByFilter(ctx, "", *cdata.NewEmptyFilterParams(), *cdata.NewEmptyPagingParams())
+		assert.NotNil(t, err)
+
+		_, err = persistence.GetCountByFilter(ctx, "", *cdata.NewEmptyFilterParams())
+		assert.NotNil(t, err)
+	})
+
+	t.Run("DummyPostgresPersistence:Distinct", func(t *testing.T) {
+		_, err := persistence.Create(context.Background(), "", tf.Dummy{Key: "Distinct 1", Content: "Distinct content"})
+		assert.Nil(t, err)
+		_, err = persistence.Create(context.Background(), "", tf.Dummy{Key: "Distinct 2", Content: "Distinct content"})
+		assert.Nil(t, err)
+
+		values, err := persistence.GetDistinctByFieldWithParams(context.Background(), "",
+			"content", "\"key\" LIKE $1", []any{"Distinct%"})
+		assert.Nil(t, err)
+		assert.Equal(t, []any{"Distinct content"}, values)
+
+		values, err = persistence.GetDistinctByField(context.Background(), "", "key", "\"key\" LIKE 'Distinct%'")
+		assert.Nil(t, err)
+		assert.Equal(t, []any{"Distinct 1", "Distinct 2"}, values)
+	})
+
+	t.Run("DummyPostgresPersistence:Aggregate", func(t *testing.T) {
+		_, err := persistence.Create(context.Background(), "", tf.Dummy{Key: "Aggregate 1", Content: "Aggregate content"})
+		assert.Nil(t, err)
+		_, err = persistence.Create(context.Background(), "", tf.Dummy{Key: "Aggregate 2", Content: "Aggregate content"})
+		assert.Nil(t, err)
+
+		rows, err := persistence.GetAggregateByFilter(context.Background(), "",
+			"\"key\" LIKE $1", []any{"Aggregate%"},
+			[]string{"content"},
+			[]persist.PostgresAggregate{persist.AggregateCount(""), persist.AggregateMax("key", "")},
+		)
+		assert.Nil(t, err)
+		assert.Len(t, rows, 1)
+		assert.Equal(t, "Aggregate content", rows[0]["content"])
+		assert.Equal(t, int64(2), rows[0]["count"])
+		assert.Equal(t, "Aggregate 2", rows[0]["max_key"])
+
+		_, err = persistence.GetAggregateByFilter(context.Background(), "", "", nil, nil,
+			[]persist.PostgresAggregate{{Function: "DROP"}})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("DummyPostgresPersistence:Exists", func(t *testing.T) {
+		dummy, err := persistence.Create(context.Background(), "", tf.Dummy{Key: "Exists 1", Content: "Exists content"})
+		assert.Nil(t, err)
+
+		exists, err := persistence.ExistsById(context.Background(), "", dummy.Id)
+		assert.Nil(t, err)
+		assert.True(t, exists)
+
+		exists, err = persistence.ExistsById(context.Background(), "", "unknown")
+		assert.Nil(t, err)
+		assert.False(t, exists)
+
+		exists, err = persistence.ExistsByFilterWithParams(context.Background(), "", "\"key\"=$1", []any{"Exists 1"})
+		assert.Nil(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("DummyPostgresPersistence:DeleteByFilter", func(t *testing.T) {
+		_, err := persistence.Create(context.Background(), "", tf.Dummy{Key: "Delete 1", Content: "Delete content"})
+		assert.Nil(t, err)
+		_, err = persistence.Create(context.Background(), "", tf.Dummy{Key: "Delete 2", Content: "Delete content"})
+		assert.Nil(t, err)
+
+		count, err := persistence.DeleteByFilterWithParams(context.Background(), "", "\"content\"=$1", []any{"Delete content"})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), count)
+
+		count, err = persistence.DeleteByFilterWithParams(context.Background(), "", "\"content\"=$1", []any{"Delete content"})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("DummyPostgresPersistence:ExecuteQuery", func(t *testing.T) {
+		count, err := persistence.ExecuteNonQuery(context.Background(), "",
+			"INSERT INTO "+persistence.QuotedTableName()+" (\"id\", \"key\", \"content\") VALUES ($1, $2, $3)",
+			"execute_1", "Execute 1", "Execute content")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+
+		items, err := persistence.ExecuteQuery(context.Background(), "",
+			"SELECT * FROM "+persistence.QuotedTableName()+" WHERE \"content\"=$1", "Execute content")
+		assert.Nil(t, err)
+		assert.Len(t, items, 1)
+		assert.Equal(t, "Execute 1", items[0].Key)
+	})
+
+	t.Run("DummyPostgresPersistence:ExecuteReturning", func(t *testing.T) {
+		items, err := persistence.ExecuteReturning(context.Background(), "",
+			"INSERT INTO "+persistence.QuotedTableName()+" (\"id\", \"key\", \"content\") VALUES ($1, $2, $3), ($4, $5, $6)",
+			"returning_1", "Returning 1", "Returning content", "returning_2", "Returning 2", "Returning content")
+		assert.Nil(t, err)
+		assert.Len(t, items, 2)
+
+		keys := make([]string, 0)
+		count, err := persistence.ExecuteReturningEach(context.Background(), "",
+			"UPDATE "+persistence.QuotedTableName()+" SET \"content\"=$1 WHERE \"content\"=$2",
+			[]any{"Returned content", "Returning content"},
+			func(item tf.Dummy) error {
+				keys = append(keys, item.Key)
+				return nil
+			})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), count)
+		assert.ElementsMatch(t, []string{"Returning 1", "Returning 2"}, keys)
+
+		items, err = persistence.DeleteByFilterReturning(context.Background(), "", "\"content\"=$1", []any{"Returned content"})
+		assert.Nil(t, err)
+		assert.Len(t, items, 2)
+	})
+
+	t.Run("DummyPostgresPersistence:MaxPageSize", func(t *testing.T) {
+		persistence.MaxPageSize = 2
+		defer func() { persistence.MaxPageSize = 100 }()
+
+		for index := 0; index < 3; index++ {
+			_, err := persistence.Create(context.Background(), "", tf.Dummy{Key: "Page " + strconv.Itoa(index), Content: "Page content"})
+			assert.Nil(t, err)
+		}
+		filter := *cdata.NewEmptyFilterParams()
+		paging := *cdata.NewPagingParams(0, 3, false)
+
+		page, err := persistence.GetPageByFilter(context.Background(), "", filter, paging)
+		assert.Nil(t, err)
+		assert.Len(t, page.Data, 2)
+
+		ctx := persist.NewContextWithMaxPageSize(context.Background(), 10)
+		page, err = persistence.GetPageByFilter(ctx, "", filter, paging)
+		assert.Nil(t, err)
+		assert.Len(t, page.Data, 3)
+	})
+
+	t.Run("DummyPostgresPersistence:QueryTimeout", func(t *testing.T) {
+		persistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.query_timeout", 100,
+		))
+		defer persistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.query_timeout", 0,
+		))
+
+		_, err := persistence.ExecuteNonQuery(context.Background(), "", "SELECT pg_sleep(1)")
+		assert.NotNil(t, err)
+		appErr, ok := err.(*cerr.ApplicationError)
+		assert.True(t, ok)
+		if ok {
+			assert.Equal(t, "QUERY_TIMEOUT", appErr.Code)
+		}
+
+		_, err = persistence.ExecuteNonQuery(context.Background(), "", "SELECT 1")
+		assert.Nil(t, err)
+	})
+
+	t.Run("DummyPostgresPersistence:Join", func(t *testing.T) {
+		_, err := persistence.ExecuteNonQuery(context.Background(), "",
+			"CREATE TABLE IF NOT EXISTS \"test_schema\".\"dummy_tags\" (\"dummy_id\" TEXT PRIMARY KEY, \"tag\" TEXT)")
+		assert.Nil(t, err)
+		defer persistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE \"test_schema\".\"dummy_tags\"")
+
+		_, err = persistence.Create(context.Background(), "", tf.Dummy{Id: "join_1", Key: "Join 1", Content: "Join content"})
+		assert.Nil(t, err)
+		_, err = persistence.Create(context.Background(), "", tf.Dummy{Id: "join_2", Key: "Join 2", Content: "Join content"})
+		assert.Nil(t, err)
+		_, err = persistence.ExecuteNonQuery(context.Background(), "",
+			"INSERT INTO \"test_schema\".\"dummy_tags\" (\"dummy_id\", \"tag\") VALUES ($1, $2)", "join_1", "red")
+		assert.Nil(t, err)
+
+		persistence.DefineJoin(persist.InnerJoin("test_schema.dummy_tags", "t",
+			"\"t\".\"dummy_id\"="+persistence.QuotedTableName()+".\"id\"").Select("tag"))
+		defer persistence.ClearJoins()
+
+		items, err := persistence.GetListByFilterWithParams(context.Background(), "", "\"t\".\"tag\"=$1", []any{"red"}, "", "")
+		assert.Nil(t, err)
+		assert.Len(t, items, 1)
+		assert.Equal(t, "join_1", items[0].Id)
+
+		count, err := persistence.GetCountByFilterWithParams(context.Background(), "",
+			persistence.QuotedTableName()+".\"content\"=$1", []any{"Join content"})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("DummyPostgresPersistence:RowLocks", func(t *testing.T) {
+		_, err := persistence.Create(context.Background(), "", tf.Dummy{Id: "locked_1", Key: "Locked", Content: "Locked"})
+		assert.Nil(t, err)
+
+		// Locks are held until the end of a transaction, so they can't be acquired without it
+		_, err = persistence.GetOneByIdForUpdate(context.Background(), "", "locked_1", "")
+		assert.NotNil(t, err)
+
+		err = persistence.Connection.WithTransaction(context.Background(), "", func(ctx1 context.Context, tx pgx.Tx) error {
+			item, err := persistence.GetOneByIdForUpdate(ctx1, "", "locked_1", persist.RowLockForUpdate)
+			assert.Nil(t, err)
+			assert.Equal(t, "locked_1", item.Id)
+
+			return persistence.Connection.WithTransaction(context.Background(), "", func(ctx2 context.Context, tx pgx.Tx) error {
+				_, err := persistence.GetOneByIdForUpdate(ctx2, "", "locked_1", persist.RowLockForUpdateNoWait)
+				assert.NotNil(t, err)
+				return nil
+			})
+		})
+		assert.Nil(t, err)
+
+		err = persistence.Connection.WithTransaction(context.Background(), "", func(ctx1 context.Context, tx pgx.Tx) error {
+			items, err := persistence.GetListByFilterWithParams(persist.NewContextWithRowLock(ctx1, persist.RowLockForUpdate),
+				"", "\"key\"=$1", []any{"Locked"}, "", "")
+			assert.Nil(t, err)
+			assert.Len(t, items, 1)
+
+			return persistence.Connection.WithTransaction(context.Background(), "", func(ctx2 context.Context, tx pgx.Tx) error {
+				items, err := persistence.GetListByFilterWithParams(persist.NewContextWithRowLock(ctx2, persist.RowLockForUpdateSkipLocked),
+					"", "\"key\"=$1", []any{"Locked"}, "", "")
+				assert.Nil(t, err)
+				assert.Len(t, items, 0)
+				return nil
+			})
+		})
+		assert.Nil(t, err)
+	})
+
+	t.Run("DummyPostgresPersistence:IdentityMap", func(t *testing.T) {
+		ctx := persist.NewContextWithIdentityMap(context.Background())
+		identityMap := persist.IdentityMapFromContext(ctx)
+
+		dummy, err := persistence.Create(ctx, "", tf.Dummy{Id: "identity_1", Key: "Key 1", Content: "Content 1"})
+		assert.Nil(t, err)
+		defer persistence.DeleteById(context.Background(), "", dummy.Id)
+
+		item, err := persistence.GetOneById(ctx, "", dummy.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, "Content 1", item.Content)
+		assert.Equal(t, 1, identityMap.Len())
+
+		// Changes made outside of the request are not seen
+		_, err = persistence.ExecuteNonQuery(context.Background(), "",
+			"UPDATE "+persistence.QuotedTableName()+" SET \"content\"=$1 WHERE \"id\"=$2", "Content 2", dummy.Id)
+		assert.Nil(t, err)
+		item, err = persistence.GetOneById(ctx, "", dummy.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, "Content 1", item.Content)
+
+		// Writes with the context remove changed items
+		_, err = persistence.UpdatePartially(ctx, "", dummy.Id, *cdata.NewAnyValueMapFromTuples("content", "Content 3"))
+		assert.Nil(t, err)
+		assert.Equal(t, 0, identityMap.Len())
+		item, err = persistence.GetOneById(ctx, "", dummy.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, "Content 3", item.Content)
+	})
+
+	t.Run("DummyPostgresPersistence:RandomMethods", func(t *testing.T) {
+		for _, method := range []string{persist.RandomMethodOrder, persist.RandomMethodSample} {
+			randomPersistence := &autoDummyPostgresPersistence{}
+			randomPersistence.IdentifiablePostgresPersistence =
+				persist.InheritIdentifiablePostgresPersistence[tf.Dummy, string](randomPersistence, "dummies_random")
+			randomPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+				"options.auto_create_table", true,
+				"options.random_method", method,
+			).SetDefaults(dbConfig))
+
+			err := randomPersistence.Open(context.Background(), "")
+			assert.Nil(t, err)
+
+			item, err := randomPersistence.GetOneRandom(context.Background(), "", "")
+			assert.Nil(t, err)
+			assert.Equal(t, "", item.Id)
+
+			for _, id := range []string{"random_1", "random_2", "random_3"} {
+				_, err = randomPersistence.Create(context.Background(), "", tf.Dummy{Id: id, Key: id})
+				assert.Nil(t, err)
+			}
+
+			// The sample of a small table is usually empty and the item is taken from all rows
+			item, err = randomPersistence.GetOneRandomWithParams(context.Background(), "", "\"key\"<>$1", []any{"random_1"})
+			assert.Nil(t, err)
+			assert.Contains(t, []string{"random_2", "random_3"}, item.Id)
+
+			randomPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+randomPersistence.QuotedTableName())
+			randomPersistence.Close(context.Background(), "")
+		}
+	})
+
+	t.Run("DummyPostgresPersistence:Terminate", func(t *testing.T) {
+		terminatedPersistence := &autoDummyPostgresPersistence{}
+		terminatedPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[tf.Dummy, string](terminatedPersistence, "dummies_terminated")
+		terminatedPersistence.Configure(context.Background(), dbConfig)
+
+		err := terminatedPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer terminatedPersistence.Close(context.Background(), "")
+
+		errs := make(chan error)
+		go func() {
+			_, err := terminatedPersistence.ExecuteNonQuery(context.Background(), "", "SELECT pg_sleep(10)")
+			errs <- err
+		}()
+		time.Sleep(200 * time.Millisecond)
+
+		start := time.Now()
+		terminatedPersistence.Terminate(context.Background(), "")
+		assert.True(t, terminatedPersistence.IsTerminated())
+		assert.NotNil(t, <-errs)
+		assert.Less(t, time.Since(start), 5*time.Second)
+
+		// New operations fail until the component is reopened
+		_, err = terminatedPersistence.ExecuteNonQuery(context.Background(), "", "SELECT 1")
+		assert.NotNil(t, err)
+
+		err = terminatedPersistence.Close(context.Background(), "")
+		assert.Nil(t, err)
+		err = terminatedPersistence.Open(context.Background(), "")
+		assert.Nil(t, err)
+		assert.False(t, terminatedPersistence.IsTerminated())
+
+		_, err = terminatedPersistence.ExecuteNonQuery(context.Background(), "", "SELECT 1")
+		assert.Nil(t, err)
+	})
+}
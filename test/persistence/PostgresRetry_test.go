@@ -0,0 +1,26 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTransientError(t *testing.T) {
+	assert.False(t, persist.IsTransientError(nil))
+	assert.False(t, persist.IsTransientError(errors.New("test error")))
+
+	assert.True(t, persist.IsTransientError(&pgconn.PgError{Code: "40001"}))
+	assert.True(t, persist.IsTransientError(&pgconn.PgError{Code: "40P01"}))
+	assert.True(t, persist.IsTransientError(&pgconn.PgError{Code: "57P01"}))
+	assert.False(t, persist.IsTransientError(&pgconn.PgError{Code: "23505"}))
+
+	assert.True(t, persist.IsTransientError(
+		cerr.NewConnectionError("123", "CONNECTION_FAILED", "PostgreSQL database is not reachable")))
+	assert.False(t, persist.IsTransientError(
+		cerr.NewBadRequestError("123", "INVALID_PROJECTION", "Invalid projection")))
+}
@@ -0,0 +1,227 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresSchema(t *testing.T) {
+	dbConfig := newPostgresTestConfig().Override(cconf.NewConfigParamsFromTuples("schema", "test_schema"))
+
+	persistence := NewDummyExtendedPostgresPersistence()
+	persistence.Configure(context.Background(), dbConfig)
+
+	opnErr := persistence.Open(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error opened persistence", opnErr)
+		return
+	}
+	defer persistence.Close(context.Background(), "")
+
+	opnErr = persistence.Clear(context.Background(), "")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
+	}
+
+	t.Run("DummyPostgresPersistence:EnsureIndex", func(t *testing.T) {
+		count, err := persistence.ExecuteNonQuery(context.Background(), "",
+			"SELECT 1 FROM pg_indexes WHERE schemaname=$1 AND indexname=$2 AND indexdef LIKE $3",
+			"test_schema", "dummies_extended_content", "%(content, id DESC) INCLUDE (key) WITH (fillfactor='90') WHERE (content IS NOT NULL)")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("DummyPostgresPersistence:EnsureColumn", func(t *testing.T) {
+		count, err := persistence.ExecuteNonQuery(context.Background(), "",
+			"SELECT 1 FROM information_schema.columns WHERE table_schema=$1 AND table_name=$2 AND column_name=$3",
+			"test_schema", "dummies_extended", "updated_at")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("DummyPostgresPersistence:EnsureUpdatedAt", func(t *testing.T) {
+		_, err := persistence.ExecuteNonQuery(context.Background(), "",
+			"INSERT INTO "+persistence.QuotedTableName()+" (\"id\", \"key\", \"updated_at\") VALUES ($1, $2, now() - interval '1 day')",
+			"updated_1", "Updated 1")
+		assert.Nil(t, err)
+
+		_, err = persistence.UpdatePartially(context.Background(), "", "updated_1",
+			*cdata.NewAnyValueMapFromTuples("content", "Updated content"))
+		assert.Nil(t, err)
+
+		count, err := persistence.ExecuteNonQuery(context.Background(), "",
+			"SELECT 1 FROM "+persistence.QuotedTableName()+" WHERE \"id\"=$1 AND \"updated_at\" > now() - interval '1 hour'",
+			"updated_1")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("DummyPostgresPersistence:ValidateSchema", func(t *testing.T) {
+		differences, err := persistence.ValidateSchema(context.Background(), "")
+		assert.Nil(t, err)
+		assert.Empty(t, differences)
+
+		_, err = persistence.ExecuteNonQuery(context.Background(), "",
+			"CREATE TABLE \"test_schema\".\"dummies_drift\" (\"id\" TEXT PRIMARY KEY, \"key\" VARCHAR(20), \"extra\" INTEGER)")
+		assert.Nil(t, err)
+		defer persistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE \"test_schema\".\"dummies_drift\"")
+
+		driftPersistence := &autoDummyPostgresPersistence{}
+		driftPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[tf.Dummy, string](driftPersistence, "dummies_drift")
+		driftPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.auto_create_table", true,
+			"options.schema_validation", persist.SchemaValidationStrict,
+		).SetDefaults(dbConfig))
+
+		err = driftPersistence.Open(context.Background(), "")
+		assert.NotNil(t, err)
+		driftPersistence.Close(context.Background(), "")
+
+		driftPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.auto_create_table", true,
+			"options.schema_validation", persist.SchemaValidationWarn,
+		).SetDefaults(dbConfig))
+		err = driftPersistence.Open(context.Background(), "")
+		assert.Nil(t, err)
+		defer driftPersistence.Close(context.Background(), "")
+
+		differences, err = driftPersistence.ValidateSchema(context.Background(), "")
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []string{
+			"column key has type character varying(20) instead of text",
+			"column content is missing",
+			"column extra is not declared",
+		}, differences)
+	})
+
+	t.Run("DummyPostgresPersistence:AutoCreateTable", func(t *testing.T) {
+		autoPersistence := &autoDummyPostgresPersistence{}
+		autoPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[tf.Dummy, string](autoPersistence, "dummies_auto")
+		autoPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.auto_create_table", true,
+			"options.collation", "C",
+		).SetDefaults(dbConfig))
+
+		err := autoPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer autoPersistence.Close(context.Background(), "")
+		defer autoPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+autoPersistence.QuotedTableName())
+
+		created, err := autoPersistence.Create(context.Background(), "", tf.Dummy{Key: "Auto 1", Content: "Auto content"})
+		assert.Nil(t, err)
+		assert.NotEmpty(t, created.Id)
+
+		item, err := autoPersistence.GetOneById(context.Background(), "", created.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, "Auto 1", item.Key)
+
+		count, err := autoPersistence.ExecuteNonQuery(context.Background(), "",
+			"SELECT 1 FROM information_schema.columns WHERE table_schema=$1 AND table_name=$2 AND collation_name=$3",
+			"test_schema", "dummies_auto", "C")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3), count)
+	})
+
+	t.Run("DummyPostgresPersistence:ConcurrentCreateSchema", func(t *testing.T) {
+		config := cconf.NewConfigParamsFromTuples(
+			"table", "dummies_concurrent",
+			"options.auto_create_table", true,
+		).SetDefaults(dbConfig)
+
+		persistences := make([]*autoDummyPostgresPersistence, 3)
+		errs := make([]error, len(persistences))
+		var wg sync.WaitGroup
+		for index := range persistences {
+			autoPersistence := &autoDummyPostgresPersistence{}
+			autoPersistence.IdentifiablePostgresPersistence =
+				persist.InheritIdentifiablePostgresPersistence[tf.Dummy, string](autoPersistence, "dummies_concurrent")
+			autoPersistence.Configure(context.Background(), config)
+			persistences[index] = autoPersistence
+
+			wg.Add(1)
+			go func(index int) {
+				defer wg.Done()
+				errs[index] = persistences[index].Open(context.Background(), "")
+			}(index)
+		}
+		wg.Wait()
+
+		for index, autoPersistence := range persistences {
+			assert.Nil(t, errs[index])
+			defer autoPersistence.Close(context.Background(), "")
+		}
+		_, err := persistences[0].ExecuteNonQuery(context.Background(), "", "DROP TABLE "+persistences[0].QuotedTableName())
+		assert.Nil(t, err)
+	})
+
+	t.Run("DummyPostgresPersistence:SchemaRollback", func(t *testing.T) {
+		brokenPersistence := &brokenDummyPostgresPersistence{}
+		brokenPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[tf.Dummy, string](brokenPersistence, "dummies_broken")
+		brokenPersistence.Configure(context.Background(), dbConfig)
+
+		err := brokenPersistence.Open(context.Background(), "")
+		assert.NotNil(t, err)
+		brokenPersistence.Close(context.Background(), "")
+
+		count, err := persistence.ExecuteNonQuery(context.Background(), "",
+			"SELECT 1 FROM information_schema.tables WHERE table_schema=$1 AND table_name=$2",
+			"test_schema", "dummies_broken")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("DummyPostgresPersistence:SearchPath", func(t *testing.T) {
+		pathPersistence := &autoDummyPostgresPersistence{}
+		pathPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[tf.Dummy, string](pathPersistence, "dummies_path")
+		pathPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.auto_create_table", true,
+			"options.use_search_path", true,
+		).SetDefaults(dbConfig))
+
+		err := pathPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer pathPersistence.Close(context.Background(), "")
+		defer pathPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE \"dummies_path\"")
+
+		_, err = pathPersistence.Create(context.Background(), "", tf.Dummy{Id: "path_1", Key: "Path 1"})
+		assert.Nil(t, err)
+
+		// The table is created in the schema and custom SQL can refer to it without the schema
+		count, err := persistence.ExecuteNonQuery(context.Background(), "",
+			"SELECT 1 FROM \"test_schema\".\"dummies_path\" WHERE \"id\"=$1", "path_1")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+
+		count, err = pathPersistence.ExecuteNonQuery(context.Background(), "",
+			"UPDATE \"dummies_path\" SET \"content\"=$2 WHERE \"id\"=$1", "path_1", "Path content")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+}
+
+type brokenDummyPostgresPersistence struct {
+	*persist.IdentifiablePostgresPersistence[tf.Dummy, string]
+}
+
+func (c *brokenDummyPostgresPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"key\" TEXT)")
+	c.EnsureIndex(c.TableName+"_missing", map[string]string{"missing": "1"}, nil)
+}
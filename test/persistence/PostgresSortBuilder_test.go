@@ -0,0 +1,85 @@
+package test
+
+import (
+	"testing"
+
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresSortBuilder(t *testing.T) {
+	sort := *cdata.NewSortParams([]cdata.SortField{
+		cdata.NewSortField("key", true),
+		cdata.NewSortField("data.price", false),
+		cdata.NewSortField("name\"; DROP TABLE dummies; --", true),
+	})
+
+	builder := persist.NewPostgresSortBuilder().
+		WithCast("data.price", "numeric").
+		WithCast("key", "text; DROP TABLE dummies")
+	assert.Equal(t, "\"key\" ASC,(\"data\"->>'price')::numeric DESC,\"name\"\"; DROP TABLE dummies; --\" ASC", builder.Build(sort))
+
+	builder = persist.NewPostgresSortBuilder().WithJsonColumn("data", "id")
+	sort = *cdata.NewSortParams([]cdata.SortField{
+		cdata.NewSortField("id", true),
+		cdata.NewSortField("content.text", false),
+	})
+	assert.Equal(t, "\"id\" ASC,\"data\"->'content'->>'text' DESC", builder.Build(sort))
+
+	assert.Equal(t, "", builder.Build(*cdata.NewEmptySortParams()))
+}
+
+func TestPostgresSortBuilderTypes(t *testing.T) {
+	sort := *cdata.NewSortParams([]cdata.SortField{
+		cdata.NewSortField("price", false),
+		cdata.NewSortField("created", true),
+		cdata.NewSortField("name", true),
+		cdata.NewSortField("active", true),
+	})
+
+	builder := persist.NewPostgresSortBuilder().
+		WithJsonColumn("data", "id").
+		WithTypes(map[string]string{
+			"price":   persist.JsonTypeNumber,
+			"created": persist.JsonTypeDateTime,
+			"name":    persist.JsonTypeString,
+			"active":  "unknown",
+		})
+	assert.Equal(t, "(\"data\"->>'price')::numeric DESC,(\"data\"->>'created')::timestamptz ASC,"+
+		"\"data\"->>'name' ASC,\"data\"->>'active' ASC", builder.Build(sort))
+
+	builder = persist.NewPostgresSortBuilder().
+		WithJsonColumn("data", "id").
+		WithFieldColumn("stats.count", "stats_count")
+	assert.Equal(t, "\"stats_count\" DESC",
+		builder.Build(*cdata.NewSortParams([]cdata.SortField{cdata.NewSortField("stats.count", false)})))
+
+	persistence := NewDummyJsonPostgresPersistence()
+	persistence.SetSortTypes(map[string]string{"created": persist.JsonTypeDate})
+	assert.Equal(t, "(\"data\"->>'created')::date ASC",
+		persistence.ComposeSort(*cdata.NewSortParams([]cdata.SortField{cdata.NewSortField("created", true)})))
+}
+
+func TestPostgresSortBuilderNamingStrategy(t *testing.T) {
+	sort := *cdata.NewSortParams([]cdata.SortField{
+		cdata.NewSortField("createdAt", false),
+		cdata.NewSortField("data.createdAt", true),
+	})
+
+	builder := persist.NewPostgresSortBuilder().WithNamingStrategy(persist.NewNamingStrategy("snake_case"))
+	assert.Equal(t, "\"created_at\" DESC,\"data\"->>'createdAt' ASC", builder.Build(sort))
+}
+
+func TestPostgresSortBuilderCollation(t *testing.T) {
+	sort := *cdata.NewSortParams([]cdata.SortField{
+		cdata.NewSortField("name", true),
+		cdata.NewSortField("data.title", false),
+	})
+
+	builder := persist.NewPostgresSortBuilder().
+		WithCollation("name", "C").
+		WithCast("data.title", "text").
+		WithCollation("data.title", "en-x-icu")
+	assert.Equal(t, "\"name\" COLLATE \"C\" ASC,(\"data\"->>'title')::text COLLATE \"en-x-icu\" DESC", builder.Build(sort))
+}
@@ -0,0 +1,26 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresStatementCache(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+
+	columns, values := persistence.GenerateColumnsAndValues(map[string]any{
+		"key": "Key 1", "id": "1", "content": "Content 1",
+	})
+	assert.Equal(t, []string{"content", "id", "key"}, columns)
+	assert.Equal(t, []any{"Content 1", "1", "Key 1"}, values)
+
+	builds := 0
+	build := func() string {
+		builds++
+		return "SELECT 1"
+	}
+	assert.Equal(t, "SELECT 1", persistence.GetStatement("test", build))
+	assert.Equal(t, "SELECT 1", persistence.GetStatement("test", build))
+	assert.Equal(t, 1, builds)
+}
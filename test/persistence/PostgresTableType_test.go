@@ -0,0 +1,54 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresCreateTableClause(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples("schema", "test_schema"))
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS \"test_schema\".\"dummies\"", persistence.CreateTableClause())
+
+	persistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"schema", "test_schema",
+		"options.table_type", "unlogged",
+	))
+	assert.Equal(t, "CREATE UNLOGGED TABLE IF NOT EXISTS \"test_schema\".\"dummies\"", persistence.CreateTableClause())
+
+	persistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"schema", "test_schema",
+		"options.table_type", "temporary",
+	))
+	assert.Equal(t, "CREATE TEMPORARY TABLE IF NOT EXISTS \"dummies\"", persistence.CreateTableClause())
+	assert.Equal(t, "\"dummies\"", persistence.QuotedTableName())
+}
+
+func TestPostgresTableStorageClause(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples())
+	assert.Equal(t, "", persistence.TableStorageClause())
+
+	persistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"options.storage.fillfactor", 70,
+		"options.storage.autovacuum_vacuum_scale_factor", 0.05,
+		"options.storage.toast.autovacuum_enabled", false,
+		"options.storage.bad name", "x",
+		"options.tablespace", "fast",
+	))
+	assert.Equal(t, " WITH (autovacuum_vacuum_scale_factor='0.05', fillfactor='70', toast.autovacuum_enabled='false')"+
+		" TABLESPACE \"fast\"", persistence.TableStorageClause())
+}
+
+func TestPostgresSearchPathTableName(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"schema", "test_schema",
+		"options.use_search_path", true,
+	))
+	assert.Equal(t, "\"dummies\"", persistence.QuotedTableName())
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS \"dummies\"", persistence.CreateTableClause())
+}
@@ -0,0 +1,117 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresTenant(t *testing.T) {
+	dbConfig := newPostgresTestConfig().Override(cconf.NewConfigParamsFromTuples("schema", "test_schema"))
+
+	t.Run("DummyPostgresPersistence:Tenant", func(t *testing.T) {
+		tenantPersistence := &autoDummyPostgresPersistence{}
+		tenantPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[tf.Dummy, string](tenantPersistence, "dummies_tenant_column")
+		tenantPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.auto_create_table", true,
+			"options.tenant_column", "tenant",
+		).SetDefaults(dbConfig))
+
+		err := tenantPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer tenantPersistence.Close(context.Background(), "")
+		defer tenantPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+tenantPersistence.QuotedTableName())
+
+		ctx1 := persist.NewContextWithTenant(context.Background(), "tenant_1")
+		ctx2 := persist.NewContextWithTenant(context.Background(), "tenant_2")
+
+		_, err = tenantPersistence.Create(context.Background(), "", tf.Dummy{Id: "tenant_1", Key: "Key 1"})
+		assert.NotNil(t, err)
+
+		_, err = tenantPersistence.Create(ctx1, "", tf.Dummy{Id: "tenant_1", Key: "Key 1"})
+		assert.Nil(t, err)
+		_, err = tenantPersistence.Create(ctx2, "", tf.Dummy{Id: "tenant_2", Key: "Key 2"})
+		assert.Nil(t, err)
+
+		page, err := tenantPersistence.GetPageByFilter(ctx1, "", "", *cdata.NewEmptyPagingParams(), "", "")
+		assert.Nil(t, err)
+		assert.Len(t, page.Data, 1)
+		assert.Equal(t, "tenant_1", page.Data[0].Id)
+
+		item, err := tenantPersistence.GetOneById(ctx1, "", "tenant_2")
+		assert.Nil(t, err)
+		assert.Equal(t, "", item.Id)
+
+		// Items of other tenants are not changed
+		item, err = tenantPersistence.Set(ctx1, "", tf.Dummy{Id: "tenant_2", Key: "Changed"})
+		assert.Nil(t, err)
+		assert.Equal(t, "", item.Id)
+
+		item, err = tenantPersistence.DeleteById(ctx1, "", "tenant_2")
+		assert.Nil(t, err)
+		assert.Equal(t, "", item.Id)
+
+		item, err = tenantPersistence.GetOneById(ctx2, "", "tenant_2")
+		assert.Nil(t, err)
+		assert.Equal(t, "Key 2", item.Key)
+
+		_, err = tenantPersistence.GetCountByFilter(context.Background(), "", "")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("DummyPostgresPersistence:RowLevelSecurity", func(t *testing.T) {
+		tenantPersistence := &tenantDummyPostgresPersistence{}
+		tenantPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[tf.Dummy, string](tenantPersistence, "dummies_tenant")
+		tenantPersistence.Configure(context.Background(), dbConfig)
+
+		err := tenantPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer tenantPersistence.Close(context.Background(), "")
+		defer tenantPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+tenantPersistence.QuotedTableName())
+
+		tenant1 := persist.NewContextWithSessionSettings(context.Background(), map[string]string{"app.tenant_id": "tenant_1"})
+		tenant2 := persist.NewContextWithSessionSettings(context.Background(), map[string]string{"app.tenant_id": "tenant_2"})
+
+		_, err = tenantPersistence.Create(tenant1, "", tf.Dummy{Id: "rls_1", Key: "tenant_1", Content: "Content 1"})
+		assert.Nil(t, err)
+
+		_, err = tenantPersistence.Create(tenant2, "", tf.Dummy{Id: "rls_2", Key: "tenant_1", Content: "Content 2"})
+		assert.NotNil(t, err)
+
+		item, err := tenantPersistence.GetOneById(tenant1, "", "rls_1")
+		assert.Nil(t, err)
+		assert.Equal(t, "Content 1", item.Content)
+
+		item, err = tenantPersistence.GetOneById(tenant2, "", "rls_1")
+		assert.Nil(t, err)
+		assert.Equal(t, "", item.Id)
+
+		// Settings must not leak to other operations through pooled connections
+		item, err = tenantPersistence.GetOneById(context.Background(), "", "rls_1")
+		assert.Nil(t, err)
+		assert.Equal(t, "", item.Id)
+	})
+}
+
+type tenantDummyPostgresPersistence struct {
+	*persist.IdentifiablePostgresPersistence[tf.Dummy, string]
+}
+
+func (c *tenantDummyPostgresPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"key\" TEXT, \"content\" TEXT)")
+	c.EnsureRowLevelSecurity(true)
+	c.EnsurePolicy(c.TableName+"_tenant", "ALL", "\"key\" = current_setting('app.tenant_id', true)", "")
+}
@@ -0,0 +1,369 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	tf "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresWrites(t *testing.T) {
+	dbConfig := newPostgresTestConfig().Override(cconf.NewConfigParamsFromTuples("schema", "test_schema"))
+
+	t.Run("DummyPostgresPersistence:VersionColumn", func(t *testing.T) {
+		versionPersistence := &versionDummyPostgresPersistence{}
+		versionPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[versionDummy, string](versionPersistence, "dummies_version")
+		versionPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.auto_create_table", true,
+			"options.version_column", "version",
+		).SetDefaults(dbConfig))
+
+		err := versionPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer versionPersistence.Close(context.Background(), "")
+		defer versionPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+versionPersistence.QuotedTableName())
+
+		item, err := versionPersistence.Create(context.Background(), "", versionDummy{Id: "version_1", Key: "Version 1"})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), item.Version)
+
+		item.Key = "Version 2"
+		updated, err := versionPersistence.Update(context.Background(), "", item)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), updated.Version)
+		assert.Equal(t, "Version 2", updated.Key)
+
+		// The item still has the old version
+		_, err = versionPersistence.Update(context.Background(), "", item)
+		assert.NotNil(t, err)
+		assert.Equal(t, "VERSION_CONFLICT", err.(*cerr.ApplicationError).Code)
+
+		updated, err = versionPersistence.UpdatePartially(context.Background(), "", "version_1",
+			*cdata.NewAnyValueMapFromTuples("key", "Version 3"))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), updated.Version)
+
+		_, err = versionPersistence.UpdatePartially(context.Background(), "", "version_1",
+			*cdata.NewAnyValueMapFromTuples("key", "Version 4", "version", 1))
+		assert.NotNil(t, err)
+
+		updated, err = versionPersistence.Update(context.Background(), "", versionDummy{Id: "version_2", Key: "Missing"})
+		assert.Nil(t, err)
+		assert.Equal(t, "", updated.Id)
+	})
+
+	t.Run("DummyPostgresPersistence:Restore", func(t *testing.T) {
+		deletedPersistence := &deletedDummyPostgresPersistence{}
+		deletedPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[deletedDummy, string](deletedPersistence, "dummies_deleted")
+		deletedPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.auto_create_table", true,
+			"options.deleted_column", "deleted_at",
+		).SetDefaults(dbConfig))
+
+		err := deletedPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer deletedPersistence.Close(context.Background(), "")
+		defer deletedPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+deletedPersistence.QuotedTableName())
+
+		deletedAt := time.Now()
+		for _, id := range []string{"deleted_1", "deleted_2", "deleted_3"} {
+			_, err = deletedPersistence.Create(context.Background(), "", deletedDummy{Id: id, Key: id, DeletedAt: &deletedAt})
+			assert.Nil(t, err)
+		}
+
+		item, err := deletedPersistence.RestoreById(context.Background(), "", "deleted_1")
+		assert.Nil(t, err)
+		assert.Equal(t, "deleted_1", item.Id)
+		assert.Nil(t, item.DeletedAt)
+
+		// Items which are not deleted are not restored again
+		item, err = deletedPersistence.RestoreById(context.Background(), "", "deleted_1")
+		assert.Nil(t, err)
+		assert.Equal(t, "", item.Id)
+
+		items, err := deletedPersistence.RestoreByFilterWithParams(context.Background(), "",
+			"\"key\" IN ($1, $2)", []any{"deleted_1", "deleted_2"})
+		assert.Nil(t, err)
+		assert.Len(t, items, 1)
+		assert.Equal(t, "deleted_2", items[0].Id)
+	})
+
+	t.Run("DummyPostgresPersistence:ConflictTarget", func(t *testing.T) {
+		uniquePersistence := &uniqueDummyPostgresPersistence{}
+		uniquePersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[tf.Dummy, string](uniquePersistence, "dummies_unique")
+		uniquePersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.conflict_target", "key",
+			"options.conflict_update_columns", "content",
+		).SetDefaults(dbConfig))
+
+		err := uniquePersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer uniquePersistence.Close(context.Background(), "")
+		defer uniquePersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+uniquePersistence.QuotedTableName())
+
+		_, err = uniquePersistence.Create(context.Background(), "", tf.Dummy{Id: "unique_1", Key: "Key 1", Content: "Content 1"})
+		assert.Nil(t, err)
+
+		// The item is matched by the natural key and keeps its id
+		item, err := uniquePersistence.Set(context.Background(), "", tf.Dummy{Id: "unique_2", Key: "Key 1", Content: "Content 2"})
+		assert.Nil(t, err)
+		assert.Equal(t, "unique_1", item.Id)
+		assert.Equal(t, "Content 2", item.Content)
+
+		items, err := uniquePersistence.SetMany(context.Background(), "", []tf.Dummy{
+			{Id: "unique_3", Key: "Key 1", Content: "Content 3"},
+			{Id: "unique_4", Key: "Key 4", Content: "Content 4"},
+		})
+		assert.Nil(t, err)
+		assert.Len(t, items, 2)
+
+		uniquePersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.conflict_action", "nothing",
+		))
+
+		item, err = uniquePersistence.Set(context.Background(), "", tf.Dummy{Id: "unique_5", Key: "Key 1", Content: "Content 5"})
+		assert.Nil(t, err)
+		assert.Equal(t, "", item.Id)
+
+		item, err = uniquePersistence.GetOneById(context.Background(), "", "unique_1")
+		assert.Nil(t, err)
+		assert.Equal(t, "Content 3", item.Content)
+	})
+
+	t.Run("DummyPostgresPersistence:MergeStrategy", func(t *testing.T) {
+		mergePersistence := &autoDummyPostgresPersistence{}
+		mergePersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[tf.Dummy, string](mergePersistence, "dummies_merge")
+		mergePersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.auto_create_table", true,
+			"options.merge_strategy", persist.MergeStrategyIgnoreEmpty,
+		).SetDefaults(dbConfig))
+
+		err := mergePersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer mergePersistence.Close(context.Background(), "")
+		defer mergePersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+mergePersistence.QuotedTableName())
+
+		_, err = mergePersistence.Create(context.Background(), "", tf.Dummy{Id: "merge_1", Key: "Key 1", Content: "Content 1"})
+		assert.Nil(t, err)
+
+		// Empty fields of sparse items keep stored values
+		item, err := mergePersistence.Update(context.Background(), "", tf.Dummy{Id: "merge_1", Content: "Content 2"})
+		assert.Nil(t, err)
+		assert.Equal(t, "Key 1", item.Key)
+		assert.Equal(t, "Content 2", item.Content)
+
+		item, err = mergePersistence.Set(context.Background(), "", tf.Dummy{Id: "merge_1", Key: "Key 3"})
+		assert.Nil(t, err)
+		assert.Equal(t, "Key 3", item.Key)
+		assert.Equal(t, "Content 2", item.Content)
+	})
+
+	t.Run("DummyPostgresPersistence:SkipReturning", func(t *testing.T) {
+		fastPersistence := &autoDummyPostgresPersistence{}
+		fastPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[tf.Dummy, string](fastPersistence, "dummies_fast")
+		fastPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.auto_create_table", true,
+			"options.skip_returning", true,
+		).SetDefaults(dbConfig))
+
+		err := fastPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer fastPersistence.Close(context.Background(), "")
+		defer fastPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+fastPersistence.QuotedTableName())
+
+		item, err := fastPersistence.Create(context.Background(), "", tf.Dummy{Key: "Key 1", Content: "Content 1"})
+		assert.Nil(t, err)
+		assert.NotEqual(t, "", item.Id)
+
+		item.Content = "Content 2"
+		updated, err := fastPersistence.Update(context.Background(), "", item)
+		assert.Nil(t, err)
+		assert.Equal(t, "Content 2", updated.Content)
+
+		updated, err = fastPersistence.Update(context.Background(), "", tf.Dummy{Id: "fast_missing", Key: "Missing"})
+		assert.Nil(t, err)
+		assert.Equal(t, "", updated.Id)
+
+		deleted, err := fastPersistence.DeleteById(context.Background(), "", item.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, item.Id, deleted.Id)
+
+		deleted, err = fastPersistence.DeleteById(context.Background(), "", item.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, "", deleted.Id)
+	})
+
+	t.Run("DummyPostgresPersistence:NestedUpdate", func(t *testing.T) {
+		nestedPersistence := &nestedDummyPostgresPersistence{}
+		nestedPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[nestedDummy, string](nestedPersistence, "dummies_nested")
+		nestedPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.auto_create_table", true,
+		).SetDefaults(dbConfig))
+
+		err := nestedPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer nestedPersistence.Close(context.Background(), "")
+		defer nestedPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+nestedPersistence.QuotedTableName())
+
+		_, err = nestedPersistence.Create(context.Background(), "", nestedDummy{
+			Id:   "nested_1",
+			Key:  "Key 1",
+			Data: map[string]any{"city": "Berlin", "zip": "10115"},
+		})
+		assert.Nil(t, err)
+
+		item, err := nestedPersistence.UpdatePartially(context.Background(), "", "nested_1",
+			*cdata.NewAnyValueMapFromTuples(
+				"key", "Key 2",
+				"data.city", "Munich",
+				"data.geo.lat", 48.1,
+			))
+		assert.Nil(t, err)
+		assert.Equal(t, "Key 2", item.Key)
+		assert.Equal(t, "Munich", item.Data["city"])
+		assert.Equal(t, "10115", item.Data["zip"])
+		assert.Equal(t, map[string]any{"lat": 48.1}, item.Data["geo"])
+
+		// A column can't be updated as a whole and by nested paths at once
+		_, err = nestedPersistence.UpdatePartially(context.Background(), "", "nested_1",
+			*cdata.NewAnyValueMapFromTuples(
+				"data", map[string]any{},
+				"data.city", "Hamburg",
+			))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("DummyPostgresPersistence:Interceptors", func(t *testing.T) {
+		hookPersistence := &autoDummyPostgresPersistence{}
+		hookPersistence.IdentifiablePostgresPersistence =
+			persist.InheritIdentifiablePostgresPersistence[tf.Dummy, string](hookPersistence, "dummies_hooks")
+		hookPersistence.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+			"options.auto_create_table", true,
+		).SetDefaults(dbConfig))
+		interceptor := &recordingInterceptor{}
+		hookPersistence.AddInterceptor(interceptor)
+
+		err := hookPersistence.Open(context.Background(), "")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer hookPersistence.Close(context.Background(), "")
+		defer hookPersistence.ExecuteNonQuery(context.Background(), "", "DROP TABLE "+hookPersistence.QuotedTableName())
+
+		// Before hooks change items
+		item, err := hookPersistence.Create(context.Background(), "", tf.Dummy{Id: "hook_1", Key: "Key 1", Content: "content"})
+		assert.Nil(t, err)
+		assert.Equal(t, "CONTENT", item.Content)
+
+		_, err = hookPersistence.Update(context.Background(), "", tf.Dummy{Id: "hook_missing", Key: "Missing"})
+		assert.Nil(t, err)
+
+		// Before hooks veto operations
+		_, err = hookPersistence.DeleteById(context.Background(), "", "hook_1")
+		assert.NotNil(t, err)
+		item, err = hookPersistence.GetOneById(context.Background(), "", "hook_1")
+		assert.Nil(t, err)
+		assert.Equal(t, "hook_1", item.Id)
+
+		_, err = hookPersistence.Create(context.Background(), "", tf.Dummy{Id: "hook_2", Key: "Key 2"})
+		assert.Nil(t, err)
+		err = hookPersistence.DeleteByIds(context.Background(), "", []string{"hook_2"})
+		assert.Nil(t, err)
+
+		// After hooks see only changed items
+		assert.Equal(t, []string{"created hook_1", "created hook_2", "deleted hook_2"}, interceptor.events)
+	})
+}
+
+type deletedDummy struct {
+	Id        string     `json:"id"`
+	Key       string     `json:"key"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+type deletedDummyPostgresPersistence struct {
+	*persist.IdentifiablePostgresPersistence[deletedDummy, string]
+}
+
+type versionDummy struct {
+	Id      string `json:"id"`
+	Key     string `json:"key"`
+	Version int64  `json:"version"`
+}
+
+type versionDummyPostgresPersistence struct {
+	*persist.IdentifiablePostgresPersistence[versionDummy, string]
+}
+
+type nestedDummyPostgresPersistence struct {
+	*persist.IdentifiablePostgresPersistence[nestedDummy, string]
+}
+
+type uniqueDummyPostgresPersistence struct {
+	*persist.IdentifiablePostgresPersistence[tf.Dummy, string]
+}
+
+func (c *uniqueDummyPostgresPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"key\" TEXT UNIQUE, \"content\" TEXT)")
+}
+
+// recordingInterceptor upper-cases created contents, protects the hook_1 item
+// from deletion and records changes.
+type recordingInterceptor struct {
+	persist.PostgresInterceptor[tf.Dummy]
+	events []string
+}
+
+func (c *recordingInterceptor) BeforeCreate(ctx context.Context, correlationId string, item tf.Dummy) (tf.Dummy, error) {
+	item.Content = strings.ToUpper(item.Content)
+	return item, nil
+}
+
+func (c *recordingInterceptor) AfterCreate(ctx context.Context, correlationId string, item tf.Dummy) error {
+	c.events = append(c.events, "created "+item.Id)
+	return nil
+}
+
+func (c *recordingInterceptor) AfterUpdate(ctx context.Context, correlationId string, item tf.Dummy) error {
+	c.events = append(c.events, "updated "+item.Id)
+	return nil
+}
+
+func (c *recordingInterceptor) BeforeDelete(ctx context.Context, correlationId string, id any) error {
+	if id == "hook_1" {
+		return cerr.NewBadRequestError(correlationId, "PROTECTED", "Item is protected")
+	}
+	return nil
+}
+
+func (c *recordingInterceptor) AfterDelete(ctx context.Context, correlationId string, item tf.Dummy) error {
+	c.events = append(c.events, "deleted "+item.Id)
+	return nil
+}
@@ -0,0 +1,23 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	persist "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionSettingsContext(t *testing.T) {
+	assert.Empty(t, persist.SessionSettingsFromContext(context.Background()))
+
+	ctx := persist.NewContextWithSessionSettings(context.Background(),
+		map[string]string{"app.tenant_id": "1", "app.user_id": "2"})
+	ctx = persist.NewContextWithSessionSettings(ctx, map[string]string{"app.tenant_id": "3"})
+
+	settings := persist.SessionSettingsFromContext(ctx)
+	assert.Equal(t, map[string]string{"app.tenant_id": "3", "app.user_id": "2"}, settings)
+
+	settings["app.user_id"] = "4"
+	assert.Equal(t, "2", persist.SessionSettingsFromContext(ctx)["app.user_id"])
+}
@@ -0,0 +1,136 @@
+package test_state
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	cref "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	conn "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/connect"
+	pstate "github.com/pip-services3-gox/pip-services3-postgres-gox/v2/state"
+	"github.com/stretchr/testify/assert"
+)
+
+type dummyState struct {
+	Name    string `json:"name"`
+	Counter int    `json:"counter"`
+}
+
+func TestPostgresStateStoreWithClosedConnection(t *testing.T) {
+	store := pstate.NewPostgresStateStore[dummyState]()
+
+	_, _, err := store.LoadVersion(context.Background(), "", "key_closed")
+	assert.NotNil(t, err)
+	_, err = store.SaveVersion(context.Background(), "", "key_closed", dummyState{Name: "ABC"}, 0)
+	assert.NotNil(t, err)
+	assert.Equal(t, dummyState{}, store.Save(context.Background(), "", "key_closed", dummyState{Name: "ABC"}))
+	assert.Len(t, store.LoadBulk(context.Background(), "", []string{"key_closed"}), 0)
+}
+
+func TestPostgresStateStore(t *testing.T) {
+	postgresUri := os.Getenv("POSTGRES_URI")
+	postgresHost := os.Getenv("POSTGRES_HOST")
+	if postgresHost == "" {
+		postgresHost = "localhost"
+	}
+	postgresPort := os.Getenv("POSTGRES_PORT")
+	if postgresPort == "" {
+		postgresPort = "5432"
+	}
+	postgresDatabase := os.Getenv("POSTGRES_DB")
+	if postgresDatabase == "" {
+		postgresDatabase = "test"
+	}
+	postgresUser := os.Getenv("POSTGRES_USER")
+	if postgresUser == "" {
+		postgresUser = "postgres"
+	}
+	postgresPassword := os.Getenv("POSTGRES_PASSWORD")
+	if postgresPassword == "" {
+		postgresPassword = "postgres#"
+	}
+
+	dbConfig := cconf.NewConfigParamsFromTuples(
+		"connection.uri", postgresUri,
+		"connection.host", postgresHost,
+		"connection.port", postgresPort,
+		"connection.database", postgresDatabase,
+		"credential.username", postgresUser,
+		"credential.password", postgresPassword,
+	)
+
+	connection := conn.NewPostgresConnection()
+	connection.Configure(context.Background(), dbConfig)
+	err := connection.Open(context.Background(), "")
+	if err != nil {
+		t.Error("Error opened connection", err)
+		return
+	}
+	defer connection.Close(context.Background(), "")
+
+	references := cref.NewReferencesFromTuples(context.Background(),
+		cref.NewDescriptor("pip-services", "connection", "postgres", "default", "1.0"), connection)
+
+	store := pstate.NewPostgresStateStore[dummyState]()
+	store.Configure(context.Background(), cconf.NewConfigParamsFromTuples("table", "test_state"))
+	store.SetReferences(context.Background(), references)
+	assert.Nil(t, store.Open(context.Background(), ""))
+	defer store.Close(context.Background(), "")
+
+	t.Run("SaveAndLoad", func(t *testing.T) {
+		store.Delete(context.Background(), "", "key1")
+		store.Delete(context.Background(), "", "key2")
+
+		state1 := dummyState{Name: "ABC", Counter: 1}
+		assert.Equal(t, state1, store.Save(context.Background(), "", "key1", state1))
+		assert.Equal(t, state1, store.Load(context.Background(), "", "key1"))
+
+		state2 := dummyState{Name: "XYZ", Counter: 2}
+		store.Save(context.Background(), "", "key2", state2)
+		states := store.LoadBulk(context.Background(), "", []string{"key1", "key2", "key_missing"})
+		assert.Len(t, states, 2)
+
+		assert.Equal(t, dummyState{}, store.Load(context.Background(), "", "key_missing"))
+
+		assert.Equal(t, state1, store.Delete(context.Background(), "", "key1"))
+		assert.Equal(t, dummyState{}, store.Load(context.Background(), "", "key1"))
+		store.Delete(context.Background(), "", "key2")
+	})
+
+	t.Run("OptimisticConcurrency", func(t *testing.T) {
+		store.Delete(context.Background(), "", "key3")
+
+		state, version, err := store.LoadVersion(context.Background(), "", "key3")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), version)
+
+		state.Counter++
+		version, err = store.SaveVersion(context.Background(), "", "key3", state, version)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), version)
+
+		// Creating an existing state conflicts
+		_, err = store.SaveVersion(context.Background(), "", "key3", state, 0)
+		assert.NotNil(t, err)
+		assert.Equal(t, cerr.Conflict, err.(*cerr.ApplicationError).Category)
+
+		state, version, err = store.LoadVersion(context.Background(), "", "key3")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, state.Counter)
+
+		// Saving a state changed since it was loaded conflicts
+		store.Save(context.Background(), "", "key3", dummyState{Counter: 10})
+		state.Counter++
+		_, err = store.SaveVersion(context.Background(), "", "key3", state, version)
+		assert.NotNil(t, err)
+
+		state, version, err = store.LoadVersion(context.Background(), "", "key3")
+		assert.Nil(t, err)
+		assert.Equal(t, 10, state.Counter)
+		assert.Equal(t, int64(2), version)
+
+		store.Delete(context.Background(), "", "key3")
+	})
+}